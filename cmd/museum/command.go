@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"museum/cmd/tool"
+	"os"
+	"strings"
+)
+
+// invocation is everything a command gets to run with
+type invocation struct {
+	opts tool.Options
+	args []string
+	out  printer
+}
+
+type command struct {
+	name        string
+	args        string
+	description string
+	// minArgs is the number of positional arguments the command needs
+	minArgs int
+	// local commands don't talk to a server, they have no server or output flags
+	local       bool
+	flags       func(fs *flag.FlagSet)
+	run         func(inv invocation) error
+	subcommands []*command
+}
+
+func (c *command) usage(parent string) {
+	path := strings.TrimSpace(parent + " " + c.name)
+
+	if len(c.subcommands) > 0 {
+		fmt.Println("Usage: " + path + " <command>")
+		fmt.Println(c.description)
+		printCommands(c.subcommands)
+		fmt.Println()
+		fmt.Println("Run '" + path + " <command> --help' for the flags of a command")
+		return
+	}
+
+	fmt.Println("Usage: " + strings.TrimSpace(path+" "+c.args) + " [flags]")
+	fmt.Println(c.description)
+}
+
+func printCommands(commands []*command) {
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		fmt.Println("\t" + strings.TrimSpace(c.name+" "+c.args))
+		fmt.Println("\t- " + c.description)
+	}
+}
+
+func findCommand(commands []*command, name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// execute parses the flags and arguments of a command (flags may come after the arguments, e.g. delete <id> --force) and runs it
+func (c *command) execute(parent string, args []string) error {
+	path := strings.TrimSpace(parent + " " + c.name)
+
+	if len(c.subcommands) > 0 {
+		if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+			c.usage(parent)
+			return nil
+		}
+
+		sub := findCommand(c.subcommands, args[0])
+		if sub == nil {
+			c.usage(parent)
+			return errors.New("unknown command " + path + " " + args[0])
+		}
+
+		return sub.execute(path, args[1:])
+	}
+
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	inv := invocation{}
+	if !c.local {
+		fs.StringVar(&inv.opts.Server, "server", "", "URL of the museum server, overrides $MUSEUM_SERVER and the context")
+		fs.StringVar(&inv.opts.Context, "context", "", "context of the config file to use, overrides $MUSEUM_CONTEXT")
+		fs.StringVar(&inv.opts.ConfigFile, "config", "", "path of the config file (default "+tool.DefaultConfigFile()+")")
+		fs.StringVar(&inv.out.format, "o", "", "output format, one of "+strings.Join(formats, ", "))
+		fs.StringVar(&inv.out.format, "output", "", "output format, same as -o")
+	}
+
+	if c.flags != nil {
+		c.flags(fs)
+	}
+
+	fs.Usage = func() {
+		c.usage(parent)
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	positional := make([]string, 0)
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return err
+		}
+
+		if fs.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) < c.minArgs {
+		fs.Usage()
+		return errors.New("missing arguments, expected " + c.args)
+	}
+
+	err := inv.out.validate()
+	if err != nil {
+		return err
+	}
+
+	inv.args = positional
+	return c.run(inv)
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"museum/cmd/server"
+	"museum/cmd/tool"
+	"museum/domain"
+	"strconv"
+	"time"
+)
+
+func commands() []*command {
+	return []*command{
+		serverCommand(),
+		createCommand(),
+		validateCommand(),
+		getCommand(),
+		updateCommand(),
+		historyCommand(),
+		rollbackCommand(),
+		deleteCommand(),
+		listCommand(),
+		renewCommand(),
+		startCommand(),
+		stopCommand(),
+		restartCommand(),
+		warmupCommand(),
+		contextCommand(),
+	}
+}
+
+func serverCommand() *command {
+	return &command{
+		name:        "server",
+		description: "Starts the mūsēum API and proxy server",
+		local:       true,
+		run: func(inv invocation) error {
+			server.Run()
+			return nil
+		},
+	}
+}
+
+// exhibitResult prints an exhibit, the message is the output for humans
+func exhibitResult(exhibit *domain.ExhibitDto, message string) result {
+	return result{
+		value: exhibit,
+		table: exhibitTable{*exhibit},
+		pretty: func() {
+			fmt.Println(message)
+			fmt.Println("‎‎‎👉 " + exhibit.Url)
+		},
+	}
+}
+
+func createCommand() *command {
+	return &command{
+		name:        "create",
+		args:        "<file>",
+		description: "Creates a new exhibit",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Create(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "🧑‍🎨 exhibit "+exhibit.Name+" created successfully"))
+		},
+	}
+}
+
+func validateCommand() *command {
+	var remote bool
+
+	return &command{
+		name:        "validate",
+		args:        "<file>",
+		description: "Checks an exhibit file and lists all of its problems, without creating anything",
+		minArgs:     1,
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&remote, "remote", false, "validate on the server, which also checks the name and the volume drivers")
+		},
+		run: func(inv invocation) error {
+			file := inv.args[0]
+
+			r, err := tool.Validate(inv.opts, file, remote)
+			if err != nil {
+				return err
+			}
+
+			err = inv.out.print(result{
+				value: r,
+				table: problemTable(r.Problems),
+				pretty: func() {
+					for _, problem := range r.Problems {
+						fmt.Println(file + ":" + problem.String())
+					}
+
+					errs, warnings := len(r.Problems.Errors()), len(r.Problems.Warnings())
+					if r.Valid {
+						fmt.Println("✅ " + file + " is valid, " + strconv.Itoa(warnings) + " warning(s)")
+					} else {
+						fmt.Println("❌ " + file + " is invalid, " + strconv.Itoa(errs) + " error(s), " + strconv.Itoa(warnings) + " warning(s)")
+					}
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			if !r.Valid {
+				return errFailed
+			}
+
+			return nil
+		},
+	}
+}
+
+func getCommand() *command {
+	return &command{
+		name:        "get",
+		args:        "<id>",
+		description: "Shows an exhibit",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Get(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(result{
+				value:  exhibit,
+				table:  exhibitTable{*exhibit},
+				pretty: func() { printExhibits([]domain.ExhibitDto{*exhibit}) },
+			})
+		},
+	}
+}
+
+func updateCommand() *command {
+	return &command{
+		name:        "update",
+		args:        "<id> <file>",
+		description: "Updates an exhibit, a running exhibit is restarted",
+		minArgs:     2,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Update(inv.opts, inv.args[0], inv.args[1])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "🧑‍🎨 exhibit "+exhibit.Name+" updated to revision "+strconv.Itoa(exhibit.Revision)))
+		},
+	}
+}
+
+func historyCommand() *command {
+	return &command{
+		name:        "history",
+		args:        "<id>",
+		description: "Lists the revisions of an exhibit with their changes",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			revisions, err := tool.History(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(result{
+				value: revisions,
+				table: revisionTable(revisions),
+				pretty: func() {
+					for _, r := range revisions {
+						printSeparator()
+
+						fmt.Println("📜  revision " + strconv.Itoa(r.Revision) + " by " + r.Author + ", " + time.Unix(r.Timestamp, 0).Format(time.RFC1123))
+						if r.Comment != "" {
+							fmt.Println("    💬  " + r.Comment)
+						}
+						if r.Diff != "" {
+							fmt.Println()
+							fmt.Print(r.Diff)
+						}
+					}
+
+					printSeparator()
+				},
+			})
+		},
+	}
+}
+
+func rollbackCommand() *command {
+	return &command{
+		name:        "rollback",
+		args:        "<id> <revision>",
+		description: "Rolls an exhibit back to a revision, the rollback is stored as a new revision",
+		minArgs:     2,
+		run: func(inv invocation) error {
+			revision, err := strconv.Atoi(inv.args[1])
+			if err != nil {
+				return errors.New("revision must be a number")
+			}
+
+			exhibit, err := tool.Rollback(inv.opts, inv.args[0], revision)
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "⏪ exhibit "+exhibit.Name+" rolled back to revision "+inv.args[1]+", now at revision "+strconv.Itoa(exhibit.Revision)))
+		},
+	}
+}
+
+func deleteCommand() *command {
+	var force bool
+
+	return &command{
+		name:        "delete",
+		args:        "<id>",
+		description: "Deletes an exhibit",
+		minArgs:     1,
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&force, "force", false, "delete the exhibit even while it is starting")
+		},
+		run: func(inv invocation) error {
+			err := tool.Delete(inv.opts, inv.args[0], force)
+			if err != nil {
+				return err
+			}
+
+			status := statusTable{Id: inv.args[0], Status: "deleted"}
+			return inv.out.print(result{
+				value:  status,
+				table:  status,
+				pretty: func() { fmt.Println("‎‎‎🗑️ exhibit deleted successfully") },
+			})
+		},
+	}
+}
+
+func printExhibits(exhibits []domain.ExhibitDto) {
+	for _, e := range exhibits {
+		printSeparator()
+
+		fmt.Println("🧮  " + e.Name)
+		fmt.Print("    ")
+		if e.RuntimeInfo.Status == domain.Running {
+			fmt.Print("🟢 ")
+		} else {
+			fmt.Print("🔴 ")
+		}
+		fmt.Println(" " + e.Url)
+		fmt.Println("    ⏰‎  " + expiry(e))
+
+		fmt.Println("    🧺  exhibits:")
+		for _, o := range e.Objects {
+			fmt.Println("        📜  " + o.Name + " (" + o.Image + ")")
+		}
+	}
+
+	printSeparator()
+}
+
+func listCommand() *command {
+	var asJson bool
+
+	return &command{
+		name:        "list",
+		description: "Lists all exhibits",
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&asJson, "json", false, "same as -o json, kept for scripts written against older versions")
+		},
+		run: func(inv invocation) error {
+			exhibits, err := tool.List(inv.opts)
+			if err != nil {
+				return err
+			}
+
+			if asJson {
+				inv.out.format = "json"
+			}
+
+			return inv.out.print(result{
+				value:  exhibits,
+				table:  exhibitTable(exhibits),
+				pretty: func() { printExhibits(exhibits) },
+			})
+		},
+	}
+}
+
+func renewCommand() *command {
+	return &command{
+		name:        "renew",
+		args:        "<id>",
+		description: "Renews the lease on a running exhibit",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Renew(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "‎‎‎⏲ exhibit lease renewed successfully, "+expiry(*exhibit)))
+		},
+	}
+}
+
+func startCommand() *command {
+	return &command{
+		name:        "start",
+		args:        "<id>",
+		description: "Starts an exhibit in the background",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Start(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "‎‎‎▶️ exhibit is starting"))
+		},
+	}
+}
+
+func stopCommand() *command {
+	return &command{
+		name:        "stop",
+		args:        "<id>",
+		description: "Stops a running exhibit",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Stop(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(result{
+				value:  exhibit,
+				table:  exhibitTable{*exhibit},
+				pretty: func() { fmt.Println("‎‎‎⏹️ exhibit " + exhibit.Name + " stopped") },
+			})
+		},
+	}
+}
+
+func restartCommand() *command {
+	return &command{
+		name:        "restart",
+		args:        "<id>",
+		description: "Stops a running exhibit and starts it again",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			exhibit, err := tool.Restart(inv.opts, inv.args[0])
+			if err != nil {
+				return err
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "‎‎‎🔄 exhibit is restarting"))
+		},
+	}
+}
+
+func warmupCommand() *command {
+	var wait bool
+
+	return &command{
+		name:        "warmup",
+		args:        "<id>",
+		description: "Warms up an exhibit",
+		minArgs:     1,
+		flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&wait, "wait", false, "show the progress until the exhibit is running, fails if it doesn't start")
+		},
+		run: func(inv invocation) error {
+			if !wait {
+				exhibit, err := tool.Warmup(inv.opts, inv.args[0])
+				if err != nil {
+					return err
+				}
+
+				return inv.out.print(exhibitResult(exhibit, "‎‎‎🔥 exhibit warmed up successfully"))
+			}
+
+			// the progress would garble machine readable output
+			p := newProgress()
+			onStep := p.update
+			if inv.out.machine() {
+				onStep = p.record
+			}
+
+			exhibit, err := tool.WarmupAndWait(inv.opts, inv.args[0], onStep)
+			if err != nil {
+				return err
+			}
+
+			if p.failed() {
+				return errors.New(p.err)
+			}
+
+			return inv.out.print(exhibitResult(exhibit, "‎‎‎🔥 exhibit is running"))
+		},
+	}
+}
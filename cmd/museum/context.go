@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"museum/cmd/tool"
+)
+
+// configFile is the config file the context commands work on, --config wins over the default one
+func configFile(inv invocation) string {
+	if inv.opts.ConfigFile != "" {
+		return inv.opts.ConfigFile
+	}
+
+	return tool.DefaultConfigFile()
+}
+
+type contextTable struct {
+	config *tool.Config
+}
+
+func (t contextTable) header(wide bool) []string {
+	header := []string{"CURRENT", "NAME", "SERVER"}
+	if wide {
+		header = append(header, "CA")
+	}
+	return header
+}
+
+func (t contextTable) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(t.config.Contexts))
+	for _, c := range t.config.Contexts {
+		current := ""
+		if c.Name == t.config.CurrentContext {
+			current = "*"
+		}
+
+		row := []string{current, c.Name, c.Server}
+		if wide {
+			row = append(row, c.CertificateAuthority)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func contextCommand() *command {
+	return &command{
+		name:        "context",
+		description: "Manages the museum servers in the config file",
+		subcommands: []*command{
+			contextListCommand(),
+			contextUseCommand(),
+			contextSetCommand(),
+			contextDeleteCommand(),
+		},
+	}
+}
+
+func contextListCommand() *command {
+	return &command{
+		name:        "list",
+		description: "Lists the contexts, the current one is marked with *",
+		run: func(inv invocation) error {
+			config, err := tool.LoadConfig(configFile(inv))
+			if err != nil {
+				return err
+			}
+
+			t := contextTable{config: config}
+			return inv.out.print(result{
+				value: config,
+				table: t,
+				pretty: func() {
+					for _, c := range config.Contexts {
+						marker := "  "
+						if c.Name == config.CurrentContext {
+							marker = "👉"
+						}
+						fmt.Println(marker + " " + c.Name + " (" + c.Server + ")")
+					}
+				},
+			})
+		},
+	}
+}
+
+func contextUseCommand() *command {
+	return &command{
+		name:        "use",
+		args:        "<name>",
+		description: "Makes a context the current one",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			path := configFile(inv)
+			config, err := tool.LoadConfig(path)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := config.GetContext(inv.args[0]); !ok {
+				return errors.New("context " + inv.args[0] + " not found in " + path)
+			}
+
+			config.CurrentContext = inv.args[0]
+			err = config.Save(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("👉 switched to context " + inv.args[0])
+			return nil
+		},
+	}
+}
+
+func contextSetCommand() *command {
+	var token, ca string
+
+	return &command{
+		name:        "set",
+		args:        "<name> <server>",
+		description: "Adds a context or replaces it",
+		minArgs:     2,
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&token, "token", "", "token sent as bearer token to the server")
+			fs.StringVar(&ca, "ca", "", "path of a PEM file with the certificate authority of the server")
+		},
+		run: func(inv invocation) error {
+			path := configFile(inv)
+			config, err := tool.LoadConfig(path)
+			if err != nil {
+				return err
+			}
+
+			context := tool.Context{Name: inv.args[0], Server: inv.args[1], Token: token, CertificateAuthority: ca}
+
+			replaced := false
+			for i, c := range config.Contexts {
+				if c.Name == context.Name {
+					config.Contexts[i] = context
+					replaced = true
+				}
+			}
+			if !replaced {
+				config.Contexts = append(config.Contexts, context)
+			}
+
+			// the first context is the one you want to use
+			if config.CurrentContext == "" {
+				config.CurrentContext = context.Name
+			}
+
+			err = config.Save(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("💾 context " + context.Name + " saved to " + path)
+			return nil
+		},
+	}
+}
+
+func contextDeleteCommand() *command {
+	return &command{
+		name:        "delete",
+		args:        "<name>",
+		description: "Removes a context",
+		minArgs:     1,
+		run: func(inv invocation) error {
+			path := configFile(inv)
+			config, err := tool.LoadConfig(path)
+			if err != nil {
+				return err
+			}
+
+			contexts := make([]tool.Context, 0, len(config.Contexts))
+			for _, c := range config.Contexts {
+				if c.Name != inv.args[0] {
+					contexts = append(contexts, c)
+				}
+			}
+
+			if len(contexts) == len(config.Contexts) {
+				return errors.New("context " + inv.args[0] + " not found in " + path)
+			}
+
+			config.Contexts = contexts
+			if config.CurrentContext == inv.args[0] {
+				config.CurrentContext = ""
+			}
+
+			err = config.Save(path)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("🗑️ context " + inv.args[0] + " deleted")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hako/durafmt"
+	"gopkg.in/yaml.v3"
+	"museum/domain"
+	"museum/util"
+	"museum/validation"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// formats are the values of -o, the default output is meant for humans and prints no format at all
+var formats = []string{"json", "yaml", "table", "wide"}
+
+// table is a result that can be printed as a table, wide adds columns that don't fit on a narrow terminal
+type table interface {
+	header(wide bool) []string
+	rows(wide bool) [][]string
+}
+
+// result is what a command prints, value is printed for json and yaml
+type result struct {
+	value  any
+	table  table
+	pretty func()
+}
+
+type printer struct {
+	format string
+}
+
+func (p printer) validate() error {
+	if p.format == "" {
+		return nil
+	}
+
+	for _, format := range formats {
+		if p.format == format {
+			return nil
+		}
+	}
+
+	return errors.New("unknown output format " + p.format + ", must be one of " + strings.Join(formats, ", "))
+}
+
+// machine reports whether the output is read by a program, progress and hints must not be printed then
+func (p printer) machine() bool {
+	return p.format != ""
+}
+
+func (p printer) print(r result) error {
+	switch p.format {
+	case "json":
+		b, err := json.MarshalIndent(r.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	case "yaml":
+		b, err := toYaml(r.value)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(b))
+	case "table", "wide":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		wide := p.format == "wide"
+		fmt.Fprintln(w, strings.Join(r.table.header(wide), "\t"))
+		for _, row := range r.table.rows(wide) {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	default:
+		r.pretty()
+	}
+
+	return nil
+}
+
+// toYaml converts a value to yaml using its json field names, the dtos only have json tags
+func toYaml(value any) ([]byte, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// json is valid yaml, decoding it into a node keeps the order of the fields
+	node := &yaml.Node{}
+	err = yaml.Unmarshal(b, node)
+	if err != nil {
+		return nil, err
+	}
+
+	blockStyle(node)
+	return yaml.Marshal(node)
+}
+
+// blockStyle makes a node decoded from json print as regular yaml instead of json
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func printSeparator() {
+	w := util.GetTerminalWidth() / 2
+	for i := 0; i < w; i++ {
+		fmt.Print("─")
+	}
+	fmt.Println()
+}
+
+// expiry describes when the lease of an exhibit runs out, or since when it has expired
+func expiry(e domain.ExhibitDto) string {
+	if e.RuntimeInfo.Status != domain.Running {
+		return "expired " + durafmt.Parse(time.Since(time.Unix(e.RuntimeInfo.LastAccessed, 0)).Truncate(time.Second)).String() + " ago"
+	}
+
+	d, err := time.ParseDuration(e.Lease)
+	if err != nil {
+		return "unknown"
+	}
+
+	return "expires in " + durafmt.Parse(time.Until(time.Unix(e.RuntimeInfo.LastAccessed, 0).Add(d)).Truncate(time.Second)).String()
+}
+
+type exhibitTable []domain.ExhibitDto
+
+func (t exhibitTable) header(wide bool) []string {
+	header := []string{"ID", "NAME", "STATUS", "URL"}
+	if wide {
+		header = append(header, "REVISION", "LEASE", "EXPIRY", "OBJECTS")
+	}
+	return header
+}
+
+func (t exhibitTable) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(t))
+	for _, e := range t {
+		row := []string{e.Id, e.Name, string(e.RuntimeInfo.Status), e.Url}
+		if wide {
+			objects := make([]string, 0, len(e.Objects))
+			for _, o := range e.Objects {
+				objects = append(objects, o.Name+"="+o.Image+":"+o.Label)
+			}
+			row = append(row, strconv.Itoa(e.Revision), e.Lease, expiry(e), strings.Join(objects, ","))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type revisionTable []domain.ExhibitRevisionDto
+
+func (t revisionTable) header(wide bool) []string {
+	header := []string{"REVISION", "AUTHOR", "TIME", "COMMENT"}
+	if wide {
+		header = append(header, "ADDED", "REMOVED")
+	}
+	return header
+}
+
+func (t revisionTable) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(t))
+	for _, r := range t {
+		row := []string{strconv.Itoa(r.Revision), r.Author, time.Unix(r.Timestamp, 0).Format(time.RFC3339), r.Comment}
+		if wide {
+			added, removed := diffStat(r.Diff)
+			row = append(row, strconv.Itoa(added), strconv.Itoa(removed))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// diffStat counts the added and removed lines of a unified diff
+func diffStat(diff string) (added int, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// statusTable is the result of a command that leaves nothing to show but the exhibit it acted on
+type statusTable struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (t statusTable) header(bool) []string {
+	return []string{"ID", "STATUS"}
+}
+
+func (t statusTable) rows(bool) [][]string {
+	return [][]string{{t.Id, t.Status}}
+}
+
+type problemTable validation.Problems
+
+func (t problemTable) header(wide bool) []string {
+	header := []string{"SEVERITY", "LINE", "MESSAGE"}
+	if wide {
+		header = append(header, "COLUMN", "PATH")
+	}
+	return header
+}
+
+func (t problemTable) rows(wide bool) [][]string {
+	rows := make([][]string, 0, len(t))
+	for _, p := range t {
+		row := []string{string(p.Severity), strconv.Itoa(p.Line), p.Message}
+		if wide {
+			row = append(row, strconv.Itoa(p.Column), p.Path)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
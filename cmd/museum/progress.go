@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"museum/domain"
+	"strconv"
+	"strings"
+)
+
+// progress renders a live progress bar per object of an exhibit while it is starting
+type progress struct {
+	objects  []string
+	steps    map[string]domain.ObjectStartingStep
+	current  int
+	total    int
+	err      string
+	rendered int
+}
+
+func newProgress() *progress {
+	return &progress{
+		objects: make([]string, 0),
+		steps:   make(map[string]domain.ObjectStartingStep),
+	}
+}
+
+// update records a step and renders the progress again
+func (p *progress) update(step domain.ExhibitStartingStepEvent) {
+	p.record(step)
+	p.render()
+}
+
+// record records a step, objects show up in the order they are started in
+func (p *progress) record(step domain.ExhibitStartingStepEvent) {
+	if step.Error != "" {
+		p.err = step.Error
+		return
+	}
+
+	if _, ok := p.steps[step.Object]; !ok {
+		p.objects = append(p.objects, step.Object)
+	}
+
+	for s := domain.ObjectStartingStepClean; s <= domain.ObjectStartingStepReady; s++ {
+		if s.String() == step.Step {
+			p.steps[step.Object] = s
+		}
+	}
+
+	p.current = step.CurrentStepCount
+	p.total = step.TotalStepCount
+}
+
+func (p *progress) failed() bool {
+	return p.err != ""
+}
+
+func (p *progress) render() {
+	// move the cursor back up to overwrite the last rendering
+	if p.rendered > 0 {
+		fmt.Printf("\033[%dA", p.rendered)
+	}
+
+	lines := make([]string, 0, len(p.objects)+2)
+	for _, object := range p.objects {
+		step := p.steps[object]
+		lines = append(lines, "    📜  "+object+" "+bar(int(step)+1, int(domain.ObjectStartingStepReady)+1)+" "+step.String())
+	}
+
+	lines = append(lines, "    ⏳  "+bar(p.current, p.total)+" "+strconv.Itoa(p.current)+"/"+strconv.Itoa(p.total)+" steps")
+	if p.err != "" {
+		lines = append(lines, "    ❌  "+p.err)
+	}
+
+	for _, line := range lines {
+		fmt.Print("\033[2K")
+		fmt.Println(line)
+	}
+
+	p.rendered = len(lines)
+}
+
+func bar(done int, total int) string {
+	const width = 20
+
+	filled := 0
+	if total > 0 {
+		filled = min(done, total) * width / total
+	}
+
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
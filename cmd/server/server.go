@@ -9,11 +9,13 @@ import (
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
 	"museum/config"
 	proxymode "museum/config/proxy-mode"
 	"museum/controller/api"
 	"museum/controller/exhibit"
 	"museum/controller/health"
+	"museum/controller/metrics"
 	"museum/http"
 	"museum/ioc"
 	"museum/observability"
@@ -38,8 +40,16 @@ func Run() {
 	ioc.RegisterSingleton[config.Config](c, config.NewEnvConfig)
 	cfg := ioc.Get[config.Config](c)
 
-	// register docker
-	ioc.RegisterSingleton[*docker.Client](c, service.NewDockerClient)
+	// register docker, on kubernetes there is no docker daemon to talk to
+	if cfg.GetProxyMode() == proxymode.ModeK8s {
+		ioc.RegisterSingleton[kubernetes.Interface](c, service.NewKubernetesClient)
+		ioc.RegisterSingleton[*docker.Client](c, func() *docker.Client { return nil })
+	} else {
+		ioc.RegisterSingleton[*docker.Client](c, service.NewDockerClient)
+	}
+
+	// register prometheus metrics
+	ioc.RegisterSingleton[*observability.Metrics](c, observability.NewMetrics)
 
 	// register jaeger
 	ioc.RegisterSingleton[tracesdk.SpanExporter](c, observability.NewSpanExporter)
@@ -80,6 +90,12 @@ func Run() {
 	case proxymode.ModeSwarmExt:
 		ioc.RegisterSingleton[service.ApplicationResolverService](c, service.NewDockerExtHostApplicationResolverService)
 		break
+	case proxymode.ModeK8s:
+		ioc.RegisterSingleton[service.ApplicationResolverService](c, service.NewKubernetesApplicationResolverService)
+		break
+	case proxymode.ModeDind:
+		ioc.RegisterSingleton[service.ApplicationResolverService](c, service.NewDindApplicationResolverService)
+		break
 	}
 
 	ioc.RegisterSingleton[service.ApplicationProxyService](c, service.NewDockerApplicationProxyService)
@@ -90,15 +106,27 @@ func Run() {
 	ioc.RegisterSingleton[service.LivecheckFactoryService](c, service.NewLivecheckFactoryService)
 
 	// register services
-	ioc.RegisterSingleton[service.ApplicationProvisionerService](c, service.NewDockerApplicationProvisionerService)
+	switch cfg.GetProxyMode() {
+	case proxymode.ModeK8s:
+		ioc.RegisterSingleton[service.ApplicationProvisionerService](c, service.NewKubernetesApplicationProvisionerService)
+	case proxymode.ModeDind:
+		ioc.RegisterSingleton[service.ApplicationProvisionerService](c, service.NewDindApplicationProvisionerService)
+	default:
+		ioc.RegisterSingleton[service.ApplicationProvisionerService](c, service.NewDockerApplicationProvisionerService)
+	}
 	ioc.RegisterSingleton[service.ApplicationProvisionerHandlerService](c, service.NewApplicationProvisionerHandlerService)
 	ioc.RegisterSingleton[service.ExhibitCleanupService](c, service.NewExhibitCleanupService)
+	ioc.RegisterSingleton[service.ExhibitUpdateService](c, service.NewExhibitUpdateService)
+	ioc.RegisterSingleton[service.ExhibitLifecycleService](c, service.NewExhibitLifecycleService)
+	ioc.RegisterSingleton[service.VolumeSnapshotService](c, service.NewVolumeSnapshotService)
 
 	// register router and routes
 	ioc.RegisterSingleton[*http.Mux](c, http.NewMux)
+	ioc.ForFunc(c, registerMiddlewares)
 	ioc.ForFunc(c, health.RegisterRoutes)
 	ioc.ForFunc(c, exhibit.RegisterRoutes)
 	ioc.ForFunc(c, api.RegisterRoutes)
+	ioc.ForFunc(c, metrics.RegisterRoutes)
 
 	go ioc.ForFunc(c, startProxyServer)
 	go ioc.ForFunc(c, startExhibitCleanup)
@@ -106,6 +134,11 @@ func Run() {
 	<-ctx.Done()
 }
 
+// registerMiddlewares adds the middlewares every route is wrapped with, route specific ones are added by the controllers
+func registerMiddlewares(router *http.Mux, log *zap.SugaredLogger) {
+	router.Use(http.Recovery(log), http.Logging(log))
+}
+
 func startExhibitCleanup(log *zap.SugaredLogger, cleanupService service.ExhibitCleanupService, exhibitService service.ExhibitService) {
 	cleanup := func() {
 		defer func() {
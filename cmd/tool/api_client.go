@@ -2,28 +2,72 @@ package tool
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	cloudevents "github.com/cloudevents/sdk-go/v2/event"
 	"museum/domain"
+	"museum/validation"
 	"net/http"
+	"strconv"
 )
 
 type ApiClient interface {
 	CreateExhibit(exhibit *domain.Exhibit) (string, error)
-	DeleteExhibitById(id string) error
+	ValidateExhibit(content []byte) (validation.Result, error)
+	UpdateExhibit(id string, exhibit *domain.Exhibit) (*domain.ExhibitDto, error)
+	DeleteExhibitById(id string, force bool) error
 	CreateEvent(event *cloudevents.Event) error
 	GetBaseUrl() string
 	GetExhibitById(id string) (*domain.ExhibitDto, error)
 	GetAllExhibits() ([]domain.ExhibitDto, error)
+	GetExhibitRevisions(id string) ([]domain.ExhibitRevisionDto, error)
+	RollbackExhibit(id string, revision int) (*domain.ExhibitDto, error)
+	StartExhibit(id string) error
+	StopExhibit(id string) (*domain.ExhibitDto, error)
+	RestartExhibit(id string) error
+	RenewExhibit(id string) (*domain.ExhibitDto, error)
+	WatchExhibitStatus(ctx context.Context, id string, handle func(event string, data map[string]string) error) error
 }
 
 type ApiClientImpl struct {
 	BaseUrl string
+	// Author is sent with every change to an exhibit, the server stores it with the revision
+	Author string
+	// Token is sent as a bearer token with every request
+	Token  string
+	Client *http.Client
+}
+
+// do sends a request with the headers every request needs
+func (a *ApiClientImpl) do(req *http.Request) (*http.Response, error) {
+	if a.Author != "" {
+		req.Header.Set("X-Museum-Author", a.Author)
+	}
+
+	if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+func (a *ApiClientImpl) get(path string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, a.BaseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.do(req)
 }
 
 func (a *ApiClientImpl) GetAllExhibits() ([]domain.ExhibitDto, error) {
-	res, err := http.Get(a.BaseUrl + "/api/exhibits")
+	res, err := a.get("/api/exhibits")
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +91,13 @@ func (a *ApiClientImpl) CreateExhibit(exhibit *domain.Exhibit) (string, error) {
 		return "", err
 	}
 
-	res, err := http.Post(a.BaseUrl+"/api/exhibits", "application/json", bytes.NewBuffer(b))
+	req, err := http.NewRequest(http.MethodPost, a.BaseUrl+"/api/exhibits", bytes.NewBuffer(b))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -65,28 +115,97 @@ func (a *ApiClientImpl) CreateExhibit(exhibit *domain.Exhibit) (string, error) {
 	return status["id"], nil
 }
 
-func (a *ApiClientImpl) DeleteExhibitById(id string) error {
-	req, err := http.NewRequest(http.MethodDelete, a.BaseUrl+"/api/exhibits/"+id, nil)
+// ValidateExhibit sends an exhibit file as is to a dry run, the server reports the problems with their line numbers
+func (a *ApiClientImpl) ValidateExhibit(content []byte) (validation.Result, error) {
+	req, err := http.NewRequest(http.MethodPost, a.BaseUrl+"/api/exhibits?dryRun=true", bytes.NewBuffer(content))
+	if err != nil {
+		return validation.Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/yaml")
+
+	res, err := a.do(req)
+	if err != nil {
+		return validation.Result{}, err
+	}
+
+	result := validation.Result{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	if err != nil {
+		return validation.Result{}, err
+	}
+
+	// an invalid exhibit is answered with 400 and its problems, anything else without problems is a failed request
+	if res.StatusCode != http.StatusOK && result.Problems == nil {
+		return validation.Result{}, errors.New("could not validate exhibit: " + result.Error)
+	}
+
+	return result, nil
+}
+
+func (a *ApiClientImpl) UpdateExhibit(id string, exhibit *domain.Exhibit) (*domain.ExhibitDto, error) {
+	b, err := json.Marshal(exhibit)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, a.BaseUrl+"/api/exhibits/"+id, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not update exhibit: " + status["error"])
+	}
+
+	dto := &domain.ExhibitDto{}
+	err = json.NewDecoder(res.Body).Decode(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+func (a *ApiClientImpl) DeleteExhibitById(id string, force bool) error {
+	query := ""
+	if force {
+		query = "?force=true"
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, a.BaseUrl+"/api/exhibits/"+id+query, nil)
 	if err != nil {
 		return err
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := a.do(req)
 	if err != nil {
 		return err
 	}
 
+	// a successful delete has no body
+	if res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
 	status := make(map[string]string)
 	err = json.NewDecoder(res.Body).Decode(&status)
 	if err != nil {
 		return err
 	}
 
-	if res.StatusCode != http.StatusNoContent {
-		return errors.New("could not delete exhibit: " + status["error"])
-	}
-
-	return nil
+	return errors.New("could not delete exhibit: " + status["error"])
 }
 
 func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
@@ -95,7 +214,13 @@ func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
 		return err
 	}
 
-	res, err := http.Post(a.BaseUrl+"/api/events", "application/json", bytes.NewBuffer(b))
+	req, err := http.NewRequest(http.MethodPost, a.BaseUrl+"/api/events", bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.do(req)
 	if err != nil {
 		return err
 	}
@@ -114,7 +239,7 @@ func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
 }
 
 func (a *ApiClientImpl) GetExhibitById(id string) (*domain.ExhibitDto, error) {
-	res, err := http.Get(a.BaseUrl + "/api/exhibits/" + id)
+	res, err := a.get("/api/exhibits/" + id)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +257,145 @@ func (a *ApiClientImpl) GetExhibitById(id string) (*domain.ExhibitDto, error) {
 	return exhibit, nil
 }
 
+func (a *ApiClientImpl) GetExhibitRevisions(id string) ([]domain.ExhibitRevisionDto, error) {
+	res, err := a.get("/api/exhibits/" + id + "/revisions")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not get revisions: " + status["error"])
+	}
+
+	revisions := make([]domain.ExhibitRevisionDto, 0)
+	err = json.NewDecoder(res.Body).Decode(&revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (a *ApiClientImpl) RollbackExhibit(id string, revision int) (*domain.ExhibitDto, error) {
+	req, err := http.NewRequest(http.MethodPost, a.BaseUrl+"/api/exhibits/"+id+"/rollback/"+strconv.Itoa(revision), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not roll back exhibit: " + status["error"])
+	}
+
+	dto := &domain.ExhibitDto{}
+	err = json.NewDecoder(res.Body).Decode(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+// postAction posts to an action of an exhibit (e.g. stop), the response is decoded into v if it is not nil
+func (a *ApiClientImpl) postAction(id string, action string, expectedStatus int, v any) error {
+	req, err := http.NewRequest(http.MethodPost, a.BaseUrl+"/api/exhibits/"+id+"/"+action, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.do(req)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != expectedStatus {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return err
+		}
+
+		return errors.New("could not " + action + " exhibit: " + status["error"])
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (a *ApiClientImpl) StartExhibit(id string) error {
+	return a.postAction(id, "start", http.StatusAccepted, nil)
+}
+
+func (a *ApiClientImpl) StopExhibit(id string) (*domain.ExhibitDto, error) {
+	dto := &domain.ExhibitDto{}
+	err := a.postAction(id, "stop", http.StatusOK, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+func (a *ApiClientImpl) RestartExhibit(id string) error {
+	return a.postAction(id, "restart", http.StatusAccepted, nil)
+}
+
+func (a *ApiClientImpl) RenewExhibit(id string) (*domain.ExhibitDto, error) {
+	dto := &domain.ExhibitDto{}
+	err := a.postAction(id, "renew", http.StatusOK, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+// WatchExhibitStatus follows the status events of an exhibit until the server closes the stream,
+// ctx is cancelled or handle returns an error
+func (a *ApiClientImpl) WatchExhibitStatus(ctx context.Context, id string, handle func(event string, data map[string]string) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.BaseUrl+"/api/exhibits/"+id+"/status", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := a.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("could not watch exhibit status: " + res.Status)
+	}
+
+	err = readEvents(res.Body, handle)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
 func (a *ApiClientImpl) GetBaseUrl() string {
 	return a.BaseUrl
 }
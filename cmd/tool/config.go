@@ -0,0 +1,175 @@
+package tool
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultServer is used when neither a flag, the environment nor the config file name a server
+const DefaultServer = "http://localhost:8080"
+
+// Config is the config file of the CLI, it holds the museum servers the CLI can talk to
+type Config struct {
+	CurrentContext string    `yaml:"currentContext" json:"currentContext"`
+	Contexts       []Context `yaml:"contexts" json:"contexts"`
+}
+
+// Context is a named museum server
+type Context struct {
+	Name   string `yaml:"name" json:"name"`
+	Server string `yaml:"server" json:"server"`
+	// Token is sent as a bearer token, e.g. to get through an authenticating proxy in front of museum
+	Token string `yaml:"token,omitempty" json:"-"`
+	// CertificateAuthority is the path of a PEM file the TLS certificate of the server is checked against
+	CertificateAuthority string `yaml:"certificateAuthority,omitempty" json:"certificateAuthority,omitempty"`
+}
+
+// Options select the server the CLI talks to, set options win over the environment and the config file
+type Options struct {
+	Server     string
+	Context    string
+	ConfigFile string
+}
+
+// DefaultConfigFile returns the path of the config file, $MUSEUM_CONFIG or ~/.config/museum/config.yaml
+func DefaultConfigFile() string {
+	if path := os.Getenv("MUSEUM_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "museum", "config.yaml")
+}
+
+// LoadConfig reads a config file, a missing file is an empty config
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(content, config)
+	if err != nil {
+		return nil, errors.New("invalid config file " + path + ": " + err.Error())
+	}
+
+	return config, nil
+}
+
+// Save writes the config file, only the owner can read it as it might contain tokens
+func (c *Config) Save(path string) error {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
+
+func (c *Config) GetContext(name string) (Context, bool) {
+	for _, context := range c.Contexts {
+		if context.Name == name {
+			return context, true
+		}
+	}
+
+	return Context{}, false
+}
+
+// resolve returns the context the CLI talks to, in order of precedence:
+// --server, $MUSEUM_SERVER, --context, $MUSEUM_CONTEXT, the current context of the config file and DefaultServer
+func (o Options) resolve() (Context, error) {
+	path := o.ConfigFile
+	if path == "" {
+		path = DefaultConfigFile()
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		return Context{}, err
+	}
+
+	name := o.Context
+	if name == "" {
+		name = os.Getenv("MUSEUM_CONTEXT")
+	}
+	if name == "" {
+		name = config.CurrentContext
+	}
+
+	context := Context{Server: DefaultServer}
+	if name != "" {
+		found, ok := config.GetContext(name)
+		if !ok {
+			return Context{}, errors.New("context " + name + " not found in " + path)
+		}
+		context = found
+	}
+
+	// the server can be overridden without losing the rest of the context, e.g. its certificate authority.
+	// The token is only sent to the server of the context, another server gets none unless $MUSEUM_TOKEN is set
+	server := o.Server
+	if server == "" {
+		server = os.Getenv("MUSEUM_SERVER")
+	}
+	if server != "" && strings.TrimRight(server, "/") != strings.TrimRight(context.Server, "/") {
+		context.Server = server
+		context.Token = ""
+	}
+
+	if token := os.Getenv("MUSEUM_TOKEN"); token != "" {
+		context.Token = token
+	}
+
+	return context, nil
+}
+
+// httpClient returns a client that trusts the certificate authority of the context, on top of the system ones
+func (c Context) httpClient() (*http.Client, error) {
+	if c.CertificateAuthority == "" {
+		return http.DefaultClient, nil
+	}
+
+	pem, err := os.ReadFile(c.CertificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + c.CertificateAuthority)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,63 @@
+package tool
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := &Config{
+		CurrentContext: "prod",
+		Contexts: []Context{
+			{Name: "prod", Server: "https://museum.example.org", Token: "secret"},
+			{Name: "staging", Server: "https://staging.example.org"},
+		},
+	}
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MUSEUM_SERVER", "")
+	t.Setenv("MUSEUM_CONTEXT", "")
+	t.Setenv("MUSEUM_TOKEN", "")
+
+	tests := []struct {
+		name   string
+		opts   Options
+		env    map[string]string
+		server string
+		token  string
+	}{
+		{"current context", Options{ConfigFile: path}, nil, "https://museum.example.org", "secret"},
+		{"context flag", Options{ConfigFile: path, Context: "staging"}, nil, "https://staging.example.org", ""},
+		{"context env", Options{ConfigFile: path}, map[string]string{"MUSEUM_CONTEXT": "staging"}, "https://staging.example.org", ""},
+		{"server env drops token", Options{ConfigFile: path}, map[string]string{"MUSEUM_SERVER": "http://localhost:9090"}, "http://localhost:9090", ""},
+		{"server flag wins", Options{ConfigFile: path, Server: "http://flag"}, map[string]string{"MUSEUM_SERVER": "http://env"}, "http://flag", ""},
+		{"server of the context keeps token", Options{ConfigFile: path, Server: "https://museum.example.org/"}, nil, "https://museum.example.org", "secret"},
+		{"token env for another server", Options{ConfigFile: path, Server: "http://flag"}, map[string]string{"MUSEUM_TOKEN": "other"}, "http://flag", "other"},
+		{"no config file", Options{ConfigFile: filepath.Join(t.TempDir(), "missing.yaml")}, nil, DefaultServer, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+
+			context, err := test.opts.resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if context.Server != test.server || context.Token != test.token {
+				t.Errorf("Expected %s with token %q, got %s with token %q", test.server, test.token, context.Server, context.Token)
+			}
+		})
+	}
+
+	_, err := Options{ConfigFile: path, Context: "missing"}.resolve()
+	if err == nil {
+		t.Errorf("Expected an error for a missing context")
+	}
+}
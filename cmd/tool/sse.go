@@ -0,0 +1,40 @@
+package tool
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// readEvents reads server sent events until the stream ends or handle returns an error,
+// museum only sends json objects with string values as data (see http.Response.SendMessage)
+func readEvents(r io.Reader, handle func(event string, data map[string]string) error) error {
+	scanner := bufio.NewScanner(r)
+
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := make(map[string]string)
+			err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &data)
+			if err != nil {
+				return err
+			}
+
+			err = handle(event, data)
+			if err != nil {
+				return err
+			}
+		case line == "":
+			// an empty line ends an event
+			event = ""
+		}
+	}
+
+	return scanner.Err()
+}
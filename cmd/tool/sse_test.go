@@ -0,0 +1,25 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := "event: status.subscribed\ndata: {\"exhibitId\":\"1\"}\n\n" +
+		"event: status.update\ndata: {\"object\":\"web\",\"step\":\"start\"}\n\n" +
+		"event: close\ndata: {}\n\n"
+
+	events := make([]string, 0)
+	err := readEvents(strings.NewReader(stream), func(event string, data map[string]string) error {
+		events = append(events, event+":"+data["step"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(events, ",") != "status.subscribed:,status.update:start,close:" {
+		t.Errorf("Expected three events, got %v", events)
+	}
+}
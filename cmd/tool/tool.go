@@ -1,94 +1,382 @@
 package tool
 
 import (
-	"gopkg.in/yaml.v3"
+	"context"
+	"errors"
 	"museum/domain"
 	"museum/ioc"
+	"museum/spec"
+	"museum/validation"
 	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func createToolContainer() *ioc.Container {
+func createToolContainer(opts Options) (*ioc.Container, error) {
+	context, err := opts.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := context.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
 	c := ioc.NewContainer()
 	ioc.RegisterSingleton[ApiClient](c, func() ApiClient {
 		return &ApiClientImpl{
-			BaseUrl: "http://localhost:8080",
+			BaseUrl: strings.TrimSuffix(context.Server, "/"),
+			Author:  currentAuthor(),
+			Token:   context.Token,
+			Client:  client,
 		}
 	})
-	return c
+	return c, nil
 }
 
-func Create(filePath string) (*domain.Exhibit, string, error) {
-	c := createToolContainer()
+// apiClient returns the client for the server selected by the options
+func apiClient(opts Options) (ApiClient, error) {
+	c, err := createToolContainer(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := os.Open(filePath)
+	return ioc.Get[ApiClient](c), nil
+}
+
+// currentAuthor identifies who changes an exhibit, as user@host
+func currentAuthor() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+
+	host, err := os.Hostname()
 	if err != nil {
-		return nil, "", err
+		return u.Username
 	}
+
+	return u.Username + "@" + host
+}
+
+func readExhibit(filePath string) (*domain.Exhibit, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	exhibit, err := spec.Decode(content)
+	if err != nil {
+		return nil, errors.New("invalid exhibit file " + filePath + ": " + err.Error())
 	}
 
-	exhibit := &domain.Exhibit{}
-	err = yaml.Unmarshal(content, exhibit)
+	return &exhibit, nil
+}
 
+func Create(opts Options, filePath string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	exhibit, err := readExhibit(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	a := ioc.Get[ApiClient](c)
 	id, err := a.CreateExhibit(exhibit)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	return getExhibit(a, id)
+}
+
+func Update(opts Options, id string, filePath string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	exhibit, err := readExhibit(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dto, err := a.UpdateExhibit(id, exhibit)
+	if err != nil {
+		return nil, err
 	}
 
-	exhibit.Id = id
+	dto.Url = exhibitUrl(a, *dto)
+	return dto, nil
+}
+
+// Validate checks an exhibit file, locally or by a dry run on the server,
+// only the server knows e.g. the names of the other exhibits and its volume drivers
+func Validate(opts Options, filePath string, remote bool) (validation.Result, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return validation.Result{}, err
+	}
+
+	if !remote {
+		_, problems := validation.Lint(content, validation.Validator{})
+		return validation.NewResult(problems), nil
+	}
+
+	a, err := apiClient(opts)
+	if err != nil {
+		return validation.Result{}, err
+	}
+
+	return a.ValidateExhibit(content)
+}
+
+// exhibitUrl returns the URL the server reported for an exhibit,
+// older servers don't report one, so we fall back to the default path based URL
+func exhibitUrl(a ApiClient, exhibit domain.ExhibitDto) string {
+	if exhibit.Url != "" {
+		return exhibit.Url
+	}
+
+	return a.GetBaseUrl() + "/exhibit/" + exhibit.Id
+}
+
+// getExhibit gets an exhibit with its URL filled in
+func getExhibit(a ApiClient, id string) (*domain.ExhibitDto, error) {
+	dto, err := a.GetExhibitById(id)
+	if err != nil {
+		return nil, err
+	}
 
-	return exhibit, a.GetBaseUrl() + "/exhibit/" + id, nil
+	dto.Url = exhibitUrl(a, *dto)
+	return dto, nil
 }
 
-func Delete(id string) error {
-	c := createToolContainer()
+func Get(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	a := ioc.Get[ApiClient](c)
-	err := a.DeleteExhibitById(id)
+	return getExhibit(a, id)
+}
+
+func Delete(opts Options, id string, force bool) error {
+	a, err := apiClient(opts)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return a.DeleteExhibitById(id, force)
 }
 
-func Warmup(id string) (string, error) {
-	c := createToolContainer()
+func Warmup(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	a := ioc.Get[ApiClient](c)
-	exhibit, err := a.GetExhibitById(id)
+	exhibit, err := getExhibit(a, id)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	err = startByEvent(a, exhibit)
+	if err != nil {
+		return nil, err
+	}
+
+	return getExhibit(a, id)
+}
+
+func startByEvent(a ApiClient, exhibit *domain.ExhibitDto) error {
 	event, err := domain.NewStartEvent(exhibit.ToExhibit())
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	err = a.CreateEvent(&event)
+	return a.CreateEvent(&event)
+}
+
+// finishTimeout is how long the last status events may lag behind the answer to the start event
+const finishTimeout = 2 * time.Second
+
+// WarmupAndWait starts an exhibit and waits until it is running, onStep is called for every step of every object
+// the server answers the start event once the exhibit is running (or failed to start), the status stream only reports the progress
+func WarmupAndWait(opts Options, id string, onStep func(step domain.ExhibitStartingStepEvent)) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return a.GetBaseUrl() + "/exhibit/" + id, nil
+	exhibit, err := getExhibit(a, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscribed := make(chan struct{})
+	watched := make(chan error, 1)
+	go func() {
+		watched <- a.WatchExhibitStatus(ctx, exhibit.Id, func(event string, data map[string]string) error {
+			switch event {
+			case "status.subscribed":
+				close(subscribed)
+			case "status.update":
+				onStep(stepFromMap(data))
+			case "status.error":
+				onStep(domain.ExhibitStartingStepEvent{ExhibitId: exhibit.Id, Error: data["error"]})
+			}
+			return nil
+		})
+	}()
+
+	// the start event is only sent once the stream is subscribed, otherwise the first steps would be missed
+	select {
+	case <-subscribed:
+	case err := <-watched:
+		if err != nil {
+			return nil, err
+		}
+		// the stream ended already, there is nothing to wait for later on
+		watched <- nil
+	}
+
+	err = startByEvent(a, exhibit)
+
+	// the stream ends after the last step, an exhibit that was running already sends none though
+	// onStep must not be called anymore once this returns
+	select {
+	case <-watched:
+	case <-time.After(finishTimeout):
+		cancel()
+		<-watched
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getExhibit(a, id)
+}
+
+func stepFromMap(data map[string]string) domain.ExhibitStartingStepEvent {
+	current, _ := strconv.Atoi(data["currentStepCount"])
+	total, _ := strconv.Atoi(data["totalStepCount"])
+
+	return domain.ExhibitStartingStepEvent{
+		ExhibitId:        data["exhibitId"],
+		Object:           data["object"],
+		Step:             data["step"],
+		Error:            data["error"],
+		CurrentStepCount: current,
+		TotalStepCount:   total,
+	}
 }
 
-func List() (string, []domain.ExhibitDto, error) {
-	c := createToolContainer()
+func List(opts Options) ([]domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	a := ioc.Get[ApiClient](c)
 	exhibits, err := a.GetAllExhibits()
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+
+	for i, e := range exhibits {
+		exhibits[i].Url = exhibitUrl(a, e)
+	}
+
+	return exhibits, nil
+}
+
+func History(opts Options, id string) ([]domain.ExhibitRevisionDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.GetExhibitRevisions(id)
+}
+
+func Rollback(opts Options, id string, revision int) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dto, err := a.RollbackExhibit(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	dto.Url = exhibitUrl(a, *dto)
+	return dto, nil
+}
+
+func Start(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.StartExhibit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return getExhibit(a, id)
+}
+
+func Stop(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dto, err := a.StopExhibit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dto.Url = exhibitUrl(a, *dto)
+	return dto, nil
+}
+
+func Restart(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.RestartExhibit(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return getExhibit(a, id)
+}
+
+func Renew(opts Options, id string) (*domain.ExhibitDto, error) {
+	a, err := apiClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dto, err := a.RenewExhibit(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return a.GetBaseUrl(), exhibits, nil
+	dto.Url = exhibitUrl(a, *dto)
+	return dto, nil
 }
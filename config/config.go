@@ -1,6 +1,10 @@
 package config
 
-import proxymode "museum/config/proxy-mode"
+import (
+	proxymode "museum/config/proxy-mode"
+	routingmode "museum/config/routing-mode"
+	"museum/domain"
+)
 
 type Config interface {
 	GetEtcdHost() string
@@ -13,7 +17,20 @@ type Config interface {
 	GetJaegerHost() string
 	GetEnvironment() string
 	GetProxyMode() proxymode.Mode
+	GetRoutingMode() routingmode.Mode
 	GetCertFile() string
 	GetKeyFile() string
 	GetStartingTimeout() int
+	// GetKubeconfig returns the path of the kubeconfig file, museum uses its service account in the cluster if it is empty
+	GetKubeconfig() string
+	GetK8sNamespace() string
+	GetDindImage() string
+	// GetDindNetwork returns the network the docker daemons of the exhibits are attached to, museum has to be part of it
+	GetDindNetwork() string
+	// GetScratchPath returns the directory the copies of scratch volumes are kept in, it has to be at the same path on the docker host
+	GetScratchPath() string
+	// GetSnapshotPath returns the directory the snapshots of volumes are kept in
+	GetSnapshotPath() string
+	// GetDefaultProfile returns the resource limits and security settings of objects that don't set their own
+	GetDefaultProfile() domain.Profile
 }
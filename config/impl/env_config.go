@@ -2,6 +2,8 @@ package impl
 
 import (
 	proxymode "museum/config/proxy-mode"
+	routingmode "museum/config/routing-mode"
+	"museum/domain"
 )
 
 type EnvConfig struct {
@@ -15,9 +17,28 @@ type EnvConfig struct {
 	JaegerHost      string `env:"JAEGER_HOST"`
 	Environment     string `env:"ENVIRONMENT" envDefault:"development"`
 	ProxyMode       string `env:"PROXY_MODE" envDefault:"swarm-ext"`
+	RoutingMode     string `env:"ROUTING_MODE" envDefault:"path"`
 	CertFile        string `env:"CERT_FILE"`
 	KeyFile         string `env:"KEY_FILE"`
 	StartingTimeout int    `env:"STARTING_TIMEOUT" envDefault:"280"`
+	Kubeconfig      string `env:"KUBECONFIG"`
+	K8sNamespace    string `env:"K8S_NAMESPACE" envDefault:"museum"`
+	DindImage       string `env:"DIND_IMAGE" envDefault:"docker:27-dind"`
+	DindNetwork     string `env:"DIND_NETWORK"`
+	ScratchPath     string `env:"SCRATCH_PATH" envDefault:"/var/lib/museum/scratch"`
+	SnapshotPath    string `env:"SNAPSHOT_PATH" envDefault:"/var/lib/museum/snapshots"`
+
+	// the default profile of every object, objects need to opt in to relax it
+	DefaultCpus            string   `env:"DEFAULT_CPUS"`
+	DefaultMemory          string   `env:"DEFAULT_MEMORY"`
+	DefaultPids            int64    `env:"DEFAULT_PIDS"`
+	DefaultReadOnly        bool     `env:"DEFAULT_READ_ONLY"`
+	DefaultTmpfs           []string `env:"DEFAULT_TMPFS"`
+	DefaultCapDrop         []string `env:"DEFAULT_CAP_DROP"`
+	DefaultNoNewPrivileges bool     `env:"DEFAULT_NO_NEW_PRIVILEGES"`
+	DefaultSeccomp         string   `env:"DEFAULT_SECCOMP"`
+	DefaultAppArmor        string   `env:"DEFAULT_APPARMOR"`
+	DefaultUser            string   `env:"DEFAULT_USER"`
 }
 
 func (e EnvConfig) GetEtcdHost() string {
@@ -62,11 +83,26 @@ func (e EnvConfig) GetProxyMode() proxymode.Mode {
 		return proxymode.ModeSwarm
 	case "swarm-ext":
 		return proxymode.ModeSwarmExt
+	case "k8s":
+		return proxymode.ModeK8s
+	case "dind":
+		return proxymode.ModeDind
 	default:
 		panic("invalid proxy mode" + e.ProxyMode)
 	}
 }
 
+func (e EnvConfig) GetRoutingMode() routingmode.Mode {
+	switch e.RoutingMode {
+	case "path":
+		return routingmode.ModePath
+	case "host":
+		return routingmode.ModeHost
+	default:
+		panic("invalid routing mode " + e.RoutingMode)
+	}
+}
+
 func (e EnvConfig) GetCertFile() string {
 	return e.CertFile
 }
@@ -78,3 +114,46 @@ func (e EnvConfig) GetKeyFile() string {
 func (e EnvConfig) GetStartingTimeout() int {
 	return e.StartingTimeout
 }
+
+func (e EnvConfig) GetKubeconfig() string {
+	return e.Kubeconfig
+}
+
+func (e EnvConfig) GetK8sNamespace() string {
+	return e.K8sNamespace
+}
+
+func (e EnvConfig) GetDindImage() string {
+	return e.DindImage
+}
+
+func (e EnvConfig) GetDindNetwork() string {
+	return e.DindNetwork
+}
+
+func (e EnvConfig) GetScratchPath() string {
+	return e.ScratchPath
+}
+
+func (e EnvConfig) GetSnapshotPath() string {
+	return e.SnapshotPath
+}
+
+func (e EnvConfig) GetDefaultProfile() domain.Profile {
+	return domain.Profile{
+		Resources: domain.Resources{
+			Cpus:   e.DefaultCpus,
+			Memory: e.DefaultMemory,
+			Pids:   e.DefaultPids,
+		},
+		Security: domain.Security{
+			ReadOnly:        &e.DefaultReadOnly,
+			Tmpfs:           e.DefaultTmpfs,
+			CapDrop:         e.DefaultCapDrop,
+			NoNewPrivileges: &e.DefaultNoNewPrivileges,
+			Seccomp:         e.DefaultSeccomp,
+			AppArmor:        e.DefaultAppArmor,
+			User:            e.DefaultUser,
+		},
+	}
+}
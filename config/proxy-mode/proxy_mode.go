@@ -5,4 +5,6 @@ type Mode string
 const (
 	ModeSwarm    Mode = "swarm"
 	ModeSwarmExt Mode = "swarm-ext"
+	ModeK8s      Mode = "k8s"
+	ModeDind     Mode = "dind"
 )
@@ -0,0 +1,27 @@
+package routingMode
+
+type Mode string
+
+const (
+	ModePath Mode = "path"
+	ModeHost Mode = "host"
+)
+
+// BasePath returns the path prefix under which the exhibit with the given id is served
+func (m Mode) BasePath(exhibitId string) string {
+	if m == ModeHost {
+		return ""
+	}
+
+	return "/exhibit/" + exhibitId
+}
+
+// Host returns the host under which the exhibit with the given name is served,
+// museumHost is the host of the museum instance itself (e.g. localhost:8080)
+func (m Mode) Host(exhibitName string, museumHost string) string {
+	if m == ModeHost {
+		return exhibitName + "." + museumHost
+	}
+
+	return museumHost
+}
@@ -3,132 +3,463 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	cloudevents "github.com/cloudevents/sdk-go/v2/event"
-	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"io"
+	"museum/config"
 	"museum/domain"
 	"museum/http"
 	"museum/persistence"
 	"museum/service"
+	"museum/spec"
+	"museum/validation"
 	gohttp "net/http"
+	"strconv"
 	"time"
 )
 
-func getExhibits(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
-	return func(res *http.Response, req *http.Request) {
-		subCtx, span := provider.
-			Tracer("API request").
-			Start(req.Context(), "HTTP GET /api/exhibits/", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
-		defer span.End()
+// exhibitUrl returns the URL under which an exhibit can be reached, depending on the routing mode
+func exhibitUrl(c config.Config, exhibit domain.Exhibit) string {
+	scheme := "http://"
+	if c.GetCertFile() != "" && c.GetKeyFile() != "" {
+		scheme = "https://"
+	}
+
+	mode := c.GetRoutingMode()
+	return scheme + mode.Host(exhibit.Name, c.GetHostname()+":"+c.GetPort()) + mode.BasePath(exhibit.Id)
+}
+
+// authorHeader names the author of a change to an exhibit, it is stored with the revision
+const authorHeader = "X-Museum-Author"
+
+func author(req *http.Request) string {
+	if a := req.Header.Get(authorHeader); a != "" {
+		return a
+	}
+
+	return "anonymous"
+}
+
+// isConflict reports whether an exhibit is in the wrong state for a request, e.g. it is still starting
+func isConflict(err error) bool {
+	return errors.Is(err, domain.ErrExhibitStarting) ||
+		errors.Is(err, domain.ErrExhibitStopping) ||
+		errors.Is(err, domain.ErrExhibitRunning) ||
+		errors.Is(err, domain.ErrExhibitNotRunning)
+}
 
-		exhibits := exhibitService.GetAllExhibits(subCtx)
+func getExhibits(exhibitService service.ExhibitService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibits := exhibitService.GetAllExhibits(req.Context())
 		dtos := make([]domain.ExhibitDto, len(exhibits))
 
 		for i, exhibit := range exhibits {
 			dtos[i] = exhibit.ToDto()
+			dtos[i].Url = exhibitUrl(c, exhibit)
 		}
 
-		err := res.WriteJson(dtos)
+		return res.WriteJson(dtos)
+	}
+}
+
+func getExhibitById(exhibitService service.ExhibitService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibit, err := exhibitService.GetExhibitById(req.Context(), req.Params["id"])
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
+			return http.WithStatus(gohttp.StatusNotFound, err)
 		}
+
+		dto := exhibit.ToDto()
+		dto.Url = exhibitUrl(c, exhibit)
+
+		return res.WriteJson(dto)
 	}
 }
 
-func getExhibitById(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
-	return func(res *http.Response, req *http.Request) {
-		subCtx, span := provider.
-			Tracer("API request").
-			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"], trace.WithAttributes(attribute.String("requestId", req.RequestID)))
-		defer span.End()
+func createExhibit(exhibitService service.ExhibitService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		span := trace.SpanFromContext(req.Context())
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("error reading request body: %w", err)
+		}
+
+		// a dry run only validates the definition, it may be yaml as well to get the line numbers of the file
+		if req.URL.Query().Get("dryRun") == "true" {
+			_, problems := exhibitService.LintExhibit(req.Context(), body)
+			if problems.HasErrors() {
+				res.WriteHeader(gohttp.StatusBadRequest)
+			}
+
+			return res.WriteJson(validation.NewResult(problems))
+		}
+
+		// json is yaml as well, older spec versions are upgraded on the way
+		exhibit, err := spec.Decode(body)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusBadRequest, fmt.Errorf("error reading exhibit: %w", err))
+		}
+
+		span.AddEvent("request read")
+
+		id, err := exhibitService.CreateExhibit(req.Context(), domain.CreateExhibit{
+			Exhibit:   exhibit,
+			RequestID: req.RequestID,
+			Author:    author(req),
+		})
+		if errors.Is(err, domain.ErrInvalidExhibit) {
+			return http.WithStatus(gohttp.StatusBadRequest, err)
+		}
 
-		exhibitId := req.Params["id"]
-		exhibit, err := exhibitService.GetExhibitById(subCtx, exhibitId)
 		if err != nil {
-			span.RecordError(err)
-			res.WriteErr(err)
-			res.WriteHeader(gohttp.StatusNotFound)
-			return
+			return fmt.Errorf("error creating exhibit: %w", err)
 		}
 
-		err = res.WriteJson(exhibit.ToDto())
+		exhibit.Id = id
+
+		res.WriteHeader(gohttp.StatusCreated)
+		err = res.WriteJson(map[string]string{"status": "Created", "id": id, "url": exhibitUrl(c, exhibit)})
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
+			return err
 		}
+
+		span.AddEvent("response written")
+		return nil
 	}
 }
 
-func createExhibit(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
-	return func(res *http.Response, req *http.Request) {
-		ctx, span := provider.
-			Tracer("API request").
-			Start(req.Context(), "HTTP POST /api/exhibits", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
-		defer span.End()
+func updateExhibit(exhibitService service.ExhibitService, updateService service.ExhibitUpdateService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
 
 		body, err := io.ReadAll(req.Body)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error reading request body", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return fmt.Errorf("error reading request body: %w", err)
 		}
 
-		exhibit := &domain.Exhibit{}
-		err = json.Unmarshal(body, exhibit)
+		exhibit, err := spec.Decode(body)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error unmarshalling json", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return http.WithStatus(gohttp.StatusBadRequest, fmt.Errorf("error reading exhibit: %w", err))
 		}
 
-		span.AddEvent("request read")
+		// the id in the path wins, the definition must not move to another exhibit
+		exhibit.Id = exhibitId
+
+		updated, err := updateService.UpdateExhibit(req.Context(), domain.UpdateExhibit{
+			Exhibit:   exhibit,
+			RequestID: req.RequestID,
+			Author:    author(req),
+		})
+		if isConflict(err) {
+			return http.WithStatus(gohttp.StatusConflict, err)
+		}
+
+		if errors.Is(err, domain.ErrInvalidExhibit) {
+			return http.WithStatus(gohttp.StatusBadRequest, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error updating exhibit: %w", err)
+		}
+
+		dto := updated.ToDto()
+		dto.Url = exhibitUrl(c, updated)
+
+		return res.WriteJson(dto)
+	}
+}
+
+// getExhibitSchema serves the JSON Schema of a spec version, e.g. for editors to check exhibit files against
+func getExhibitSchema() http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		schema, err := spec.Schema(req.Params["version"])
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		res.Header().Set("Content-Type", "application/schema+json")
+		return res.WriteJson(schema)
+	}
+}
+
+func getExhibitRevisions(exhibitService service.ExhibitService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		revisions, err := exhibitService.GetExhibitRevisions(req.Context(), req.Params["id"])
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
 
-		id, err := exhibitService.CreateExhibit(ctx, domain.CreateExhibit{
-			Exhibit:   *exhibit,
+		dtos := make([]domain.ExhibitRevisionDto, len(revisions))
+		for i, revision := range revisions {
+			dtos[i] = revision.ToDto()
+		}
+
+		return res.WriteJson(dtos)
+	}
+}
+
+func rollbackExhibit(exhibitService service.ExhibitService, updateService service.ExhibitUpdateService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		revision, err := strconv.Atoi(req.Params["rev"])
+		if err != nil || revision < 1 {
+			return http.WithStatus(gohttp.StatusBadRequest, errors.New("revision must be a positive integer"))
+		}
+
+		_, err = exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		updated, err := updateService.RollbackExhibit(req.Context(), domain.RollbackExhibit{
+			ExhibitId: exhibitId,
+			Revision:  revision,
 			RequestID: req.RequestID,
+			Author:    author(req),
 		})
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		if isConflict(err) {
+			return http.WithStatus(gohttp.StatusConflict, err)
+		}
+
+		// an old revision can be invalid by now, e.g. because another exhibit took its name
+		if errors.Is(err, domain.ErrInvalidExhibit) {
+			return http.WithStatus(gohttp.StatusBadRequest, err)
+		}
+
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error creating exhibit", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return fmt.Errorf("error rolling back exhibit: %w", err)
+		}
+
+		dto := updated.ToDto()
+		dto.Url = exhibitUrl(c, updated)
+
+		return res.WriteJson(dto)
+	}
+}
+
+// snapshotError maps the errors of the snapshot service to a status, action is what failed, e.g. creating
+func snapshotError(err error, action string) error {
+	switch {
+	case errors.Is(err, domain.ErrVolumeNotFound), errors.Is(err, domain.ErrSnapshotNotFound):
+		return http.WithStatus(gohttp.StatusNotFound, err)
+	case errors.Is(err, domain.ErrSnapshotExists), errors.Is(err, domain.ErrSnapshotPinned), isConflict(err):
+		return http.WithStatus(gohttp.StatusConflict, err)
+	case errors.Is(err, domain.ErrSnapshotsNotSupported), errors.Is(err, domain.ErrInvalidSnapshotName):
+		return http.WithStatus(gohttp.StatusBadRequest, err)
+	}
+
+	return fmt.Errorf("error %s snapshot: %w", action, err)
+}
+
+func getSnapshots(exhibitService service.ExhibitService, snapshotService service.VolumeSnapshotService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		snapshots, err := snapshotService.GetSnapshots(req.Context(), exhibitId, req.Params["name"])
+		if err != nil {
+			return snapshotError(err, "listing")
+		}
+
+		return res.WriteJson(snapshots)
+	}
+}
+
+func createSnapshot(exhibitService service.ExhibitService, snapshotService service.VolumeSnapshotService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		body := domain.CreateSnapshot{}
+		err = json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusBadRequest, fmt.Errorf("error reading snapshot: %w", err))
+		}
+
+		snapshot, err := snapshotService.CreateSnapshot(req.Context(), exhibitId, req.Params["name"], body.Name)
+		if err != nil {
+			return snapshotError(err, "creating")
 		}
 
 		res.WriteHeader(gohttp.StatusCreated)
-		err = res.WriteJson(map[string]string{"status": "Created", "id": id})
+		return res.WriteJson(snapshot)
+	}
+}
+
+func restoreSnapshot(exhibitService service.ExhibitService, snapshotService service.VolumeSnapshotService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return http.WithStatus(gohttp.StatusNotFound, err)
 		}
 
-		span.AddEvent("response written")
+		err = snapshotService.RestoreSnapshot(req.Context(), exhibitId, req.Params["name"], req.Params["snapshot"])
+		if err != nil {
+			return snapshotError(err, "restoring")
+		}
+
+		res.WriteHeader(gohttp.StatusNoContent)
+		return nil
+	}
+}
+
+func deleteSnapshot(exhibitService service.ExhibitService, snapshotService service.VolumeSnapshotService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		err = snapshotService.DeleteSnapshot(req.Context(), exhibitId, req.Params["name"], req.Params["snapshot"])
+		if err != nil {
+			return snapshotError(err, "deleting")
+		}
+
+		res.WriteHeader(gohttp.StatusNoContent)
+		return nil
+	}
+}
+
+// lifecycleAction is a state transition of an exhibit that finishes in the background
+type lifecycleAction func(ctx context.Context, exhibitId string) error
+
+func startExhibit(exhibitService service.ExhibitService, action lifecycleAction) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		err = action(req.Context(), exhibitId)
+		if isConflict(err) {
+			return http.WithStatus(gohttp.StatusConflict, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error starting exhibit: %w", err)
+		}
+
+		// the client can follow the start on /api/exhibits/{id}/status
+		res.WriteHeader(gohttp.StatusAccepted)
+		return res.WriteJson(map[string]string{"status": "Accepted", "id": exhibitId})
 	}
 }
 
-func handleEvents(handlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
-	return func(res *http.Response, req *http.Request) {
-		ctx, span := provider.
-			Tracer("API request").
-			Start(req.Context(), "HTTP POST /api/events", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
-		defer span.End()
+func stopExhibit(exhibitService service.ExhibitService, lifecycleService service.ExhibitLifecycleService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		err = lifecycleService.StopExhibit(req.Context(), exhibitId)
+		if isConflict(err) {
+			return http.WithStatus(gohttp.StatusConflict, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error stopping exhibit: %w", err)
+		}
+
+		exhibit, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return err
+		}
+
+		dto := exhibit.ToDto()
+		dto.Url = exhibitUrl(c, exhibit)
+
+		return res.WriteJson(dto)
+	}
+}
+
+func renewExhibit(exhibitService service.ExhibitService, lifecycleService service.ExhibitLifecycleService, c config.Config) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		exhibit, err := lifecycleService.RenewExhibit(req.Context(), exhibitId)
+		if isConflict(err) {
+			return http.WithStatus(gohttp.StatusConflict, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error renewing lease: %w", err)
+		}
+
+		dto := exhibit.ToDto()
+		dto.Url = exhibitUrl(c, exhibit)
+
+		return res.WriteJson(dto)
+	}
+}
+
+func deleteExhibit(exhibitService service.ExhibitService, cleanupService service.ExhibitCleanupService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		exhibitId := req.Params["id"]
+
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
+		if err != nil {
+			return http.WithStatus(gohttp.StatusNotFound, err)
+		}
+
+		force := req.URL.Query().Get("force") == "true"
+		err = cleanupService.DeleteExhibit(req.Context(), exhibitId, force)
+		if errors.Is(err, domain.ErrExhibitStarting) {
+			return http.WithStatus(gohttp.StatusConflict, errors.New("exhibit is starting, use force=true to delete it anyway"))
+		}
+
+		if err != nil {
+			return fmt.Errorf("error deleting exhibit: %w", err)
+		}
+
+		res.WriteHeader(gohttp.StatusNoContent)
+		return nil
+	}
+}
+
+func handleEvents(handlerService service.ApplicationProvisionerHandlerService) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		span := trace.SpanFromContext(req.Context())
 
 		event := &cloudevents.Event{}
 		err := cloudevents.ReadJson(event, req.Body)
-
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error reading or parsing request body", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return fmt.Errorf("error reading or parsing request body: %w", err)
 		}
 
 		span.AddEvent("request read")
@@ -136,138 +467,143 @@ func handleEvents(handlerService service.ApplicationProvisionerHandlerService, l
 		exhibit := &domain.Exhibit{}
 		err = json.Unmarshal(event.Data(), exhibit)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error unmarshalling cloudevent data", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return fmt.Errorf("error unmarshalling cloudevent data: %w", err)
 		}
 
-		err = handlerService.HandleEvent(ctx, event, exhibit.Id)
+		// the event is handled synchronously, e.g. the answer to a start event is only sent once the exhibit is running
+		err = handlerService.HandleEvent(req.Context(), event, exhibit.Id)
+		if errors.Is(err, domain.ErrUnknownEventType) {
+			return http.WithStatus(gohttp.StatusBadRequest, err)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error handling %s event: %w", event.Type(), err)
+		}
 
-		span.AddEvent("application started")
+		span.AddEvent("event handled")
 
 		res.WriteHeader(gohttp.StatusCreated)
 		err = res.WriteJson(map[string]string{"status": "Started"})
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
-			return
+			return err
 		}
 
 		span.AddEvent("response written")
+		return nil
 	}
 }
 
-func handleExhibitStatus(exhibitService service.ExhibitService, eventing persistence.Eventing, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
-	return func(res *http.Response, req *http.Request) {
-		ctx, span := provider.
-			Tracer("API request").
-			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"]+"/status", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
-		defer span.End()
-
+func handleExhibitStatus(exhibitService service.ExhibitService, eventing persistence.Eventing, log *zap.SugaredLogger) http.ErrorHandlerFunc {
+	return func(res *http.Response, req *http.Request) error {
+		span := trace.SpanFromContext(req.Context())
 		exhibitId := req.Params["id"]
 
 		// get the exhibit
-		_, err := exhibitService.GetExhibitById(ctx, exhibitId)
+		_, err := exhibitService.GetExhibitById(req.Context(), exhibitId)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error getting exhibit", "error", err, "requestId", req.RequestID, "exhibitId", exhibitId)
-			res.WriteHeader(gohttp.StatusInternalServerError)
-			return
+			return http.WithStatus(gohttp.StatusNotFound, err)
 		}
 
 		span.AddEvent("setting up SSE")
 
 		err = res.SetupSSE()
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error setting up SSE", "error", err, "requestId", req.RequestID)
-			gohttp.Error(res, "Streaming unsupported!", gohttp.StatusInternalServerError)
-			return
+			return err
 		}
 
 		defer func() {
-			err = res.CloseSSE()
+			err := res.CloseSSE()
 			if err != nil {
 				log.Warnw("error closing SSE", "error", err, "requestId", req.RequestID)
 			}
 		}()
 
-		span.AddEvent("sending initial message")
-
-		// send initial SSE message
-		err = res.SendMessage("status.subscribed", map[string]string{"exhibitId": exhibitId})
-		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error sending message", "error", err, "requestId", req.RequestID)
-			return
-		}
-
 		if !eventing.CanReceive() {
-			err := res.SendMessage("unsupported", map[string]string{})
+			err = res.SendMessage("status.subscribed", map[string]string{"exhibitId": exhibitId})
 			if err != nil {
-				log.Warnw("error sending message", "error", err, "requestId", req.RequestID)
-				return
+				return err
 			}
 
-			return
+			return res.SendMessage("unsupported", map[string]string{})
 		}
 
 		sseContext, sseContextCancel := context.WithCancel(context.Background())
 		defer sseContextCancel()
 
-		timeOut := time.After(5 * time.Minute)
+		// the client may start the exhibit as soon as it is subscribed, the events of that start must not be missed
+		span.AddEvent("subscribing to starting events")
 		events, cancel, err := eventing.GetExhibitStartingChannel(exhibitId, sseContext)
 		if err != nil {
-			span.RecordError(err)
-			log.Warnw("error getting exhibit starting channel", "error", err, "requestId", req.RequestID)
-			return
+			return fmt.Errorf("error getting exhibit starting channel: %w", err)
 		}
 		defer cancel()
 
+		span.AddEvent("sending initial message")
+		err = res.SendMessage("status.subscribed", map[string]string{"exhibitId": exhibitId})
+		if err != nil {
+			return err
+		}
+
+		timeOut := time.After(5 * time.Minute)
 		for {
 			select {
 			case <-timeOut:
 				log.Warnw("timeout reached, stopping SSE", "requestId", req.RequestID)
-				return
+				return nil
 
-			case event := <-events:
-				//TODO: handle error
-				if event.Error != "" {
-					err := res.SendMessage("status.error", map[string]string{"error": event.Error})
-					if err != nil {
-						log.Warnw("error sending message", "error", err, "requestId", req.RequestID)
-						return
-					}
+			case <-req.Context().Done():
+				return nil
+
+			case event, ok := <-events:
+				if !ok {
+					return res.SendMessage("status.error", map[string]string{"exhibitId": exhibitId, "error": "status updates ended unexpectedly"})
+				}
 
-					return
+				// the start failed, the client gets the error and the stream ends as there won't be any more steps
+				if event.Error != "" {
+					log.Infow("exhibit failed to start", "exhibitId", exhibitId, "step", event.Step, "error", event.Error, "requestId", req.RequestID)
+					return res.SendMessage("status.error", map[string]string{"exhibitId": exhibitId, "error": event.Error})
 				}
 
 				err := res.SendMessage("status.update", event.ToMap())
 				if err != nil {
-					log.Warnw("error sending message", "error", err, "requestId", req.RequestID)
-					return
+					return err
 				}
 
 				if event.CurrentStepCount == event.TotalStepCount {
-					err := res.SendMessage("status.finished", map[string]string{"exhibitId": exhibitId})
-					if err != nil {
-						log.Warnw("error sending message", "error", err, "requestId", req.RequestID)
-						return
-					}
-
-					return
+					return res.SendMessage("status.finished", map[string]string{"exhibitId": exhibitId})
 				}
 			}
 		}
 	}
 }
 
-func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, eventing persistence.Eventing, provisionerHandlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) {
-	r.AddRoute(http.Get("/api/exhibits", getExhibits(exhibitService, log, provider)))
-	r.AddRoute(http.Get("/api/exhibits/{id}", getExhibitById(exhibitService, log, provider)))
-	r.AddRoute(http.Get("/api/exhibits/{id}/status", handleExhibitStatus(exhibitService, eventing, log, provider)))
-	r.AddRoute(http.Post("/api/exhibits", createExhibit(exhibitService, log, provider)))
-	r.AddRoute(http.Post("/api/events", handleEvents(provisionerHandlerService, log, provider)))
+// maxBodySize is the largest request body the api accepts, exhibit definitions are way smaller than this
+const maxBodySize = 1 << 20
+
+func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, eventing persistence.Eventing, provisionerHandlerService service.ApplicationProvisionerHandlerService, cleanupService service.ExhibitCleanupService, updateService service.ExhibitUpdateService, lifecycleService service.ExhibitLifecycleService, snapshotService service.VolumeSnapshotService, c config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	tracing := http.Tracing(provider)
+	bodyLimit := http.BodyLimit(maxBodySize)
+
+	r.AddRoute(http.Get("/api/exhibits", http.HandleErrors(log, getExhibits(exhibitService, c))).With(tracing))
+	// the loading page polls this endpoint, which is cross-origin when exhibits are served on their own host
+	r.AddRoute(http.Get("/api/exhibits/{id}", http.HandleErrors(log, getExhibitById(exhibitService, c))).With(http.Cors("*"), tracing))
+	r.AddRoute(http.Put("/api/exhibits/{id}", http.HandleErrors(log, updateExhibit(exhibitService, updateService, c))).With(tracing, bodyLimit))
+	r.AddRoute(http.Delete("/api/exhibits/{id}", http.HandleErrors(log, deleteExhibit(exhibitService, cleanupService))).With(tracing))
+	r.AddRoute(http.Options("/api/exhibits/{id}", func(res *http.Response, req *http.Request) {}).With(http.Cors("*")))
+	r.AddRoute(http.Get("/api/exhibits/{id}/revisions", http.HandleErrors(log, getExhibitRevisions(exhibitService))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/rollback/{rev}", http.HandleErrors(log, rollbackExhibit(exhibitService, updateService, c))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/start", http.HandleErrors(log, startExhibit(exhibitService, lifecycleService.StartExhibit))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/stop", http.HandleErrors(log, stopExhibit(exhibitService, lifecycleService, c))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/restart", http.HandleErrors(log, startExhibit(exhibitService, lifecycleService.RestartExhibit))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/renew", http.HandleErrors(log, renewExhibit(exhibitService, lifecycleService, c))).With(tracing))
+	r.AddRoute(http.Get("/api/exhibits/{id}/volumes/{name}/snapshots", http.HandleErrors(log, getSnapshots(exhibitService, snapshotService))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits/{id}/volumes/{name}/snapshots", http.HandleErrors(log, createSnapshot(exhibitService, snapshotService))).With(tracing, bodyLimit))
+	r.AddRoute(http.Post("/api/exhibits/{id}/volumes/{name}/snapshots/{snapshot}/restore", http.HandleErrors(log, restoreSnapshot(exhibitService, snapshotService))).With(tracing))
+	r.AddRoute(http.Delete("/api/exhibits/{id}/volumes/{name}/snapshots/{snapshot}", http.HandleErrors(log, deleteSnapshot(exhibitService, snapshotService))).With(tracing))
+	r.AddRoute(http.Get("/api/exhibits/{id}/status", http.HandleErrors(log, handleExhibitStatus(exhibitService, eventing, log))).With(tracing))
+	r.AddRoute(http.Post("/api/exhibits", http.HandleErrors(log, createExhibit(exhibitService, c))).With(tracing, bodyLimit))
+	// editors fetch the schema from wherever the exhibit file is opened
+	r.AddRoute(http.Get("/api/schema/exhibit/{version}", http.HandleErrors(log, getExhibitSchema())).With(http.Cors("*"), tracing))
+	r.AddRoute(http.Post("/api/events", http.HandleErrors(log, handleEvents(provisionerHandlerService))).With(tracing, bodyLimit))
 }
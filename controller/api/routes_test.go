@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+	gohttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeStatusExhibitService struct {
+	service.ExhibitService
+}
+
+func (f fakeStatusExhibitService) GetExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	return domain.Exhibit{Id: id, Name: "test"}, nil
+}
+
+// fakeStartingEventing hands out a channel of its own, the writer it records to tells whether the stream had started already
+type fakeStartingEventing struct {
+	persistence.Eventing
+	events     chan domain.ExhibitStartingStepEvent
+	recorder   *httptest.ResponseRecorder
+	subscribed string
+}
+
+func (f *fakeStartingEventing) CanReceive() bool {
+	return true
+}
+
+func (f *fakeStartingEventing) GetExhibitStartingChannel(string, context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
+	f.subscribed = f.recorder.Body.String()
+	return f.events, func() {}, nil
+}
+
+func streamStatus(t *testing.T, eventing *fakeStartingEventing) string {
+	req := &http.Request{
+		Request: httptest.NewRequest(gohttp.MethodGet, "/api/exhibits/1234/status", nil),
+		Params:  map[string]string{"id": "1234"},
+	}
+
+	err := handleExhibitStatus(fakeStatusExhibitService{}, eventing, zap.NewNop().Sugar())(&http.Response{ResponseWriter: eventing.recorder}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return eventing.recorder.Body.String()
+}
+
+func TestStatusSubscribesBeforeAnnouncingIt(t *testing.T) {
+	eventing := &fakeStartingEventing{events: make(chan domain.ExhibitStartingStepEvent, 1), recorder: httptest.NewRecorder()}
+	eventing.events <- domain.ExhibitStartingStepEvent{ExhibitId: "1234", Step: "done", CurrentStepCount: 1, TotalStepCount: 1}
+
+	body := streamStatus(t, eventing)
+
+	if strings.Contains(eventing.subscribed, "status.subscribed") {
+		t.Error("expected the subscription before status.subscribed was sent")
+	}
+
+	if !strings.Contains(body, "status.subscribed") || !strings.Contains(body, "status.finished") {
+		t.Errorf("expected the stream to be announced and finished, got %q", body)
+	}
+}
+
+func TestStatusEndsWhenEventsEnd(t *testing.T) {
+	eventing := &fakeStartingEventing{events: make(chan domain.ExhibitStartingStepEvent), recorder: httptest.NewRecorder()}
+	close(eventing.events)
+
+	body := streamStatus(t, eventing)
+
+	if !strings.Contains(body, "event: status.error") || strings.Contains(body, "status.finished") {
+		t.Errorf("expected a closed channel to end the stream with an error, got %q", body)
+	}
+}
+
+// runningProvisioner answers like the lifecycle does for an exhibit that is running already
+type runningProvisioner struct {
+	service.ApplicationProvisionerService
+}
+
+func (runningProvisioner) StartApplication(context.Context, string) error {
+	return domain.ErrExhibitRunning
+}
+
+func TestWarmupOfRunningExhibit(t *testing.T) {
+	event, err := domain.NewStartEvent(domain.Exhibit{Id: "1234", Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	req := &http.Request{Request: httptest.NewRequest(gohttp.MethodPost, "/api/events", bytes.NewReader(body))}
+	handler := impl.ApplicationProvisionerHandlerServiceImpl{ApplicationProvisionerService: runningProvisioner{}}
+
+	err = handleEvents(handler)(&http.Response{ResponseWriter: recorder}, req)
+	if err != nil {
+		t.Fatalf("Expected warming up a running exhibit to succeed, got %v", err)
+	}
+
+	if recorder.Code != gohttp.StatusCreated {
+		t.Errorf("Expected %d, got %d", gohttp.StatusCreated, recorder.Code)
+	}
+}
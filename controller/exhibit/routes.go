@@ -8,8 +8,10 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"museum/config"
+	routingmode "museum/config/routing-mode"
 	"museum/domain"
 	"museum/http"
+	"museum/observability"
 	service "museum/service/interface"
 	gohttp "net/http"
 	"regexp"
@@ -24,26 +26,50 @@ type LoadingPageTemplate struct {
 	Exhibit   string
 	Host      string
 	ExhibitId string
+	Url       string
 }
 
-func proxyHandler(exhibitService service.ExhibitService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, c config.Config, provider trace.TracerProvider) http.MuxHandlerFunc {
-	tmpl, _ := template.New("loading").Parse(string(loadingPage))
+// exhibitLookup finds the exhibit a request to the proxy is meant for
+type exhibitLookup func(ctx context.Context, req *http.Request) (domain.Exhibit, error)
 
-	return func(res *http.Response, req *http.Request) {
+func lookupById(exhibitService service.ExhibitService) exhibitLookup {
+	return func(ctx context.Context, req *http.Request) (domain.Exhibit, error) {
 		id, ok := req.Params["id"]
 		if !ok {
-			log.Warn("no id provided", "requestId", req.RequestID)
-			res.WriteHeader(gohttp.StatusBadRequest)
-			return
+			return domain.Exhibit{}, errors.New("no id provided")
+		}
+
+		return exhibitService.GetExhibitById(ctx, id)
+	}
+}
+
+func lookupByName(exhibitService service.ExhibitService) exhibitLookup {
+	return func(ctx context.Context, req *http.Request) (domain.Exhibit, error) {
+		name, ok := req.Params["name"]
+		if !ok {
+			return domain.Exhibit{}, errors.New("no name provided")
 		}
 
-		app, err := exhibitService.GetExhibitById(req.Context(), id)
+		return exhibitService.GetExhibitByName(ctx, name)
+	}
+}
+
+func proxyHandler(lookup exhibitLookup, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, c config.Config, provider trace.TracerProvider, metrics *observability.Metrics) http.MuxHandlerFunc {
+	tmpl, _ := template.New("loading").Parse(string(loadingPage))
+
+	return func(res *http.Response, req *http.Request) {
+		app, err := lookup(req.Context(), req)
 		if err != nil {
-			log.Warnw("error getting exhibit", "error", err, "requestId", req.RequestID, "exhibitId", id)
-			res.WriteHeader(gohttp.StatusInternalServerError)
+			log.Warnw("error getting exhibit", "error", err, "requestId", req.RequestID)
+			res.WriteHeader(gohttp.StatusNotFound)
 			return
 		}
 
+		start := time.Now()
+		defer func() {
+			metrics.ObserveProxyRequest(app.Id, req.Method, res.StatusCode(), time.Since(start))
+		}()
+
 		// if the application is stopping, return a 503
 		if app.RuntimeInfo.Status == domain.Stopping {
 			log.Warnw("application is stopping, returning 503", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
@@ -51,6 +77,13 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 			return
 		}
 
+		// a loading page makes no sense for an upgrade request (e.g. a websocket), the client has to retry
+		if app.RuntimeInfo.Status != domain.Running && req.IsUpgrade() {
+			log.Warnw("application is not running, refusing upgrade request", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
+			res.WriteHeader(gohttp.StatusServiceUnavailable)
+			return
+		}
+
 		// if the application is not running, start it and return the loading page
 		// if the state is "starting", only return the loading page
 		if app.RuntimeInfo.Status != domain.Running {
@@ -62,10 +95,12 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 			defer span.End()
 
 			// if the application is not starting, start it
+			host := c.GetHostname() + ":" + c.GetPort()
 			err := tmpl.Execute(res, LoadingPageTemplate{
 				Exhibit:   app.Name,
-				Host:      c.GetHostname() + ":" + c.GetPort(),
+				Host:      host,
 				ExhibitId: app.Id,
+				Url:       c.GetRoutingMode().Host(app.Name, host) + c.GetRoutingMode().BasePath(app.Id),
 			})
 			span.AddEvent("loading page rendered")
 
@@ -79,7 +114,7 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 					defer subSpan.End()
 
 					subSpan.AddEvent("starting application")
-					err := provisioner.StartApplication(subCtx, id)
+					err := provisioner.StartApplication(subCtx, app.Id)
 					if err != nil {
 						log.Warnw("error starting application", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
 						return
@@ -109,7 +144,7 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 		}
 
 		go func() {
-			err := lastAccessedService.SetLastAccessed(context.Background(), id, time.Now().Unix())
+			err := lastAccessedService.SetLastAccessed(context.Background(), app.Id, time.Now().Unix())
 			if err != nil {
 				return
 			}
@@ -118,8 +153,14 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 	}
 }
 
-func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, config config.Config, provider trace.TracerProvider) {
-	r.AddRoute(http.Any("/exhibit/{id}/>>", proxyHandler(exhibitService, lastAccessedService, proxy, provisioner, log, config, provider)))
+func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, config config.Config, provider trace.TracerProvider, metrics *observability.Metrics) {
+	// every exhibit is served on its own subdomain, e.g. my-exhibit.localhost:8080
+	if config.GetRoutingMode() == routingmode.ModeHost {
+		r.AddRoute(http.Any("/>>", proxyHandler(lookupByName(exhibitService), lastAccessedService, proxy, provisioner, log, config, provider, metrics)).OnHost("{name}." + config.GetHostname()))
+		return
+	}
+
+	r.AddRoute(http.Any("/exhibit/{id}/>>", proxyHandler(lookupById(exhibitService), lastAccessedService, proxy, provisioner, log, config, provider, metrics)))
 
 	defaultRouteReg := regexp.MustCompile("/exhibit/([a-f0-9-]+)")
 	r.SetFallbackHandler(func(writer gohttp.ResponseWriter, req *http.Request) error {
@@ -9,8 +9,6 @@ import (
 
 func healthEndpoint(log *zap.SugaredLogger) func(res *http.Response, req *http.Request) {
 	return func(res *http.Response, req *http.Request) {
-		log.Debug("handling health endpoint request", "requestId", req.RequestID)
-
 		res.WriteHeader(gohttp.StatusOK)
 		err := http.WriteStatus(res, http.Status{Status: "OK"})
 		if err != nil {
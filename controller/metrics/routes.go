@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"museum/http"
+	"museum/observability"
+)
+
+func RegisterRoutes(r *http.Mux, metrics *observability.Metrics, log *zap.SugaredLogger) {
+	handler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorLog: zap.NewStdLog(log.Desugar()),
+	})
+
+	r.AddRoute(http.Get("/metrics", func(res *http.Response, req *http.Request) {
+		handler.ServeHTTP(res, req.Request)
+	}))
+}
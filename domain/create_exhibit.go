@@ -3,4 +3,5 @@ package domain
 type CreateExhibit struct {
 	Exhibit   Exhibit
 	RequestID string
+	Author    string
 }
@@ -0,0 +1,106 @@
+package domain
+
+// StartedObjects returns the indices of the objects that are started, in order if the exhibit has one (only the ordered objects are started then)
+func (e Exhibit) StartedObjects() []int {
+	indices := make([]int, 0, len(e.Objects))
+
+	if e.Order == nil {
+		for i := range e.Objects {
+			indices = append(indices, i)
+		}
+		return indices
+	}
+
+	for _, name := range e.Order {
+		for i, o := range e.Objects {
+			if o.Name == name {
+				indices = append(indices, i)
+			}
+		}
+	}
+
+	return indices
+}
+
+// Dependencies returns the names of the objects every object waits for before it is started.
+// order is a shorthand for a chain, in which every object depends on the one before it. Exhibits without any dependsOn
+// start their objects one after another in the defined order, like before objects could depend on each other
+func (e Exhibit) Dependencies() map[string][]string {
+	dependencies := make(map[string][]string)
+
+	declared := false
+	for _, o := range e.Objects {
+		if len(o.DependsOn) != 0 {
+			declared = true
+		}
+		dependencies[o.Name] = append(dependencies[o.Name], o.DependsOn...)
+	}
+
+	if e.Order == nil && declared {
+		return dependencies
+	}
+
+	previous := ""
+	for _, idx := range e.StartedObjects() {
+		name := e.Objects[idx].Name
+		if previous != "" {
+			dependencies[name] = append(dependencies[name], previous)
+		}
+		previous = name
+	}
+
+	return dependencies
+}
+
+// DependencyCycle returns objects that depend on each other, e.g. [a b a] if a depends on b and b on a, or nil if there are none
+func (e Exhibit) DependencyCycle() []string {
+	dependencies := e.Dependencies()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	path := make([]string, 0)
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, dependency := range dependencies[name] {
+			// unknown objects can't be part of a cycle
+			if _, ok := dependencies[dependency]; !ok {
+				continue
+			}
+
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, o := range e.Objects {
+		if cycle := visit(o.Name); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "errors"
+
+// ErrExhibitStarting is returned when an exhibit can't be changed while it is starting
+var ErrExhibitStarting = errors.New("exhibit is starting")
+
+// ErrInvalidExhibit is wrapped around the errors found while validating an exhibit definition
+var ErrInvalidExhibit = errors.New("invalid exhibit")
+
+// ErrExhibitStopping is returned when an exhibit can't be changed while it is stopping
+var ErrExhibitStopping = errors.New("exhibit is stopping")
+
+// ErrRevisionNotFound is returned when an exhibit has no revision with the requested number
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// ErrExhibitRunning is returned when an exhibit is supposed to be started, but it is running already
+var ErrExhibitRunning = errors.New("exhibit is already running")
+
+// ErrExhibitNotRunning is returned when an exhibit has to be running for an action, e.g. to be stopped
+var ErrExhibitNotRunning = errors.New("exhibit is not running")
+
+// ErrUnknownEventType is returned for events museum can't handle
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// ErrVolumeNotFound is returned when an exhibit has no volume with the requested name
+var ErrVolumeNotFound = errors.New("volume not found")
+
+// ErrSnapshotNotFound is returned when a volume has no snapshot with the requested name
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrSnapshotExists is returned when a snapshot is created with a name that is taken already
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// ErrSnapshotPinned is returned when a snapshot can't be deleted because its volume is pinned to it
+var ErrSnapshotPinned = errors.New("snapshot is pinned by its volume")
+
+// ErrSnapshotsNotSupported is returned for volumes whose driver can't take snapshots
+var ErrSnapshotsNotSupported = errors.New("volume driver doesn't support snapshots")
+
+// ErrInvalidSnapshotName is wrapped around the reason a snapshot name is not valid
+var ErrInvalidSnapshotName = errors.New("invalid snapshot name")
+
+// ErrProfileRelaxed is returned when an object is less strict than the default profile without security.relax
+var ErrProfileRelaxed = errors.New("object is less strict than the default profile, set security.relax to allow it")
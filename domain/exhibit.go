@@ -2,6 +2,7 @@ package domain
 
 type Exhibit struct {
 	Id          string                 `json:"id"`
+	Spec        string                 `json:"spec" yaml:"spec"`
 	Name        string                 `json:"name" yaml:"name"`
 	Expose      string                 `json:"expose" yaml:"expose"`
 	Rewrite     *bool                  `json:"rewrite" yaml:"rewrite"`
@@ -10,7 +11,9 @@ type Exhibit struct {
 	Order       []string               `json:"order" yaml:"order"`
 	Meta        map[string]interface{} `json:"meta" yaml:"meta"`
 	Volumes     []Volume               `json:"volumes" yaml:"volumes"`
-	RuntimeInfo *ExhibitRuntimeInfo    `json:"-"`
+	Proxy       *ProxyConfig           `json:"proxy" yaml:"proxy"`
+	Revision    int                    `json:"revision" yaml:"-"`
+	RuntimeInfo *ExhibitRuntimeInfo    `json:"-" yaml:"-"`
 }
 
 func (e Exhibit) ToDto() ExhibitDto {
@@ -21,19 +24,21 @@ func (e Exhibit) ToDto() ExhibitDto {
 
 	return ExhibitDto{
 		Id:          e.Id,
+		Spec:        e.Spec,
 		Name:        e.Name,
 		RuntimeInfo: e.RuntimeInfo.ToDto(),
 		Lease:       e.Lease,
 		Objects:     objects,
 		Meta:        e.Meta,
+		Revision:    e.Revision,
 	}
 }
 
 func (e Exhibit) GetTotalSteps() int {
 	steps := 0
-	for _, object := range e.Objects {
+	for _, idx := range e.StartedObjects() {
 		steps += 4
-		if object.Livecheck != nil {
+		if e.Objects[idx].Livecheck != nil {
 			steps++
 		}
 	}
@@ -2,16 +2,20 @@ package domain
 
 type ExhibitDto struct {
 	Id          string                 `json:"id"`
+	Spec        string                 `json:"spec"`
 	Name        string                 `json:"name"`
 	RuntimeInfo RuntimeInfoDto         `json:"runtime_info"`
 	Lease       string                 `json:"lease"`
 	Objects     []ObjectDto            `json:"objects"`
 	Meta        map[string]interface{} `json:"meta"`
+	Url         string                 `json:"url"`
+	Revision    int                    `json:"revision"`
 }
 
 func (d ExhibitDto) ToExhibit() Exhibit {
 	return Exhibit{
 		Id:    d.Id,
+		Spec:  d.Spec,
 		Name:  d.Name,
 		Lease: d.Lease,
 		Meta:  d.Meta,
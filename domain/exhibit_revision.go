@@ -0,0 +1,22 @@
+package domain
+
+// ExhibitRevision is an immutable version of an exhibit definition, every change to an exhibit creates a new one
+type ExhibitRevision struct {
+	Revision  int    `json:"revision"`
+	Author    string `json:"author"`
+	Timestamp int64  `json:"timestamp"`
+	Comment   string `json:"comment,omitempty"`
+	// Diff is a unified diff against the previous revision, it is empty for the first one
+	Diff    string  `json:"diff"`
+	Exhibit Exhibit `json:"exhibit"`
+}
+
+func (r ExhibitRevision) ToDto() ExhibitRevisionDto {
+	return ExhibitRevisionDto{
+		Revision:  r.Revision,
+		Author:    r.Author,
+		Timestamp: r.Timestamp,
+		Comment:   r.Comment,
+		Diff:      r.Diff,
+	}
+}
@@ -0,0 +1,9 @@
+package domain
+
+type ExhibitRevisionDto struct {
+	Revision  int    `json:"revision"`
+	Author    string `json:"author"`
+	Timestamp int64  `json:"timestamp"`
+	Comment   string `json:"comment,omitempty"`
+	Diff      string `json:"diff"`
+}
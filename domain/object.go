@@ -1,5 +1,13 @@
 package domain
 
+import (
+	"errors"
+	"regexp"
+)
+
+// volume names end up in directory names on the host, e.g. of scratch copies
+var volumeNameRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}$")
+
 const (
 	LivecheckTypeHttp = "http"
 	LivecheckTypeExec = "exec"
@@ -13,6 +21,9 @@ type Object struct {
 	Environment StringMap  `json:"environment" yaml:"environment"`
 	Mounts      StringMap  `json:"mounts" yaml:"mounts"`
 	Port        *string    `json:"port" yaml:"port"`
+	DependsOn   []string   `json:"dependsOn" yaml:"dependsOn"`
+	Resources   *Resources `json:"resources" yaml:"resources"`
+	Security    *Security  `json:"security" yaml:"security"`
 }
 
 func (o Object) ToDto() ObjectDto {
@@ -28,9 +39,28 @@ type Livecheck struct {
 	Config StringMap `json:"config" yaml:"config"`
 }
 
+// Volume is mounted read only, unless it is a scratch volume
 type Volume struct {
 	Name   string `json:"name" yaml:"name"`
 	Driver Driver `json:"driver" yaml:"driver"`
+	// Scratch mounts a writable copy of the volume instead, the copy is thrown away when the exhibit stops
+	Scratch bool `json:"scratch" yaml:"scratch"`
+	// Snapshot pins the volume to one of its snapshots, which is mounted instead of the volume
+	Snapshot string `json:"snapshot" yaml:"snapshot"`
+}
+
+// CheckVolumeName returns why a name can't be the name of a volume
+func CheckVolumeName(name string) error {
+	if !volumeNameRegex.MatchString(name) {
+		return errors.New("volume name " + name + " must start with a letter or digit and only contain letters, digits, _, . and - (up to 63 characters)")
+	}
+
+	return nil
+}
+
+// IsCopy reports whether the volume is mounted from a copy museum makes whenever the exhibit starts
+func (v Volume) IsCopy() bool {
+	return v.Scratch || v.Snapshot != ""
 }
 
 type Driver struct {
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"fmt"
+	"github.com/docker/go-units"
+	"strconv"
+	"strings"
+)
+
+const (
+	SeccompDefault    = "default"
+	SeccompUnconfined = "unconfined"
+)
+
+// Resources limits what an object may use of its host, empty values don't limit anything
+type Resources struct {
+	// Cpus is the number of cpus, e.g. 0.5
+	Cpus string `json:"cpus" yaml:"cpus"`
+	// Memory is the memory in bytes or with a unit, e.g. 512m
+	Memory string `json:"memory" yaml:"memory"`
+	// Pids is the number of processes
+	Pids int64 `json:"pids" yaml:"pids"`
+}
+
+// Security hardens the container of an object
+type Security struct {
+	ReadOnly *bool `json:"readOnly" yaml:"readOnly"`
+	// Tmpfs are writable directories in memory, e.g. for a read only object, as path or path:options
+	Tmpfs           []string `json:"tmpfs" yaml:"tmpfs"`
+	CapDrop         []string `json:"capDrop" yaml:"capDrop"`
+	CapAdd          []string `json:"capAdd" yaml:"capAdd"`
+	NoNewPrivileges *bool    `json:"noNewPrivileges" yaml:"noNewPrivileges"`
+	// Seccomp is default, unconfined or the path of a profile
+	Seccomp  string `json:"seccomp" yaml:"seccomp"`
+	AppArmor string `json:"apparmor" yaml:"apparmor"`
+	// User is the user (and group) the object runs as, e.g. 1000:1000
+	User string `json:"user" yaml:"user"`
+	// Relax lets the object be less strict than the default profile of museum, in resources as well
+	Relax bool `json:"relax" yaml:"relax"`
+}
+
+// Profile are the resources and security settings of an object
+type Profile struct {
+	Resources Resources
+	Security  Security
+}
+
+// Relaxation is a setting of an object that is less strict than the default profile
+type Relaxation struct {
+	Path    string
+	Message string
+}
+
+// Effective returns the profile an object runs with, the settings of the object replace the ones of the default profile
+func (p Profile) Effective(o Object) Profile {
+	if o.Resources != nil {
+		r := *o.Resources
+		if r.Cpus != "" {
+			p.Resources.Cpus = r.Cpus
+		}
+		if r.Memory != "" {
+			p.Resources.Memory = r.Memory
+		}
+		if r.Pids != 0 {
+			p.Resources.Pids = r.Pids
+		}
+	}
+
+	if o.Security != nil {
+		s := *o.Security
+		if s.ReadOnly != nil {
+			p.Security.ReadOnly = s.ReadOnly
+		}
+		if s.Tmpfs != nil {
+			p.Security.Tmpfs = s.Tmpfs
+		}
+		if s.CapDrop != nil {
+			p.Security.CapDrop = s.CapDrop
+		}
+		if s.CapAdd != nil {
+			p.Security.CapAdd = s.CapAdd
+		}
+		if s.NoNewPrivileges != nil {
+			p.Security.NoNewPrivileges = s.NoNewPrivileges
+		}
+		if s.Seccomp != "" {
+			p.Security.Seccomp = s.Seccomp
+		}
+		if s.AppArmor != "" {
+			p.Security.AppArmor = s.AppArmor
+		}
+		if s.User != "" {
+			p.Security.User = s.User
+		}
+		p.Security.Relax = s.Relax
+	}
+
+	return p
+}
+
+// Relaxations returns where an object is less strict than the default profile, the paths are relative to the object.
+// Values that can't be parsed are left to the validation
+func (p Profile) Relaxations(o Object) []Relaxation {
+	relaxations := make([]Relaxation, 0)
+	add := func(path string, message string) {
+		relaxations = append(relaxations, Relaxation{Path: path, Message: message})
+	}
+
+	e := p.Effective(o)
+	d := p.Resources
+
+	if d.Cpus != "" && exceeds(e.Resources.Cpus, d.Cpus, parseCpus) {
+		add("resources.cpus", "cpus "+e.Resources.Cpus+" exceed the default limit of "+d.Cpus)
+	}
+
+	if d.Memory != "" && exceeds(e.Resources.Memory, d.Memory, units.RAMInBytes) {
+		add("resources.memory", "memory "+e.Resources.Memory+" exceeds the default limit of "+d.Memory)
+	}
+
+	if d.Pids != 0 && e.Resources.Pids > d.Pids {
+		add("resources.pids", "pids "+strconv.FormatInt(e.Resources.Pids, 10)+" exceed the default limit of "+strconv.FormatInt(d.Pids, 10))
+	}
+
+	s := p.Security
+
+	if isTrue(s.ReadOnly) && !isTrue(e.Security.ReadOnly) {
+		add("security.readOnly", "the root filesystem is read only by default")
+	}
+
+	for _, c := range s.CapDrop {
+		if !containsCapability(e.Security.CapDrop, c) {
+			add("security.capDrop", "capability "+c+" is dropped by default")
+		}
+	}
+
+	// capabilities, seccomp, apparmor and root are relaxations whatever the default profile sets,
+	// as even an empty default profile is the one of the container runtime
+	for _, c := range e.Security.CapAdd {
+		if !containsCapability(s.CapAdd, c) {
+			add("security.capAdd", "capability "+c+" is not added by default")
+		}
+	}
+
+	if isTrue(s.NoNewPrivileges) && !isTrue(e.Security.NoNewPrivileges) {
+		add("security.noNewPrivileges", "no new privileges are allowed by default")
+	}
+
+	if seccompProfile(e.Security.Seccomp) != seccompProfile(s.Seccomp) {
+		add("security.seccomp", "the default seccomp profile is "+seccompProfile(s.Seccomp))
+	}
+
+	if e.Security.AppArmor != s.AppArmor {
+		add("security.apparmor", "the default apparmor profile is "+appArmorProfile(s.AppArmor))
+	}
+
+	if IsRootUser(e.Security.User) && !IsRootUser(s.User) {
+		add("security.user", "objects don't run as root by default")
+	}
+
+	return relaxations
+}
+
+// Enforce returns the profile an object runs with, unless the object is less strict than the default profile
+// without security.relax, e.g. because the default profile was tightened after the exhibit was stored
+func (p Profile) Enforce(o Object) (Profile, error) {
+	effective := p.Effective(o)
+	if effective.Security.Relax {
+		return effective, nil
+	}
+
+	relaxations := p.Relaxations(o)
+	if len(relaxations) == 0 {
+		return effective, nil
+	}
+
+	messages := make([]string, len(relaxations))
+	for i, relaxation := range relaxations {
+		messages[i] = relaxation.Message
+	}
+
+	return Profile{}, fmt.Errorf("%w: object %s: %s", ErrProfileRelaxed, o.Name, strings.Join(messages, ", "))
+}
+
+// IsRootUser reports whether a user (and group), e.g. 0:0, is root
+func IsRootUser(user string) bool {
+	name, _, _ := strings.Cut(user, ":")
+	return name == "root" || name == "0"
+}
+
+// NormalizeCapability returns a capability without the CAP_ prefix in upper case, e.g. NET_ADMIN
+func NormalizeCapability(c string) string {
+	return strings.TrimPrefix(strings.ToUpper(c), "CAP_")
+}
+
+func containsCapability(capabilities []string, c string) bool {
+	for _, capability := range capabilities {
+		n := NormalizeCapability(capability)
+		if n == "ALL" || n == NormalizeCapability(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// seccompProfile returns the seccomp profile of a setting, empty is the default one
+func seccompProfile(seccomp string) string {
+	if seccomp == "" {
+		return SeccompDefault
+	}
+
+	return seccomp
+}
+
+// appArmorProfile returns the apparmor profile of a setting, empty is the one of the container runtime
+func appArmorProfile(appArmor string) string {
+	if appArmor == "" {
+		return "the one of the container runtime"
+	}
+
+	return appArmor
+}
+
+func parseCpus(cpus string) (float64, error) {
+	return strconv.ParseFloat(cpus, 64)
+}
+
+// exceeds reports whether value is above limit, an empty value is no limit at all
+func exceeds[T int64 | float64](value string, limit string, parse func(string) (T, error)) bool {
+	if value == "" {
+		return true
+	}
+
+	v, err := parse(value)
+	if err != nil {
+		return false
+	}
+
+	l, err := parse(limit)
+	if err != nil {
+		return false
+	}
+
+	return v > l
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRelaxationsOfEmptyDefaultProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		security Security
+		path     string
+	}{
+		{"nothing", Security{}, ""},
+		{"non root user", Security{User: "1000:1000"}, ""},
+		{"default seccomp", Security{Seccomp: SeccompDefault}, ""},
+		{"dropped capabilities", Security{CapDrop: []string{"ALL"}}, ""},
+		{"added capability", Security{CapAdd: []string{"SYS_ADMIN"}}, "security.capAdd"},
+		{"all capabilities", Security{CapAdd: []string{"ALL"}}, "security.capAdd"},
+		{"re-added capability", Security{CapDrop: []string{"ALL"}, CapAdd: []string{"NET_BIND_SERVICE"}}, "security.capAdd"},
+		{"unconfined seccomp", Security{Seccomp: SeccompUnconfined}, "security.seccomp"},
+		{"seccomp profile", Security{Seccomp: "/etc/shadow"}, "security.seccomp"},
+		{"unconfined apparmor", Security{AppArmor: "unconfined"}, "security.apparmor"},
+		{"root user", Security{User: "0"}, "security.user"},
+		{"root user by name", Security{User: "root:root"}, "security.user"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			security := test.security
+			object := Object{Name: "web", Security: &security}
+			relaxations := Profile{}.Relaxations(object)
+
+			if test.path == "" {
+				if len(relaxations) != 0 {
+					t.Errorf("Expected no relaxations, got %v", relaxations)
+				}
+				return
+			}
+
+			if len(relaxations) != 1 || relaxations[0].Path != test.path {
+				t.Fatalf("Expected a relaxation of %s, got %v", test.path, relaxations)
+			}
+
+			if _, err := (Profile{}).Enforce(object); !errors.Is(err, ErrProfileRelaxed) {
+				t.Errorf("Expected the profile to be enforced, got %v", err)
+			}
+
+			security.Relax = true
+			if _, err := (Profile{}).Enforce(object); err != nil {
+				t.Errorf("Expected relax to allow it, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRelaxationsOfDefaultProfile(t *testing.T) {
+	profile := Profile{Security: Security{CapDrop: []string{"ALL"}, Seccomp: "/profiles/museum.json", AppArmor: "museum", User: "1000"}}
+
+	same := Object{Name: "web", Security: &Security{Seccomp: "/profiles/museum.json", AppArmor: "museum", User: "1001"}}
+	if relaxations := profile.Relaxations(same); len(relaxations) != 0 {
+		t.Errorf("Expected no relaxations, got %v", relaxations)
+	}
+
+	other := Object{Name: "web", Security: &Security{Seccomp: SeccompDefault, AppArmor: "docker-default", User: "0"}}
+	if relaxations := profile.Relaxations(other); len(relaxations) != 3 {
+		t.Errorf("Expected seccomp, apparmor and user to be relaxations, got %v", relaxations)
+	}
+}
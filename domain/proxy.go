@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"github.com/docker/go-units"
+	"time"
+)
+
+const (
+	DefaultProxyTimeout     = 30 * time.Second
+	DefaultProxyIdleTimeout = 90 * time.Second
+)
+
+type ProxyConfig struct {
+	Timeout     string `json:"timeout" yaml:"timeout"`
+	IdleTimeout string `json:"idleTimeout" yaml:"idleTimeout"`
+	MaxBodySize string `json:"maxBodySize" yaml:"maxBodySize"`
+	// NamespaceCookies prefixes the names of cookies with the exhibit id, so exhibits on the same host can't see each others cookies
+	NamespaceCookies bool `json:"namespaceCookies" yaml:"namespaceCookies"`
+}
+
+// GetTimeout returns how long the proxy waits for an application to answer a request
+func (p *ProxyConfig) GetTimeout() (time.Duration, error) {
+	if p == nil || p.Timeout == "" {
+		return DefaultProxyTimeout, nil
+	}
+
+	return parsePositiveDuration(p.Timeout, "proxy timeout")
+}
+
+// GetIdleTimeout returns how long an idle keep-alive connection to an application is kept open
+func (p *ProxyConfig) GetIdleTimeout() (time.Duration, error) {
+	if p == nil || p.IdleTimeout == "" {
+		return DefaultProxyIdleTimeout, nil
+	}
+
+	return parsePositiveDuration(p.IdleTimeout, "proxy idle timeout")
+}
+
+// GetMaxBodySize returns the maximum size of a request body in bytes, 0 means unlimited
+func (p *ProxyConfig) GetMaxBodySize() (int64, error) {
+	if p == nil || p.MaxBodySize == "" {
+		return 0, nil
+	}
+
+	size, err := units.RAMInBytes(p.MaxBodySize)
+	if err != nil {
+		return 0, errors.New("proxy max body size must be a valid size (e.g. 10MB)")
+	}
+
+	if size < 0 {
+		return 0, errors.New("proxy max body size must not be negative")
+	}
+
+	return size, nil
+}
+
+// ShouldNamespaceCookies checks if the names of cookies set by the application have to be namespaced
+func (p *ProxyConfig) ShouldNamespaceCookies() bool {
+	return p != nil && p.NamespaceCookies
+}
+
+func parsePositiveDuration(s string, name string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.New(name + " must be a valid duration")
+	}
+
+	if d <= 0 {
+		return 0, errors.New(name + " must be greater than 0")
+	}
+
+	return d, nil
+}
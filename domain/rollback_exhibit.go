@@ -0,0 +1,8 @@
+package domain
+
+type RollbackExhibit struct {
+	ExhibitId string
+	Revision  int
+	RequestID string
+	Author    string
+}
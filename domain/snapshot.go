@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// snapshot names are file names, e.g. before-migration or 2024-01-01
+var snapshotNameRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$")
+
+// Snapshot is a named state of a volume, it can be restored or pinned by the volume
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	// Size is the size of the compressed snapshot in bytes
+	Size int64 `json:"size"`
+}
+
+// CreateSnapshot is the body of a request creating a snapshot
+type CreateSnapshot struct {
+	Name string `json:"name"`
+}
+
+// CheckSnapshotName returns why a name can't be the name of a snapshot
+func CheckSnapshotName(name string) error {
+	if !snapshotNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %s must start with a letter or digit and only contain letters, digits, _, . and -", ErrInvalidSnapshotName, name)
+	}
+
+	return nil
+}
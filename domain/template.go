@@ -0,0 +1,24 @@
+package domain
+
+import "regexp"
+
+// ObjectAddressRegex matches {{ @object }}, it is replaced with the address of the object
+var ObjectAddressRegex = regexp.MustCompile(`\{\{ *@([\w-+_.]+) *}}`)
+
+// HostRegex matches {{ host }}, it is replaced with the host and base path of the exhibit
+var HostRegex = regexp.MustCompile(`\{\{ *host *}}`)
+
+// ObjectReference returns the object the inside of a template (without the braces) references, if it is an {{ @object }} template
+func ObjectReference(template string) (string, bool) {
+	matches := ObjectAddressRegex.FindStringSubmatch("{{" + template + "}}")
+	if len(matches) != 2 {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// IsHostTemplate checks if the inside of a template (without the braces) is {{ host }}
+func IsHostTemplate(template string) bool {
+	return HostRegex.MatchString("{{" + template + "}}")
+}
@@ -0,0 +1,9 @@
+package domain
+
+type UpdateExhibit struct {
+	Exhibit   Exhibit
+	RequestID string
+	Author    string
+	// Comment is stored with the revision, e.g. to mark it as a rollback
+	Comment string
+}
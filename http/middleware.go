@@ -0,0 +1,216 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Middleware wraps a handler, e.g. to trace or log requests
+type Middleware func(next MuxHandlerFunc) MuxHandlerFunc
+
+// ErrorHandlerFunc is a handler that returns its errors instead of writing them, see HandleErrors
+type ErrorHandlerFunc func(*Response, *Request) error
+
+// StatusError is an error that is written with a specific status code by HandleErrors
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// WithStatus attaches a status code to an error, errors without a status code are written as a 500
+func WithStatus(status int, err error) error {
+	return &StatusError{Status: status, Err: err}
+}
+
+// chain wraps a handler with middlewares, the first middleware is the outermost one
+func chain(handler MuxHandlerFunc, middlewares ...[]Middleware) MuxHandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		for j := len(middlewares[i]) - 1; j >= 0; j-- {
+			handler = middlewares[i][j](handler)
+		}
+	}
+
+	return handler
+}
+
+// HandleErrors adapts an ErrorHandlerFunc, a returned error is recorded on the span of the request,
+// logged and written to the client (unless the handler already started writing a response)
+func HandleErrors(log *zap.SugaredLogger, handler ErrorHandlerFunc) MuxHandlerFunc {
+	return func(res *Response, req *Request) {
+		err := handler(res, req)
+		if err == nil {
+			return
+		}
+
+		trace.SpanFromContext(req.Context()).RecordError(err)
+		log.Warnw("error handling request", "error", err, "method", req.Method, "path", req.URL.Path, "requestId", req.RequestID)
+
+		if res.Written() {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		var statusErr *StatusError
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &statusErr):
+			status = statusErr.Status
+		case errors.As(err, &maxBytesErr):
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		res.WriteHeader(status)
+		_ = res.WriteJson(map[string]string{"status": http.StatusText(status), "error": err.Error()})
+	}
+}
+
+// Tracing starts a span for every request, handlers can get it with trace.SpanFromContext(req.Context())
+func Tracing(provider trace.TracerProvider) Middleware {
+	tracer := provider.Tracer("API request")
+
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method+" "+req.URL.Path, trace.WithAttributes(
+				attribute.String("requestId", req.RequestID),
+				attribute.String("host", req.Host),
+			))
+
+			defer func() {
+				// the span is ended before Recovery gets to see the panic, so it has to be recorded here
+				if err := recover(); err != nil {
+					span.SetStatus(codes.Error, fmt.Sprint(err))
+					span.End()
+					panic(err)
+				}
+
+				span.SetAttributes(attribute.Int("status", res.StatusCode()))
+				if res.StatusCode() >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(res.StatusCode()))
+				}
+				span.End()
+			}()
+
+			req.Request = req.Request.WithContext(ctx)
+			next(res, req)
+		}
+	}
+}
+
+// Recovery turns a panicking handler into a 500 response instead of a dropped connection
+func Recovery(log *zap.SugaredLogger) Middleware {
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+
+				// net/http uses this panic to abort a response on purpose
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				log.Errorw("panic while handling request", "error", err, "stack", string(debug.Stack()), "requestId", req.RequestID)
+
+				if !res.Written() {
+					res.WriteErr(fmt.Errorf("panic: %v", err))
+				}
+			}()
+
+			next(res, req)
+		}
+	}
+}
+
+// Logging logs when a handler starts and finishes handling a request, including its params
+// the access log is written by the Mux itself, so this is on debug level
+func Logging(log *zap.SugaredLogger) Middleware {
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			start := time.Now()
+			log.Debugw("handling request", "method", req.Method, "path", req.URL.Path, "params", req.Params, "requestId", req.RequestID)
+
+			next(res, req)
+
+			log.Debugw("request handled", "method", req.Method, "path", req.URL.Path, "status", res.StatusCode(), "duration", time.Since(start).Seconds(), "requestId", req.RequestID)
+		}
+	}
+}
+
+// Cors allows cross-origin requests from the given origins ("*" allows every origin) and answers preflight requests
+func Cors(origins ...string) Middleware {
+	allowed := make(map[string]bool)
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+
+	methods := strings.Join([]string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}, ", ")
+	maxAge := strconv.Itoa(int((10 * time.Minute).Seconds()))
+
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			origin := req.Header.Get("Origin")
+
+			switch {
+			case allowed["*"]:
+				res.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && allowed[origin]:
+				res.Header().Set("Access-Control-Allow-Origin", origin)
+				res.Header().Add("Vary", "Origin")
+			default:
+				next(res, req)
+				return
+			}
+
+			// preflight request
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				res.Header().Set("Access-Control-Allow-Methods", methods)
+				if headers := req.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					res.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				res.Header().Set("Access-Control-Max-Age", maxAge)
+				res.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next(res, req)
+		}
+	}
+}
+
+// BodyLimit answers requests with a body larger than limit bytes with a 413,
+// reading more than limit bytes of a body without a Content-Length fails
+func BodyLimit(limit int64) Middleware {
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			if req.ContentLength > limit {
+				res.WriteHeader(http.StatusRequestEntityTooLarge)
+				_ = WriteStatus(res, Status{Status: "Request Entity Too Large"})
+				return
+			}
+
+			req.Body = http.MaxBytesReader(res, req.Body, limit)
+			next(res, req)
+		}
+	}
+}
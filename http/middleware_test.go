@@ -0,0 +1,104 @@
+package http
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func record(name string, calls *[]string) Middleware {
+	return func(next MuxHandlerFunc) MuxHandlerFunc {
+		return func(res *Response, req *Request) {
+			*calls = append(*calls, name)
+			next(res, req)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	calls := make([]string, 0)
+
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.Use(record("first", &calls), record("second", &calls))
+	mux.AddRoute(Get("/test", func(res *Response, req *Request) {
+		calls = append(calls, "handler")
+	}).With(record("route", &calls)))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if strings.Join(calls, ",") != "first,second,route,handler" {
+		t.Errorf("Expected middlewares to run in order, got %v", calls)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.Use(Recovery(zap.NewNop().Sugar()))
+	mux.AddRoute(Get("/test", func(res *Response, req *Request) {
+		panic("oops")
+	}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/test", HandleErrors(zap.NewNop().Sugar(), func(res *Response, req *Request) error {
+		return WithStatus(http.StatusNotFound, errors.New("not found"))
+	})))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+
+	if !strings.Contains(rec.Body.String(), "not found") {
+		t.Errorf("Expected the error to be written, got %s", rec.Body.String())
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Post("/test", func(res *Response, req *Request) {
+		t.Errorf("Expected the handler to not be called")
+	}).With(BodyLimit(4)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("too large")))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rec.Code)
+	}
+}
+
+func TestCorsPreflight(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Any("/test", func(res *Response, req *Request) {
+		t.Errorf("Expected the handler to not be called")
+	}).With(Cors("https://example.org")))
+
+	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+	req.Header.Set("Origin", "https://example.org")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", rec.Code)
+	}
+
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://example.org" {
+		t.Errorf("Expected origin to be allowed, got %s", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
@@ -0,0 +1,113 @@
+package path
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Host is matched against the Host header of a request, e.g. "{name}.museum.example.org"
+// wildcard segments span exactly one DNS label, the port of the request is ignored
+type Host []pathSegment
+
+var hostLabelRegex = regexp.MustCompile("^[a-z0-9]([a-z0-9-]*[a-z0-9])?$")
+
+func ConstructHost(host string) Host {
+	dynamicRegex, _ := regexp.Compile("^\\{(\\w+)\\}$")
+
+	if host == "" {
+		panic("illegal host format " + host)
+	}
+
+	parts := strings.Split(strings.ToLower(host), ".")
+	segments := make([]pathSegment, 0)
+	for _, part := range parts {
+		if dynamicRegex.MatchString(part) {
+			sub := dynamicRegex.FindStringSubmatch(part)
+			segments = append(segments, &WildcardPathSegment{
+				VariableName: sub[1],
+			})
+		} else if hostLabelRegex.MatchString(part) {
+			segments = append(segments, &namedPathSegment{
+				Name: part,
+			})
+		} else {
+			panic("illegal host format " + host)
+		}
+	}
+
+	return segments
+}
+
+func (h Host) Match(host string) (Host, bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	parts := strings.Split(strings.ToLower(host), ".")
+	if len(parts) != len(h) {
+		return nil, false
+	}
+
+	// wildcard segments are copied, so concurrent requests don't overwrite each other's values
+	matched := make([]pathSegment, len(h))
+	for i, part := range parts {
+		if w, ok := h[i].(*WildcardPathSegment); ok {
+			if !hostLabelRegex.MatchString(part) {
+				return nil, false
+			}
+
+			matched[i] = &WildcardPathSegment{
+				VariableName: w.VariableName,
+				Value:        part,
+			}
+			continue
+		}
+
+		if !h[i].match(part) {
+			return nil, false
+		}
+		matched[i] = h[i]
+	}
+
+	return matched, true
+}
+
+// Covers reports if h matches every host other matches, e.g. "{name}.localhost" covers "foo.localhost"
+func (h Host) Covers(other Host) bool {
+	if len(h) != len(other) {
+		return false
+	}
+
+	for i, segment := range h {
+		if !covers(segment, other[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Overlaps reports if there is a host matched by both h and other, e.g. "{name}.localhost" and "foo.{domain}"
+func (h Host) Overlaps(other Host) bool {
+	if len(h) != len(other) {
+		return false
+	}
+
+	for i, segment := range h {
+		if !overlaps(segment, other[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h Host) String() string {
+	parts := make([]string, len(h))
+	for i, segment := range h {
+		parts[i] = segmentString(segment)
+	}
+
+	return strings.Join(parts, ".")
+}
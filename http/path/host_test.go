@@ -0,0 +1,55 @@
+package path
+
+import "testing"
+
+func TestMatchHostWithWildcard(t *testing.T) {
+	host := ConstructHost("{name}.localhost")
+	var segments Host
+	var ok bool
+
+	if segments, ok = host.Match("my-site.localhost:8080"); !ok {
+		t.Errorf("Expected my-site.localhost:8080 to match {name}.localhost")
+	}
+
+	if segments[0].(*WildcardPathSegment).Value != "my-site" {
+		t.Errorf("Expected name to be my-site, got %s", segments[0].(*WildcardPathSegment).Value)
+	}
+
+	if host[0].(*WildcardPathSegment).Value != "" {
+		t.Errorf("Expected the host pattern to stay untouched")
+	}
+}
+
+func TestMatchHostIsCaseInsensitive(t *testing.T) {
+	host := ConstructHost("{name}.museum.example.org")
+
+	if _, ok := host.Match("My-Site.Museum.Example.org"); !ok {
+		t.Errorf("Expected My-Site.Museum.Example.org to match {name}.museum.example.org")
+	}
+}
+
+func TestMatchHostWithoutSubdomain(t *testing.T) {
+	host := ConstructHost("{name}.localhost")
+
+	if _, ok := host.Match("localhost:8080"); ok {
+		t.Errorf("Expected localhost:8080 to not match {name}.localhost")
+	}
+}
+
+func TestMatchHostWithDifferentDomain(t *testing.T) {
+	host := ConstructHost("{name}.localhost")
+
+	if _, ok := host.Match("my-site.example"); ok {
+		t.Errorf("Expected my-site.example to not match {name}.localhost")
+	}
+}
+
+func TestIllegalHost(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	ConstructHost("{name}.local_host")
+}
@@ -113,3 +113,127 @@ func (p Path) Match(path string) (Path, bool) {
 
 	return clone, true
 }
+
+// Covers reports if p matches every path other matches, e.g. "/foo/{id}" covers "/foo/bar" and "/foo/>>" covers "/foo/bar/baz"
+// a route that is covered by a route registered before it is unreachable
+func (p Path) Covers(other Path) bool {
+	if len(p) == 0 || len(other) == 0 {
+		return len(p) == len(other)
+	}
+
+	_, rest := p[len(p)-1].(*RestPathSegment)
+	_, otherRest := other[len(other)-1].(*RestPathSegment)
+
+	if !rest && (otherRest || len(p) != len(other)) {
+		return false
+	}
+
+	for i, segment := range p {
+		if _, ok := segment.(*RestPathSegment); ok || i >= len(other) {
+			return true
+		}
+
+		// other matches anything from here on, so p has to as well
+		if _, ok := other[i].(*RestPathSegment); ok {
+			return p[i:].matchesAnything()
+		}
+
+		if !covers(segment, other[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Overlaps reports if there is a path matched by both p and other, e.g. "/{id}/x" and "/a/{name}" both match "/a/x"
+func (p Path) Overlaps(other Path) bool {
+	// the root path is the only one matching "/", which wildcards and rest segments match as well
+	if len(p) == 0 {
+		_, ok := other.Match("/")
+		return ok
+	}
+
+	if len(other) == 0 {
+		_, ok := p.Match("/")
+		return ok
+	}
+
+	for i := 0; i < len(p) || i < len(other); i++ {
+		// a rest segment matches whatever the other path has left, even nothing
+		if (i < len(p) && isRest(p[i])) || (i < len(other) && isRest(other[i])) {
+			return true
+		}
+
+		if i >= len(p) || i >= len(other) {
+			return false
+		}
+
+		if !overlaps(p[i], other[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p Path) matchesAnything() bool {
+	for _, segment := range p {
+		if _, ok := segment.(*namedPathSegment); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// covers reports if a segment matches everything the other segment matches
+func covers(segment, other pathSegment) bool {
+	switch s := segment.(type) {
+	case *WildcardPathSegment, *RestPathSegment:
+		return true
+	case *namedPathSegment:
+		o, ok := other.(*namedPathSegment)
+		return ok && o.Name == s.Name
+	default:
+		return false
+	}
+}
+
+// overlaps reports if there is a segment matched by both segments
+func overlaps(segment, other pathSegment) bool {
+	s, ok := segment.(*namedPathSegment)
+	if !ok {
+		return true
+	}
+
+	o, ok := other.(*namedPathSegment)
+	return !ok || o.Name == s.Name
+}
+
+func isRest(segment pathSegment) bool {
+	_, ok := segment.(*RestPathSegment)
+	return ok
+}
+
+func (p Path) String() string {
+	parts := make([]string, len(p))
+	for i, segment := range p {
+		parts[i] = segmentString(segment)
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+func segmentString(segment pathSegment) string {
+	switch s := segment.(type) {
+	case *namedPathSegment:
+		return s.Name
+	case *WildcardPathSegment:
+		return "{" + s.VariableName + "}"
+	case *RestPathSegment:
+		return ">>"
+	default:
+		return ""
+	}
+}
@@ -232,3 +232,61 @@ func TestPathMatchFail2(t *testing.T) {
 		t.Errorf("Expected /foo to not match /foo/bar")
 	}
 }
+
+func TestCovers(t *testing.T) {
+	cases := []struct {
+		path, other string
+		covers      bool
+	}{
+		{"/foo/{id}", "/foo/bar", true},
+		{"/foo/{id}", "/foo/{name}", true},
+		{"/foo/bar", "/foo/{id}", false},
+		{"/foo/{id}", "/foo/{id}/bar", false},
+		{"/foo/>>", "/foo/bar/baz", true},
+		{"/foo/>>", "/bar/baz", false},
+		{"/foo/{id}/>>", "/foo/>>", true},
+		{"/foo/bar/>>", "/foo/>>", false},
+		{"/", "/", true},
+		{"/", "/foo", false},
+	}
+
+	for _, c := range cases {
+		if ConstructPath(c.path).Covers(ConstructPath(c.other)) != c.covers {
+			t.Errorf("Expected %s covers %s to be %t", c.path, c.other, c.covers)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	cases := []struct {
+		path, other string
+		overlaps    bool
+	}{
+		{"/{id}/x", "/a/{name}", true},
+		{"/foo/bar", "/foo/{id}", true},
+		{"/foo/bar", "/foo/baz", false},
+		{"/foo/{id}", "/foo/{id}/bar", false},
+		{"/foo/>>", "/foo", true},
+		{"/{id}/>>", "/foo/bar/baz", true},
+		{"/foo/>>", "/bar/>>", false},
+		{"/", "/{id}", true},
+		{"/", "/foo", false},
+	}
+
+	for _, c := range cases {
+		if ConstructPath(c.path).Overlaps(ConstructPath(c.other)) != c.overlaps {
+			t.Errorf("Expected %s overlaps %s to be %t", c.path, c.other, c.overlaps)
+		}
+
+		if ConstructPath(c.other).Overlaps(ConstructPath(c.path)) != c.overlaps {
+			t.Errorf("Expected %s overlaps %s to be %t", c.other, c.path, c.overlaps)
+		}
+	}
+}
+
+func TestPathString(t *testing.T) {
+	path := "/foo/{id}/>>"
+	if ConstructPath(path).String() != path {
+		t.Errorf("Expected %s, got %s", path, ConstructPath(path).String())
+	}
+}
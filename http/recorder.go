@@ -0,0 +1,81 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// statusRecorder remembers the status code and the size of a response for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+
+	// discardBody is set for HEAD requests answered by a GET route
+	discardBody bool
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	if s.status == 0 {
+		s.status = statusCode
+	}
+
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+
+	if s.discardBody {
+		return len(b), nil
+	}
+
+	n, err := s.ResponseWriter.Write(b)
+	s.size += int64(n)
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	flusher, ok := s.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	flusher.Flush()
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking unsupported")
+	}
+
+	// whatever is sent over a hijacked connection is out of our sight, it's most likely a protocol switch though
+	if s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+
+	return hijacker.Hijack()
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// StatusCode returns the status code sent to the client, 200 if nothing was sent yet
+func (s *statusRecorder) StatusCode() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+
+	return s.status
+}
+
+// Written reports if the status code was sent to the client already
+func (s *statusRecorder) Written() bool {
+	return s.status != 0
+}
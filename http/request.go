@@ -1,6 +1,9 @@
 package http
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 type Request struct {
 	*http.Request
@@ -9,3 +12,20 @@ type Request struct {
 	RestPath       string
 	RawQueryParams string
 }
+
+// IsUpgrade checks if the client requested a protocol upgrade (e.g. to a websocket)
+func (r *Request) IsUpgrade() bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
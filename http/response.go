@@ -1,9 +1,11 @@
 package http
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 )
 
@@ -109,3 +111,41 @@ func (r *Response) CloseSSE() error {
 
 	return nil
 }
+
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking unsupported")
+	}
+
+	return hijacker.Hijack()
+}
+
+func (r *Response) Flush() {
+	flusher, ok := r.ResponseWriter.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	flusher.Flush()
+}
+
+// StatusCode returns the status code sent to the client so far
+func (r *Response) StatusCode() int {
+	recorder, ok := r.ResponseWriter.(*statusRecorder)
+	if !ok {
+		return 0
+	}
+
+	return recorder.StatusCode()
+}
+
+// Written reports if the handler already started writing the response, so no error status can be sent anymore
+func (r *Response) Written() bool {
+	recorder, ok := r.ResponseWriter.(*statusRecorder)
+	if !ok {
+		return false
+	}
+
+	return recorder.Written()
+}
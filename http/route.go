@@ -11,6 +11,66 @@ type Route struct {
 	Path    path.Path
 	Handler MuxHandlerFunc
 	Method  string
+	Host    path.Host
+
+	Middlewares []Middleware
+}
+
+// OnHost restricts a route to requests with a matching Host header (e.g. "{name}.example.org")
+// host routes take precedence over routes without a host
+func (r Route) OnHost(host string) Route {
+	r.Host = path.ConstructHost(host)
+	return r
+}
+
+// shadows reports if r matches every request other matches, so other could never be reached when registered after r
+func (r Route) shadows(other Route) bool {
+	if r.Method != "*" && r.Method != other.Method {
+		return false
+	}
+
+	// host routes are checked before all other routes, so they are only compared among themselves
+	if (r.Host == nil) != (other.Host == nil) {
+		return false
+	}
+
+	if r.Host != nil && !r.Host.Covers(other.Host) {
+		return false
+	}
+
+	return r.Path.Covers(other.Path)
+}
+
+// overlaps reports if there is a request both r and other match
+func (r Route) overlaps(other Route) bool {
+	if r.Method != "*" && other.Method != "*" && r.Method != other.Method {
+		return false
+	}
+
+	if (r.Host == nil) != (other.Host == nil) {
+		return false
+	}
+
+	if r.Host != nil && !r.Host.Overlaps(other.Host) {
+		return false
+	}
+
+	return r.Path.Overlaps(other.Path)
+}
+
+func (r Route) String() string {
+	host := ""
+	if r.Host != nil {
+		host = r.Host.String()
+	}
+
+	return r.Method + " " + host + r.Path.String()
+}
+
+// With adds middlewares that only apply to this route, they run after the middlewares added with Mux.Use
+func (r Route) With(middlewares ...Middleware) Route {
+	r.Middlewares = append(append([]Middleware(nil), r.Middlewares...), middlewares...)
+	return r
 }
 
 func Any(p string, handler MuxHandlerFunc) Route {
@@ -36,3 +96,43 @@ func Post(p string, handler MuxHandlerFunc) Route {
 		Method:  http.MethodPost,
 	}
 }
+
+func Put(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodPut,
+	}
+}
+
+func Patch(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodPatch,
+	}
+}
+
+func Delete(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodDelete,
+	}
+}
+
+func Head(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodHead,
+	}
+}
+
+func Options(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodOptions,
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"go.uber.org/zap"
 	"museum/http/path"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 )
 
 type FallbackHandler func(writer http.ResponseWriter, request *Request) error
@@ -16,6 +18,8 @@ type Mux struct {
 	mux        *http.ServeMux
 	log        *zap.SugaredLogger
 	fallbackFn *FallbackHandler
+
+	middlewares []Middleware
 }
 
 type Status struct {
@@ -33,42 +37,35 @@ func WriteStatus(writer http.ResponseWriter, status Status) error {
 	return err
 }
 
-func (r *Mux) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+func (r *Mux) ServeHTTP(w http.ResponseWriter, request *http.Request) {
 	requestId := uuid.New().String()
-	r.log.Debugw("request received", "method", request.Method, "path", request.URL.Path, "requestId", requestId)
 
-	for _, route := range r.routes {
-		if segments, ok := route.Path.Match(request.URL.Path); ok {
-			if route.Method == request.Method || route.Method == "*" {
-				restPath := ""
-
-				pathParams := make(map[string]string)
-				for _, segment := range segments {
-					if w, ok := segment.(*path.WildcardPathSegment); ok {
-						pathParams[w.VariableName] = w.Value
-					}
-
-					if w, ok := segment.(*path.RestPathSegment); ok {
-						restPath = w.Value
-					}
-				}
-
-				queryParams := strings.Split(request.URL.RequestURI(), "?")
-				rawQueryParams := ""
-				if len(queryParams) > 1 {
-					rawQueryParams = queryParams[1]
-				}
-
-				route.Handler(&Response{writer}, &Request{
-					Request:        request,
-					Params:         pathParams,
-					RequestID:      requestId,
-					RestPath:       restPath,
-					RawQueryParams: rawQueryParams,
-				})
-				return
-			}
+	start := time.Now()
+	writer := &statusRecorder{ResponseWriter: w}
+	defer r.logAccess(writer, request, requestId, start)
+
+	if r.serve(writer, request, requestId, request.Method) {
+		return
+	}
+
+	// HEAD requests are answered by the GET route, without sending the body
+	if request.Method == http.MethodHead {
+		writer.discardBody = true
+		if r.serve(writer, request, requestId, http.MethodGet) {
+			return
 		}
+		writer.discardBody = false
+	}
+
+	// the path exists, just not for this method
+	if allowed := r.allowedMethods(request); len(allowed) > 0 {
+		writer.Header().Set("Allow", strings.Join(allowed, ", "))
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		err := WriteStatus(writer, Status{Status: "Method Not Allowed"})
+		if err != nil {
+			r.log.Warnw("error writing status", "error", err, "requestId", requestId)
+		}
+		return
 	}
 
 	if r.fallbackFn != nil {
@@ -92,16 +89,152 @@ func (r *Mux) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// logAccess writes a structured access log entry once a request has been handled
+func (r *Mux) logAccess(writer *statusRecorder, request *http.Request, requestId string, start time.Time) {
+	r.log.Infow("access",
+		"method", request.Method,
+		"host", request.Host,
+		"path", request.URL.Path,
+		"status", writer.StatusCode(),
+		"size", writer.size,
+		"duration", time.Since(start).Seconds(),
+		"remoteAddr", request.RemoteAddr,
+		"userAgent", request.UserAgent(),
+		"referer", request.Referer(),
+		"requestId", requestId,
+	)
+}
+
+// serve dispatches a request to the first route matching its host, its path and the given method
+func (r *Mux) serve(writer http.ResponseWriter, request *http.Request, requestId string, method string) bool {
+	// routes bound to a host are checked first, so they can shadow every other route
+	for _, route := range r.routes {
+		if route.Host == nil {
+			continue
+		}
+
+		hostSegments, ok := route.Host.Match(request.Host)
+		if !ok {
+			continue
+		}
+
+		if r.serveRoute(route, hostSegments, method, writer, request, requestId) {
+			return true
+		}
+	}
+
+	for _, route := range r.routes {
+		if route.Host != nil {
+			continue
+		}
+
+		if r.serveRoute(route, nil, method, writer, request, requestId) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedMethods returns the methods of all routes matching the host and the path of a request
+func (r *Mux) allowedMethods(request *http.Request) []string {
+	methods := make(map[string]bool)
+	for _, route := range r.routes {
+		if route.Host != nil {
+			if _, ok := route.Host.Match(request.Host); !ok {
+				continue
+			}
+		}
+
+		if _, ok := route.Path.Match(request.URL.Path); !ok {
+			continue
+		}
+
+		methods[route.Method] = true
+		if route.Method == http.MethodGet {
+			methods[http.MethodHead] = true
+		}
+	}
+
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+
+	return allowed
+}
+
+func (r *Mux) serveRoute(route Route, hostSegments path.Host, method string, writer http.ResponseWriter, request *http.Request, requestId string) bool {
+	if route.Method != method && route.Method != "*" {
+		return false
+	}
+
+	segments, ok := route.Path.Match(request.URL.Path)
+	if !ok {
+		return false
+	}
+
+	restPath := ""
+
+	pathParams := make(map[string]string)
+	for _, segment := range append(hostSegments, segments...) {
+		if w, ok := segment.(*path.WildcardPathSegment); ok {
+			pathParams[w.VariableName] = w.Value
+		}
+
+		if w, ok := segment.(*path.RestPathSegment); ok {
+			restPath = w.Value
+		}
+	}
+
+	queryParams := strings.Split(request.URL.RequestURI(), "?")
+	rawQueryParams := ""
+	if len(queryParams) > 1 {
+		rawQueryParams = queryParams[1]
+	}
+
+	handler := chain(route.Handler, r.middlewares, route.Middlewares)
+	handler(&Response{writer}, &Request{
+		Request:        request,
+		Params:         pathParams,
+		RequestID:      requestId,
+		RestPath:       restPath,
+		RawQueryParams: rawQueryParams,
+	})
+
+	return true
+}
+
 func NewMux(log *zap.SugaredLogger) *Mux {
 	return &Mux{
 		log: log,
 	}
 }
 
+// AddRoute registers a route, it panics if the route can never be reached
+// because a route registered before it already matches all of its requests,
+// or if both match some of the same requests and neither is more specific (e.g. "/{id}/x" and "/a/{name}"),
+// routes only may overlap if the more specific one is registered first
 func (r *Mux) AddRoute(route Route) {
+	for _, existing := range r.routes {
+		if existing.shadows(route) {
+			panic("route " + route.String() + " is shadowed by " + existing.String())
+		}
+
+		if existing.overlaps(route) && !route.shadows(existing) {
+			panic("route " + route.String() + " partially overlaps with " + existing.String())
+		}
+	}
+
 	r.routes = append(r.routes, route)
 }
 
+// Use adds middlewares that apply to every route, the first middleware is the outermost one
+func (r *Mux) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
 func (r *Mux) SetFallbackHandler(fallbackHandler FallbackHandler) {
 	r.fallbackFn = &fallbackHandler
 }
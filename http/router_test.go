@@ -0,0 +1,76 @@
+package http
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/test", func(res *Response, req *Request) {}))
+	mux.AddRoute(Delete("/test", func(res *Response, req *Request) {}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/test", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+
+	if rec.Header().Get("Allow") != "DELETE, GET, HEAD" {
+		t.Errorf("Expected Allow header to be DELETE, GET, HEAD, got %s", rec.Header().Get("Allow"))
+	}
+}
+
+func TestHeadFromGet(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/test", func(res *Response, req *Request) {
+		res.Header().Set("X-Test", "test")
+		_, _ = res.Write([]byte("body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/test", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Test") != "test" {
+		t.Errorf("Expected HEAD to be answered by the GET route, got %d", rec.Code)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %s", rec.Body.String())
+	}
+}
+
+func TestShadowedRoute(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/exhibits/{id}", func(res *Response, req *Request) {}))
+	mux.AddRoute(Get("/exhibits/{name}", func(res *Response, req *Request) {}))
+}
+
+func TestPartiallyOverlappingRoute(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/exhibits/{id}/status", func(res *Response, req *Request) {}))
+	mux.AddRoute(Any("/exhibits/status/{name}", func(res *Response, req *Request) {}))
+}
+
+func TestNotShadowedRoute(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	mux.AddRoute(Get("/exhibits/status", func(res *Response, req *Request) {}))
+	mux.AddRoute(Get("/exhibits/{id}", func(res *Response, req *Request) {}))
+	mux.AddRoute(Post("/exhibits/{id}", func(res *Response, req *Request) {}))
+	mux.AddRoute(Any("/>>", func(res *Response, req *Request) {}).OnHost("{name}.localhost"))
+}
@@ -0,0 +1,162 @@
+package observability
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/grpc"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Metrics holds all prometheus metrics of museum, they are exposed on /metrics
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	ProxyRequests        *prometheus.CounterVec
+	ProxyRequestDuration *prometheus.HistogramVec
+
+	ExhibitStarts        *prometheus.CounterVec
+	ExhibitStartDuration *prometheus.HistogramVec
+	ExhibitStartSteps    *prometheus.HistogramVec
+	ExhibitStops         *prometheus.CounterVec
+	ExhibitCleanups      *prometheus.CounterVec
+
+	EtcdDuration *prometheus.HistogramVec
+	NatsDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		ProxyRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "museum_proxy_requests_total",
+			Help: "Requests proxied to exhibits",
+		}, []string{"exhibit", "method", "code"}),
+		ProxyRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "museum_proxy_request_duration_seconds",
+			Help:    "Duration of requests proxied to exhibits, including loading pages",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"exhibit"}),
+
+		ExhibitStarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "museum_exhibit_starts_total",
+			Help: "Exhibit starts (cold starts) by result",
+		}, []string{"exhibit", "result"}),
+		ExhibitStartDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "museum_exhibit_start_duration_seconds",
+			Help:    "Duration of exhibit starts",
+			Buckets: []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		}, []string{"result"}),
+		ExhibitStartSteps: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "museum_exhibit_start_step_duration_seconds",
+			Help:    "Duration of the steps of starting an exhibit object",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"step"}),
+		ExhibitStops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "museum_exhibit_stops_total",
+			Help: "Exhibit stops by result",
+		}, []string{"exhibit", "result"}),
+		ExhibitCleanups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "museum_exhibit_cleanups_total",
+			Help: "Exhibit cleanups by result",
+		}, []string{"exhibit", "result"}),
+
+		EtcdDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "museum_etcd_operation_duration_seconds",
+			Help:    "Duration of etcd operations",
+			Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"operation", "result"}),
+		NatsDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "museum_nats_operation_duration_seconds",
+			Help:    "Duration of NATS operations",
+			Buckets: []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1},
+		}, []string{"operation", "result"}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.ProxyRequests,
+		m.ProxyRequestDuration,
+		m.ExhibitStarts,
+		m.ExhibitStartDuration,
+		m.ExhibitStartSteps,
+		m.ExhibitStops,
+		m.ExhibitCleanups,
+		m.EtcdDuration,
+		m.NatsDuration,
+	)
+
+	return m
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+func (m *Metrics) ObserveProxyRequest(exhibitId string, method string, code int, duration time.Duration) {
+	m.ProxyRequests.WithLabelValues(exhibitId, method, strconv.Itoa(code)).Inc()
+	m.ProxyRequestDuration.WithLabelValues(exhibitId).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveStart(exhibitId string, err error, duration time.Duration) {
+	m.ExhibitStarts.WithLabelValues(exhibitId, result(err)).Inc()
+	m.ExhibitStartDuration.WithLabelValues(result(err)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveStop(exhibitId string, err error) {
+	m.ExhibitStops.WithLabelValues(exhibitId, result(err)).Inc()
+}
+
+func (m *Metrics) ObserveCleanup(exhibitId string, err error) {
+	m.ExhibitCleanups.WithLabelValues(exhibitId, result(err)).Inc()
+}
+
+func (m *Metrics) ObserveNats(operation string, err error, duration time.Duration) {
+	m.NatsDuration.WithLabelValues(operation, result(err)).Observe(duration.Seconds())
+}
+
+// EtcdInterceptor measures the duration of every etcd request, e.g. /etcdserverpb.KV/Range is recorded as Range
+func (m *Metrics) EtcdInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.EtcdDuration.WithLabelValues(path.Base(method), result(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// StepTimer measures the duration of consecutive steps, a step ends when the next one starts
+type StepTimer struct {
+	metrics *Metrics
+	step    string
+	started time.Time
+}
+
+func (m *Metrics) StartStepTimer() *StepTimer {
+	return &StepTimer{metrics: m}
+}
+
+// Step ends the current step and starts the next one
+func (s *StepTimer) Step(step string) {
+	s.Stop()
+	s.step = step
+	s.started = time.Now()
+}
+
+// Stop ends the current step
+func (s *StepTimer) Stop() {
+	if s.step == "" {
+		return
+	}
+
+	s.metrics.ExhibitStartSteps.WithLabelValues(s.step).Observe(time.Since(s.started).Seconds())
+	s.step = ""
+}
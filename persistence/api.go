@@ -36,13 +36,14 @@ func NewEtcdState(config config.Config, etcdClient *etcd.Client, providerFactory
 	return etcdState
 }
 
-func NewNatsEventing(config config.Config, log *zap.SugaredLogger, conn *nats.Conn, providerFactory *observability.TracerProviderFactory) Eventing {
+func NewNatsEventing(config config.Config, log *zap.SugaredLogger, conn *nats.Conn, providerFactory *observability.TracerProviderFactory, metrics *observability.Metrics) Eventing {
 	log.Debugw("using nats eventing")
 	return &impl.NatsEventing{
 		Config:   config,
 		Log:      log,
 		Provider: providerFactory.Build("nats-service"),
 		Conn:     conn,
+		Metrics:  metrics,
 	}
 }
 
@@ -4,14 +4,17 @@ import (
 	"github.com/nats-io/nats.go"
 	"go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"museum/config"
+	"museum/observability"
 	"time"
 )
 
-func NewEtcdClient(config config.Config, log *zap.SugaredLogger) *clientv3.Client {
+func NewEtcdClient(config config.Config, log *zap.SugaredLogger, metrics *observability.Metrics) *clientv3.Client {
 	client, err := clientv3.New(clientv3.Config{
 		Endpoints:   []string{config.GetEtcdHost()},
 		DialTimeout: 5 * time.Second,
+		DialOptions: []grpc.DialOption{grpc.WithChainUnaryInterceptor(metrics.EtcdInterceptor())},
 	})
 	if err != nil {
 		log.Panicw("error connecting to etcd", "error", err)
@@ -23,6 +23,8 @@ type EtcdState struct {
 
 	ExhibitCache   map[string]domain.Exhibit
 	ExhibitCacheMu *sync.RWMutex
+	// ExhibitNames indexes the cached exhibits by name, it is guarded by ExhibitCacheMu as well
+	ExhibitNames map[string]string
 
 	RuntimeInfoCache   map[string]domain.ExhibitRuntimeInfo
 	RuntimeInfoCacheMu *sync.RWMutex
@@ -44,10 +46,11 @@ func (e *EtcdState) Init() {
 	e.Log.Debugw("retrieved all exhibits")
 
 	e.ExhibitCache = make(map[string]domain.Exhibit)
+	e.ExhibitNames = make(map[string]string)
 	e.ExhibitCacheMu = &sync.RWMutex{}
 
 	for _, exhibit := range exhibits {
-		e.ExhibitCache[exhibit.Id] = exhibit
+		e.cacheExhibit(exhibit)
 		e.watchExhibit(exhibit.Id)
 	}
 
@@ -70,7 +73,7 @@ func (e *EtcdState) Init() {
 		e.watchRuntimeInfo(exhibit.Id)
 	}
 
-	createChan := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/")
+	createChan := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/", etcd.WithPrefix())
 	go func(w etcd.WatchChan) {
 		for {
 			e.handleCreateEvent(w)
@@ -96,3 +99,23 @@ func (e *EtcdState) GetRwLock(ctx context.Context, id string, lockName string) u
 
 	return lock
 }
+
+// DeleteLocks removes all locks of an exhibit, they must not be held by anyone anymore
+func (e *EtcdState) DeleteLocks(ctx context.Context, id string) error {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "locks" + "/"
+
+	// create new trace span for event service
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "DeleteLocks", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+	defer span.End()
+
+	_, err := e.Client.Delete(subCtx, key, etcd.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("deleted locks of exhibit")
+
+	return nil
+}
@@ -19,7 +19,7 @@ func (e *EtcdState) handleExhibitEvent(exhibitId string, w etcd.WatchChan) (dele
 
 		if event.Type == etcd.EventTypeDelete {
 			e.Log.Debugw("exhibit deleted", "exhibitId", exhibitId)
-			delete(e.ExhibitCache, exhibitId)
+			e.uncacheExhibit(exhibitId)
 			return true
 		}
 
@@ -31,7 +31,7 @@ func (e *EtcdState) handleExhibitEvent(exhibitId string, w etcd.WatchChan) (dele
 		}
 
 		e.Log.Debugw("exhibit updated", "exhibitId", exhibitId)
-		e.ExhibitCache[exhibitId] = updatedExhibit
+		e.cacheExhibit(updatedExhibit)
 	}
 
 	return false
@@ -44,7 +44,7 @@ func (e *EtcdState) handleRuntimeInfoEvent(w etcd.WatchChan) bool {
 	defer e.RuntimeInfoCacheMu.Unlock()
 
 	for _, event := range events.Events {
-		exhibitId := strings.Split(string(event.Kv.Key), "/")[1]
+		exhibitId := e.exhibitIdFromKey(string(event.Kv.Key))
 
 		e.Log.Infow("received runtime_info event for exhibit", "exhibitId", exhibitId, "event", event.Type.String())
 		if event.Type == etcd.EventTypeDelete {
@@ -96,7 +96,12 @@ func (e *EtcdState) handleNewRuntimeInfo(event *etcd.Event) {
 		return
 	}
 
-	exhibitId := strings.Split(string(event.Kv.Key), "/")[1]
+	exhibitId := e.exhibitIdFromKey(string(event.Kv.Key))
+
+	// runtime info created by this instance is cached and watched already
+	if _, ok := e.RuntimeInfoCache[exhibitId]; ok {
+		return
+	}
 
 	e.Log.Debugw("new exhibit runtime info created", "exhibitId", exhibitId)
 	e.RuntimeInfoCache[exhibitId] = newRuntimeInfo
@@ -115,13 +120,37 @@ func (e *EtcdState) handleNewExhibit(event *etcd.Event) {
 		return
 	}
 
+	// exhibits created by this instance are cached and watched already
+	if _, ok := e.ExhibitCache[newExhibit.Id]; ok {
+		return
+	}
+
+	// the runtime info is created before the exhibit, so it is watched already
 	e.Log.Debugw("new exhibit created", "exhibitId", newExhibit.Id)
-	e.ExhibitCache[newExhibit.Id] = newExhibit
-	e.watchRuntimeInfo(newExhibit.Id)
+	e.cacheExhibit(newExhibit)
 	e.watchExhibit(newExhibit.Id)
 	return
 }
 
+// cacheExhibit puts an exhibit into the cache and its name into the index, the caller holds ExhibitCacheMu
+func (e *EtcdState) cacheExhibit(exhibit domain.Exhibit) {
+	if previous, ok := e.ExhibitCache[exhibit.Id]; ok && e.ExhibitNames[previous.Name] == exhibit.Id {
+		delete(e.ExhibitNames, previous.Name)
+	}
+
+	e.ExhibitCache[exhibit.Id] = exhibit
+	e.ExhibitNames[exhibit.Name] = exhibit.Id
+}
+
+// uncacheExhibit removes an exhibit from the cache and the index, the caller holds ExhibitCacheMu
+func (e *EtcdState) uncacheExhibit(id string) {
+	if exhibit, ok := e.ExhibitCache[id]; ok && e.ExhibitNames[exhibit.Name] == id {
+		delete(e.ExhibitNames, exhibit.Name)
+	}
+
+	delete(e.ExhibitCache, id)
+}
+
 func (e *EtcdState) watchExhibit(exhibitId string) {
 	w := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/"+exhibitId+"/meta", etcd.WithPrefix())
 	go func(exhibitId string, w etcd.WatchChan) {
@@ -145,3 +174,10 @@ func (e *EtcdState) watchRuntimeInfo(exhibitId string) {
 		}
 	}(exhibitId, w)
 }
+
+// exhibitIdFromKey returns the exhibit id of a key, e.g. /museum/<id>/runtime_info
+func (e *EtcdState) exhibitIdFromKey(key string) string {
+	key = strings.TrimPrefix(key, "/"+e.Config.GetEtcdBaseKey()+"/")
+	id, _, _ := strings.Cut(key, "/")
+	return id
+}
@@ -0,0 +1,38 @@
+package impl
+
+import (
+	"context"
+	"museum/domain"
+	"sync"
+	"testing"
+)
+
+func TestExhibitNameIndex(t *testing.T) {
+	e := &EtcdState{
+		ExhibitCache:   make(map[string]domain.Exhibit),
+		ExhibitNames:   make(map[string]string),
+		ExhibitCacheMu: &sync.RWMutex{},
+	}
+
+	e.cacheExhibit(domain.Exhibit{Id: "1", Name: "a"})
+	e.cacheExhibit(domain.Exhibit{Id: "2", Name: "b"})
+
+	exhibit, err := e.GetExhibitByName(context.Background(), "b")
+	if err != nil || exhibit.Id != "2" {
+		t.Fatalf("Expected exhibit 2, got %v %v", exhibit, err)
+	}
+
+	// renamed exhibits are only found under their new name
+	e.cacheExhibit(domain.Exhibit{Id: "1", Name: "c"})
+	if _, err := e.GetExhibitByName(context.Background(), "a"); err == nil {
+		t.Errorf("Expected the old name to be gone")
+	}
+	if exhibit, _ := e.GetExhibitByName(context.Background(), "c"); exhibit.Id != "1" {
+		t.Errorf("Expected exhibit 1 under its new name, got %v", exhibit)
+	}
+
+	e.uncacheExhibit("2")
+	if _, err := e.GetExhibitByName(context.Background(), "b"); err == nil {
+		t.Errorf("Expected deleted exhibits to be gone")
+	}
+}
@@ -4,17 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	etcd "go.etcd.io/etcd/client/v3"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"museum/domain"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-func (e *EtcdState) CreateExhibit(ctx context.Context, app domain.Exhibit) error {
+// CreateExhibit stores the first revision of an exhibit, it fails if the exhibit already exists
+func (e *EtcdState) CreateExhibit(ctx context.Context, revision domain.ExhibitRevision) error {
 	e.ExhibitCacheMu.Lock()
 	defer e.ExhibitCacheMu.Unlock()
 
+	app := revision.Exhibit
 	key := "/" + e.Config.GetEtcdBaseKey() + "/" + app.Id + "/" + "meta"
 
 	// create new trace span for event service
@@ -23,32 +28,35 @@ func (e *EtcdState) CreateExhibit(ctx context.Context, app domain.Exhibit) error
 		Start(ctx, "CreateExhibit", trace.WithAttributes(attribute.String("key", key), attribute.String("id", app.Id)))
 	defer span.End()
 
-	span.AddEvent("checking if exhibit already exists")
-
-	// check if app already exists, if so, return error
-	res, err := e.Client.Get(subCtx, key)
+	meta, err := json.Marshal(app)
 	if err != nil {
 		return err
 	}
 
-	if res.Count > 0 {
-		return errors.New("exhibit with id " + app.Id + " already exists")
-	}
-
-	b, err := json.Marshal(app)
+	rev, err := json.Marshal(revision)
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Client.Put(subCtx, key, string(b))
+	// the first revision is kept as well, so the exhibit can be rolled back to it
+	res, err := e.Client.Txn(subCtx).If(
+		etcd.Compare(etcd.CreateRevision(key), "=", 0),
+	).Then(
+		etcd.OpPut(key, string(meta)),
+		etcd.OpPut(e.revisionKey(app.Id, revision.Revision), string(rev)),
+	).Commit()
 	if err != nil {
 		return err
 	}
 
+	if !res.Succeeded {
+		return errors.New("exhibit with id " + app.Id + " already exists")
+	}
+
 	span.AddEvent("added exhibit to etcd")
 
 	if e.ExhibitCache != nil {
-		e.ExhibitCache[app.Id] = app
+		e.cacheExhibit(app)
 	}
 
 	e.watchExhibit(app.Id)
@@ -96,6 +104,28 @@ func (e *EtcdState) GetExhibitById(ctx context.Context, id string) (domain.Exhib
 	return exhibit, nil
 }
 
+// GetExhibitByName looks the exhibit up in the name index of the cache, without the cache all exhibits are searched
+func (e *EtcdState) GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error) {
+	if e.ExhibitCache != nil {
+		e.ExhibitCacheMu.RLock()
+		defer e.ExhibitCacheMu.RUnlock()
+
+		if id, ok := e.ExhibitNames[name]; ok {
+			return e.ExhibitCache[id], nil
+		}
+
+		return domain.Exhibit{}, errors.New("exhibit with name " + name + " not found")
+	}
+
+	for _, exhibit := range e.GetAllExhibits(ctx) {
+		if exhibit.Name == name {
+			return exhibit, nil
+		}
+	}
+
+	return domain.Exhibit{}, errors.New("exhibit with name " + name + " not found")
+}
+
 func (e *EtcdState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
 	exhibits := make([]domain.Exhibit, 0)
 
@@ -143,6 +173,121 @@ func (e *EtcdState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
 	return exhibits
 }
 
+// UpdateExhibit replaces the definition of an exhibit and keeps it as a new revision
+func (e *EtcdState) UpdateExhibit(ctx context.Context, revision domain.ExhibitRevision) error {
+	e.ExhibitCacheMu.Lock()
+	defer e.ExhibitCacheMu.Unlock()
+
+	app := revision.Exhibit
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + app.Id + "/" + "meta"
+	revKey := e.revisionKey(app.Id, revision.Revision)
+
+	// create new trace span for event service
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "UpdateExhibit", trace.WithAttributes(attribute.String("key", key), attribute.String("id", app.Id), attribute.Int("revision", revision.Revision)))
+	defer span.End()
+
+	meta, err := json.Marshal(app)
+	if err != nil {
+		return err
+	}
+
+	rev, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+
+	// the exhibit might have been deleted in the meantime, it must not be brought back by an update,
+	// and revisions are immutable, an existing one is never overwritten
+	res, err := e.Client.Txn(subCtx).If(
+		etcd.Compare(etcd.CreateRevision(key), ">", 0),
+		etcd.Compare(etcd.CreateRevision(revKey), "=", 0),
+	).Then(
+		etcd.OpPut(key, string(meta)),
+		etcd.OpPut(revKey, string(rev)),
+	).Commit()
+	if err != nil {
+		return err
+	}
+
+	if !res.Succeeded {
+		return errors.New("exhibit with id " + app.Id + " not found or revision " + strconv.Itoa(revision.Revision) + " already exists")
+	}
+
+	span.AddEvent("updated exhibit in etcd")
+
+	if e.ExhibitCache != nil {
+		e.cacheExhibit(app)
+	}
+
+	return nil
+}
+
+// GetExhibitRevisions returns all revisions of an exhibit, oldest first
+func (e *EtcdState) GetExhibitRevisions(ctx context.Context, id string) ([]domain.ExhibitRevision, error) {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "revisions" + "/"
+
+	// create new trace span for event service
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "GetExhibitRevisions", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+	defer span.End()
+
+	resp, err := e.Client.Get(subCtx, key, etcd.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]domain.ExhibitRevision, 0, resp.Count)
+	for _, kv := range resp.Kvs {
+		revision := domain.ExhibitRevision{}
+		err := json.Unmarshal(kv.Value, &revision)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	// the keys are sorted as strings, so 10 would come before 2
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	return revisions, nil
+}
+
+func (e *EtcdState) GetExhibitRevision(ctx context.Context, id string, revision int) (domain.ExhibitRevision, error) {
+	key := e.revisionKey(id, revision)
+
+	// create new trace span for event service
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "GetExhibitRevision", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id), attribute.Int("revision", revision)))
+	defer span.End()
+
+	resp, err := e.Client.Get(subCtx, key)
+	if err != nil {
+		return domain.ExhibitRevision{}, err
+	}
+
+	if resp.Count == 0 {
+		return domain.ExhibitRevision{}, fmt.Errorf("%w: revision %d of exhibit %s", domain.ErrRevisionNotFound, revision, id)
+	}
+
+	rev := domain.ExhibitRevision{}
+	err = json.Unmarshal(resp.Kvs[0].Value, &rev)
+	if err != nil {
+		return domain.ExhibitRevision{}, err
+	}
+
+	return rev, nil
+}
+
+func (e *EtcdState) revisionKey(id string, revision int) string {
+	return "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "revisions" + "/" + strconv.Itoa(revision)
+}
+
 func (e *EtcdState) DeleteExhibitById(ctx context.Context, id string) error {
 	e.ExhibitCacheMu.Lock()
 	defer e.ExhibitCacheMu.Unlock()
@@ -157,13 +302,16 @@ func (e *EtcdState) DeleteExhibitById(ctx context.Context, id string) error {
 
 	span.AddEvent("deleting exhibit")
 
-	_, err := e.Client.Delete(subCtx, key)
+	_, err := e.Client.Txn(subCtx).Then(
+		etcd.OpDelete(key),
+		etcd.OpDelete("/"+e.Config.GetEtcdBaseKey()+"/"+id+"/"+"revisions"+"/", etcd.WithPrefix()),
+	).Commit()
 	if err != nil {
 		return err
 	}
 
 	if e.ExhibitCache != nil {
-		delete(e.ExhibitCache, id)
+		e.uncacheExhibit(id)
 	}
 
 	return nil
@@ -2,6 +2,7 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"strconv"
@@ -23,6 +24,10 @@ func (e *EtcdState) GetLastAccessed(ctx context.Context, id string) (int64, erro
 		return -1, err
 	}
 
+	if resp.Count == 0 {
+		return -1, errors.New("last_accessed time for exhibit with id " + id + " not found")
+	}
+
 	span.AddEvent("found last_accessed time for exhibit")
 
 	i, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
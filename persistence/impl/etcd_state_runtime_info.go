@@ -3,6 +3,7 @@ package impl
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"museum/domain"
@@ -64,6 +65,10 @@ func (e *EtcdState) GetRuntimeInfo(ctx context.Context, id string) (domain.Exhib
 		return domain.ExhibitRuntimeInfo{}, err
 	}
 
+	if resp.Count == 0 {
+		return domain.ExhibitRuntimeInfo{}, errors.New("runtime info for exhibit with id " + id + " not found")
+	}
+
 	span.AddEvent("found runtime info for exhibit")
 
 	runtimeInfo := domain.ExhibitRuntimeInfo{}
@@ -12,7 +12,9 @@ import (
 	"go.uber.org/zap"
 	"museum/config"
 	"museum/domain"
+	"museum/observability"
 	"strconv"
+	"time"
 )
 
 type NatsEventing struct {
@@ -20,6 +22,15 @@ type NatsEventing struct {
 	Log      *zap.SugaredLogger
 	Provider trace.TracerProvider
 	Conn     *nats.Conn
+	Metrics  *observability.Metrics
+}
+
+// publish publishes a message and records how long it took
+func (n NatsEventing) publish(subject string, data []byte) error {
+	start := time.Now()
+	err := n.Conn.Publish(subject, data)
+	n.Metrics.ObserveNats("publish", err, time.Since(start))
+	return err
 }
 
 func (n NatsEventing) DispatchExhibitCreatedEvent(ctx context.Context, exhibit domain.Exhibit) {
@@ -49,7 +60,7 @@ func (n NatsEventing) DispatchExhibitCreatedEvent(ctx context.Context, exhibit d
 		return
 	}
 
-	err = n.Conn.Publish(n.Config.GetNatsBaseKey()+".exhibit.created", bytes)
+	err = n.publish(n.Config.GetNatsBaseKey()+".exhibit.created", bytes)
 	if err != nil {
 		n.Log.Errorw("error publishing exhibit created event", "error", err)
 		span.RecordError(err)
@@ -113,7 +124,7 @@ func (n NatsEventing) DispatchExhibitStartingEvent(ctx context.Context, exhibit
 		return
 	}
 
-	err = n.Conn.Publish(n.Config.GetNatsBaseKey()+".exhibit."+exhibit.Id+".starting", bytes)
+	err = n.publish(n.Config.GetNatsBaseKey()+".exhibit."+exhibit.Id+".starting", bytes)
 	if err != nil {
 		n.Log.Errorw("error publishing exhibit starting event", "error", err)
 		span.RecordError(err)
@@ -148,7 +159,7 @@ func (n NatsEventing) DispatchExhibitStoppingEvent(ctx context.Context, exhibit
 		return
 	}
 
-	err = n.Conn.Publish(n.Config.GetNatsBaseKey()+".exhibit."+exhibit.Id+".stopping", bytes)
+	err = n.publish(n.Config.GetNatsBaseKey()+".exhibit."+exhibit.Id+".stopping", bytes)
 	if err != nil {
 		n.Log.Errorw("error publishing exhibit stopping event", "error", err)
 		span.RecordError(err)
@@ -156,10 +167,47 @@ func (n NatsEventing) DispatchExhibitStoppingEvent(ctx context.Context, exhibit
 	}
 }
 
+func (n NatsEventing) DispatchExhibitDeletedEvent(ctx context.Context, exhibit domain.Exhibit) {
+	_, span := n.Provider.
+		Tracer("nats eventing").
+		Start(ctx, "DispatchExhibitDeletedEvent", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	n.Log.Debugw("nats eventing dispatching exhibit deleted event", "exhibitId", exhibit.Id)
+	span.AddEvent("dispatching exhibit deleted event")
+
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+	event.SetSource("museum")
+	event.SetType(domain.DeleteEventType)
+	err := event.SetData(cloudevents.ApplicationJSON, map[string]string{"exhibitId": exhibit.Id, "name": exhibit.Name})
+	if err != nil {
+		n.Log.Errorw("error setting event data", "error", err)
+		span.RecordError(err)
+		return
+	}
+
+	bytes, err := event.MarshalJSON()
+	if err != nil {
+		n.Log.Errorw("error marshalling event", "error", err)
+		span.RecordError(err)
+		return
+	}
+
+	err = n.publish(n.Config.GetNatsBaseKey()+".exhibit.delete", bytes)
+	if err != nil {
+		n.Log.Errorw("error publishing exhibit deleted event", "error", err)
+		span.RecordError(err)
+		return
+	}
+}
+
 func (n NatsEventing) GetExhibitStartingChannel(exhibitId string, parentCtx context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
 	subChan := make(chan domain.ExhibitStartingStepEvent)
 
+	start := time.Now()
 	sync, err := n.Conn.SubscribeSync(n.Config.GetNatsBaseKey() + ".exhibit." + exhibitId + ".starting")
+	n.Metrics.ObserveNats("subscribe", err, time.Since(start))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -179,6 +227,13 @@ func (n NatsEventing) GetExhibitStartingChannel(exhibitId string, parentCtx cont
 					return
 				}
 
+				// the subscriber sees the closed channel, there won't be any more messages
+				if errors.Is(err, nats.ErrConnectionClosed) || errors.Is(err, nats.ErrBadSubscription) {
+					n.Log.Errorw("exhibit starting channel closed", "error", err, "exhibitId", exhibitId)
+					close(subChan)
+					return
+				}
+
 				n.Log.Errorw("error getting next message", "error", err)
 				continue
 			}
@@ -192,8 +247,16 @@ func (n NatsEventing) GetExhibitStartingChannel(exhibitId string, parentCtx cont
 
 			eventData := domain.ExhibitStartingStepEvent{}
 			err = json.Unmarshal(event.Data(), &eventData)
+			if err != nil {
+				n.Log.Errorw("error unmarshalling event data", "error", err, "exhibitId", exhibitId)
+				continue
+			}
 
-			subChan <- eventData
+			// the subscriber might be gone already, e.g. when its client disconnected
+			select {
+			case subChan <- eventData:
+			case <-ctx.Done():
+			}
 		}
 	}()
 
@@ -203,7 +266,9 @@ func (n NatsEventing) GetExhibitStartingChannel(exhibitId string, parentCtx cont
 func (n NatsEventing) GetExhibitStoppingChannel(exhibitId string, parentCtx context.Context) (<-chan domain.ExhibitStoppingEvent, context.CancelFunc, error) {
 	subChan := make(chan domain.ExhibitStoppingEvent)
 
+	start := time.Now()
 	sync, err := n.Conn.SubscribeSync(n.Config.GetNatsBaseKey() + ".exhibit." + exhibitId + ".stopping")
+	n.Metrics.ObserveNats("subscribe", err, time.Since(start))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -236,8 +301,15 @@ func (n NatsEventing) GetExhibitStoppingChannel(exhibitId string, parentCtx cont
 
 			eventData := domain.ExhibitStoppingEvent{}
 			err = json.Unmarshal(event.Data(), &eventData)
+			if err != nil {
+				n.Log.Errorw("error unmarshalling event data", "error", err, "exhibitId", exhibitId)
+				continue
+			}
 
-			subChan <- eventData
+			select {
+			case subChan <- eventData:
+			case <-ctx.Done():
+			}
 		}
 	}()
 
@@ -22,6 +22,10 @@ func (n NoopEventing) DispatchExhibitStoppingEvent(ctx context.Context, exhibit
 	n.Log.Debugw("noop eventing dispatching exhibit stopping event", "exhibitId", exhibit.Id)
 }
 
+func (n NoopEventing) DispatchExhibitDeletedEvent(_ context.Context, exhibit domain.Exhibit) {
+	n.Log.Debugw("noop eventing dispatching exhibit deleted event", "exhibitId", exhibit.Id)
+}
+
 func (n NoopEventing) GetExhibitStartingChannel(string, context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
 	return make(chan domain.ExhibitStartingStepEvent), func() {}, nil
 }
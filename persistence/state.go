@@ -11,12 +11,18 @@ import (
 // communication between museum instances. No business logic shall be contained here.
 type State interface {
 	GetRwLock(ctx context.Context, id string, lockName string) util.RwErrMutex
+	DeleteLocks(ctx context.Context, id string) error
 
-	CreateExhibit(ctx context.Context, app domain.Exhibit) error
+	CreateExhibit(ctx context.Context, revision domain.ExhibitRevision) error
 	GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error)
+	GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error)
 	GetAllExhibits(ctx context.Context) []domain.Exhibit
+	UpdateExhibit(ctx context.Context, revision domain.ExhibitRevision) error
 	DeleteExhibitById(ctx context.Context, id string) error
 
+	GetExhibitRevisions(ctx context.Context, id string) ([]domain.ExhibitRevision, error)
+	GetExhibitRevision(ctx context.Context, id string, revision int) (domain.ExhibitRevision, error)
+
 	SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error
 	GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error)
 	DeleteRuntimeInfo(ctx context.Context, id string) error
@@ -3,11 +3,13 @@ package service
 import (
 	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
 	"museum/config"
 	"museum/observability"
 	"museum/persistence"
 	"museum/service/impl"
 	service "museum/service/interface"
+	"time"
 )
 
 type ApplicationProvisionerService service.ApplicationProvisionerService
@@ -23,19 +25,85 @@ func NewDockerApplicationProvisionerService(client *docker.Client,
 	log *zap.SugaredLogger,
 	providerFactory *observability.TracerProviderFactory,
 	config config.Config,
-	volumeProvisionerFactory service.VolumeProvisionerFactoryService) ApplicationProvisionerService {
+	volumeProvisionerFactory service.VolumeProvisionerFactoryService,
+	metrics *observability.Metrics) ApplicationProvisionerService {
 	return &impl.DockerApplicationProvisionerService{
-		ExhibitService:              exhibitService,
+		ApplicationLifecycle: impl.ApplicationLifecycle{
+			ExhibitService:      exhibitService,
+			LockService:         lockService,
+			RuntimeInfoService:  runtimeInfoService,
+			LastAccessedService: lastAccessedService,
+			Eventing:            eventing,
+			Log:                 log,
+			Provider:            providerFactory.Build("docker-service"),
+			Metrics:             metrics,
+		},
 		LivecheckFactoryService:     livecheckFactoryService,
 		EnvironmentTemplateResolver: environmentTemplateResolver,
 		Client:                      client,
-		LockService:                 lockService,
-		RuntimeInfoService:          runtimeInfoService,
-		LastAccessedService:         lastAccessedService,
-		Eventing:                    eventing,
-		Log:                         log,
-		Provider:                    providerFactory.Build("docker-service"),
 		Config:                      config,
 		VolumeProvisionerFactory:    volumeProvisionerFactory,
 	}
 }
+
+func NewKubernetesApplicationProvisionerService(client kubernetes.Interface,
+	exhibitService service.ExhibitService,
+	environmentTemplateResolver service.EnvironmentTemplateResolverService,
+	runtimeInfoService service.RuntimeInfoService,
+	lastAccessedService service.LastAccessedService,
+	lockService service.LockService,
+	eventing persistence.Eventing,
+	log *zap.SugaredLogger,
+	providerFactory *observability.TracerProviderFactory,
+	config config.Config,
+	volumeProvisionerFactory service.VolumeProvisionerFactoryService,
+	metrics *observability.Metrics) ApplicationProvisionerService {
+	return &impl.KubernetesApplicationProvisionerService{
+		ApplicationLifecycle: impl.ApplicationLifecycle{
+			ExhibitService:      exhibitService,
+			LockService:         lockService,
+			RuntimeInfoService:  runtimeInfoService,
+			LastAccessedService: lastAccessedService,
+			Eventing:            eventing,
+			Log:                 log,
+			Provider:            providerFactory.Build("kubernetes-service"),
+			Metrics:             metrics,
+		},
+		EnvironmentTemplateResolver: environmentTemplateResolver,
+		VolumeProvisionerFactory:    volumeProvisionerFactory,
+		Client:                      client,
+		Config:                      config,
+		PollInterval:                time.Second,
+	}
+}
+
+func NewDindApplicationProvisionerService(client *docker.Client,
+	exhibitService service.ExhibitService,
+	environmentTemplateResolver service.EnvironmentTemplateResolverService,
+	runtimeInfoService service.RuntimeInfoService,
+	lastAccessedService service.LastAccessedService,
+	lockService service.LockService,
+	eventing persistence.Eventing,
+	log *zap.SugaredLogger,
+	providerFactory *observability.TracerProviderFactory,
+	config config.Config,
+	volumeProvisionerFactory service.VolumeProvisionerFactoryService,
+	metrics *observability.Metrics) ApplicationProvisionerService {
+	return &impl.DindApplicationProvisionerService{
+		ApplicationLifecycle: impl.ApplicationLifecycle{
+			ExhibitService:      exhibitService,
+			LockService:         lockService,
+			RuntimeInfoService:  runtimeInfoService,
+			LastAccessedService: lastAccessedService,
+			Eventing:            eventing,
+			Log:                 log,
+			Provider:            providerFactory.Build("dind-service"),
+			Metrics:             metrics,
+		},
+		EnvironmentTemplateResolver: environmentTemplateResolver,
+		VolumeProvisionerFactory:    volumeProvisionerFactory,
+		Client:                      client,
+		Config:                      config,
+		PollInterval:                time.Second,
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"museum/config"
 	"museum/service/impl"
 	service "museum/service/interface"
+	"sync"
 )
 
 type ApplicationProxyService service.ApplicationProxyService
 
-func NewDockerApplicationProxyService(resolver service.ApplicationResolverService, rewriteService service.RewriteService, log *zap.SugaredLogger, config config.Config) ApplicationProxyService {
+func NewDockerApplicationProxyService(resolver service.ApplicationResolverService, rewriteService service.RewriteService, lastAccessedService service.LastAccessedService, log *zap.SugaredLogger, config config.Config) ApplicationProxyService {
 	return &impl.DockerApplicationProxyService{
-		Resolver:       resolver,
-		RewriteService: rewriteService,
-		Log:            log,
-		Config:         config,
+		Resolver:            resolver,
+		RewriteService:      rewriteService,
+		LastAccessedService: lastAccessedService,
+		Log:                 log,
+		Config:              config,
+		ClientCache:         make(map[string]*impl.ProxyClient),
+		ClientCacheMu:       &sync.RWMutex{},
 	}
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	docker "github.com/docker/docker/client"
+	"museum/config"
 	"museum/persistence"
 	"museum/service/impl"
 	service "museum/service/interface"
@@ -26,3 +27,23 @@ func NewDockerExtHostApplicationResolverService(exhibitService service.ExhibitSe
 		Eventing:       eventing,
 	}
 }
+
+func NewKubernetesApplicationResolverService(exhibitService service.ExhibitService, config config.Config) ApplicationResolverService {
+	return &impl.KubernetesApplicationResolverService{
+		ExhibitService: exhibitService,
+		Config:         config,
+	}
+}
+
+func NewDindApplicationResolverService(exhibitService service.ExhibitService,
+	client *docker.Client,
+	config config.Config,
+	eventing persistence.Eventing) ApplicationResolverService {
+	return &impl.DindApplicationResolverService{
+		ExhibitService: exhibitService,
+		IpCache:        cache.NewLRU[string, string](1000),
+		Client:         client,
+		Config:         config,
+		Eventing:       eventing,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
 	"museum/config"
+	proxymode "museum/config/proxy-mode"
 )
 
 func NewDockerClient(config config.Config, log *zap.SugaredLogger) *docker.Client {
@@ -21,10 +22,16 @@ func NewDockerClient(config config.Config, log *zap.SugaredLogger) *docker.Clien
 		log.Panicw("failed to get docker info", "error", err)
 	}
 
-	if info.Swarm.LocalNodeState != "active" {
+	// the docker daemons of the exhibits don't need a swarm
+	if config.GetProxyMode() != proxymode.ModeDind && info.Swarm.LocalNodeState != "active" {
 		log.Panic("docker swarm is not active")
 	}
 
+	// the docker daemons of the exhibits must not end up on a network shared with other containers by default
+	if config.GetProxyMode() == proxymode.ModeDind && config.GetDindNetwork() == "" {
+		log.Panic("DIND_NETWORK is required with PROXY_MODE=dind")
+	}
+
 	log.Debugw("connected to docker", "host", config.GetDockerHost())
 
 	return c
@@ -10,11 +10,14 @@ import (
 
 type ExhibitCleanupService service.ExhibitCleanupService
 
-func NewExhibitCleanupService(exhibitService service.ExhibitService, lockService service.LockService, provisionerService service.ApplicationProvisionerService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger, config config.Config) ExhibitCleanupService {
+func NewExhibitCleanupService(exhibitService service.ExhibitService, lockService service.LockService, provisionerService service.ApplicationProvisionerService, proxyService service.ApplicationProxyService, runtimeInfoService service.RuntimeInfoService, volumeProvisionerFactory service.VolumeProvisionerFactoryService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger, config config.Config) ExhibitCleanupService {
 	return &impl.ExhibitCleanupServiceImpl{
 		ExhibitService:                exhibitService,
 		LockService:                   lockService,
 		ApplicationProvisionerService: provisionerService,
+		ApplicationProxyService:       proxyService,
+		RuntimeInfoService:            runtimeInfoService,
+		VolumeProvisionerFactory:      volumeProvisionerFactory,
 		Provider:                      factory.Build("cleanup-service"),
 		Log:                           log,
 		Config:                        config,
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type ExhibitLifecycleService service.ExhibitLifecycleService
+
+func NewExhibitLifecycleService(exhibitService service.ExhibitService, provisionerService service.ApplicationProvisionerService, lastAccessedService service.LastAccessedService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) ExhibitLifecycleService {
+	return &impl.ExhibitLifecycleServiceImpl{
+		ExhibitService:                exhibitService,
+		ApplicationProvisionerService: provisionerService,
+		LastAccessedService:           lastAccessedService,
+		Provider:                      factory.Build("lifecycle-service"),
+		Log:                           log,
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
+	"museum/config"
 	"museum/observability"
 	"museum/persistence"
 	"museum/service/impl"
@@ -18,7 +19,8 @@ func NewExhibitService(state persistence.State,
 	factory *observability.TracerProviderFactory,
 	log *zap.SugaredLogger,
 	dockerClient *docker.Client,
-	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService) ExhibitService {
+	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService,
+	config config.Config) ExhibitService {
 	return &impl.ExhibitServiceImpl{
 		State:                    state,
 		Eventing:                 eventing,
@@ -28,5 +30,6 @@ func NewExhibitService(state persistence.State,
 		Log:                      log,
 		DockerClient:             dockerClient,
 		VolumeProvisionerFactory: volumeProvisionerFactoryService,
+		Config:                   config,
 	}
 }
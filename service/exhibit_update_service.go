@@ -0,0 +1,19 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type ExhibitUpdateService service.ExhibitUpdateService
+
+func NewExhibitUpdateService(exhibitService service.ExhibitService, provisionerService service.ApplicationProvisionerService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) ExhibitUpdateService {
+	return &impl.ExhibitUpdateServiceImpl{
+		ExhibitService:                exhibitService,
+		ApplicationProvisionerService: provisionerService,
+		Provider:                      factory.Build("update-service"),
+		Log:                           log,
+	}
+}
@@ -0,0 +1,389 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/observability"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"time"
+)
+
+// ApplicationLifecycle moves an exhibit through its states (starting, running, stopping, stopped) and takes the locks for it,
+// the provisioners only start, stop and clean up the objects of the exhibit inside of it
+type ApplicationLifecycle struct {
+	ExhibitService      service.ExhibitService
+	LockService         service.LockService
+	RuntimeInfoService  service.RuntimeInfoService
+	LastAccessedService service.LastAccessedService
+	Eventing            persistence.Eventing
+	Log                 *zap.SugaredLogger
+	Provider            trace.TracerProvider
+	Metrics             *observability.Metrics
+}
+
+// objectsFunc starts, stops or cleans up the objects of an exhibit, it is called with the runtime_info lock held
+// and may change the runtime info of the exhibit, which is stored afterwards
+type objectsFunc func(ctx context.Context, exhibit *domain.Exhibit) error
+
+func (l ApplicationLifecycle) applicationStartingStep(ctx context.Context, exhibitId string) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "applicationStartingStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	// the exhibit has to exist, its status is read below
+	_, err = l.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := l.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(lock)
+
+	span.AddEvent("checking exhibit status")
+
+	// the status is read again after the lock is acquired, someone else might have started or stopped the exhibit in the meantime
+	runtimeInfo, err := l.RuntimeInfoService.GetRuntimeInfoInsideLock(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	switch runtimeInfo.Status {
+	case domain.Stopped, domain.NotCreated:
+	case domain.Running:
+		return domain.ErrExhibitRunning
+	case domain.Starting:
+		return domain.ErrExhibitStarting
+	case domain.Stopping:
+		return domain.ErrExhibitStopping
+	default:
+		return errors.New(string("cannot start application in state " + runtimeInfo.Status))
+	}
+
+	span.AddEvent("setting exhibit status to starting")
+
+	runtimeInfo.Status = domain.Starting
+	runtimeInfo.RelatedContainers = make([]string, 0)
+
+	err = l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, runtimeInfo)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("exhibit status set to starting")
+
+	err = l.LastAccessedService.SetLastAccessed(subCtx, exhibitId, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l ApplicationLifecycle) applicationRunningStep(ctx context.Context, exhibitId string, start objectsFunc) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "applicationRunningStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	exhibitRlock := l.LockService.GetRwLock(subCtx, exhibitId, "exhibit")
+	err = exhibitRlock.RLock()
+	if err != nil {
+		l.Log.Errorw("error locking exhibit", "exhibitId", exhibitId, "error", err)
+		return err
+	}
+
+	span.AddEvent("exhibit lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.RUnlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking exhibit", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(exhibitRlock)
+
+	exhibit, err := l.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := l.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(lock)
+
+	err = start(subCtx, &exhibit)
+	if err != nil {
+		l.Log.Debugw("error starting application, reverting status to stopped", "exhibitId", exhibitId, "error", err)
+		span.AddEvent("error starting application, reverting status to stopped")
+
+		exhibit.RuntimeInfo.Status = domain.Stopped
+		exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
+		_ = l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+		return err
+	}
+
+	return l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+}
+
+func (l ApplicationLifecycle) startApplication(ctx context.Context, exhibitId string, start objectsFunc) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "StartApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	begin := time.Now()
+	defer func() {
+		l.Metrics.ObserveStart(exhibitId, err, time.Since(begin))
+	}()
+
+	err = l.applicationStartingStep(subCtx, exhibitId)
+	if err != nil {
+		l.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
+		return err
+	}
+
+	err = l.applicationRunningStep(subCtx, exhibitId, start)
+	if err != nil {
+		l.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (l ApplicationLifecycle) applicationStoppingStep(ctx context.Context, exhibitId string) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "applicationStoppingStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	exhibit, err := l.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := l.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(lock)
+
+	span.AddEvent("checking exhibit status")
+
+	// the status is read again after the lock is acquired, someone else might have started or stopped the exhibit in the meantime
+	runtimeInfo, err := l.RuntimeInfoService.GetRuntimeInfoInsideLock(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	switch runtimeInfo.Status {
+	case domain.Running:
+	case domain.Stopped, domain.NotCreated:
+		return domain.ErrExhibitNotRunning
+	case domain.Starting:
+		return domain.ErrExhibitStarting
+	case domain.Stopping:
+		return domain.ErrExhibitStopping
+	default:
+		return errors.New(string("cannot stop application in state " + runtimeInfo.Status))
+	}
+
+	span.AddEvent("setting exhibit status to stopping")
+
+	runtimeInfo.Status = domain.Stopping
+	err = l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, runtimeInfo)
+	if err != nil {
+		return err
+	}
+
+	l.Eventing.DispatchExhibitStoppingEvent(subCtx, exhibit)
+
+	return nil
+}
+
+func (l ApplicationLifecycle) applicationStoppedStep(ctx context.Context, exhibitId string, stop objectsFunc) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "applicationStoppedStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	exhibit, err := l.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := l.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(lock)
+
+	err = stop(subCtx, &exhibit)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("setting exhibit status to stopped")
+
+	exhibit.RuntimeInfo.Status = domain.Stopped
+	err = l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l ApplicationLifecycle) stopApplication(ctx context.Context, exhibitId string, stop objectsFunc) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "StopApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	defer func() {
+		l.Metrics.ObserveStop(exhibitId, err)
+	}()
+
+	err = l.applicationStoppingStep(subCtx, exhibitId)
+	if err != nil {
+		l.Log.Errorw("error stopping application", "exhibitId", exhibitId, "error", err)
+		return err
+	}
+
+	err = l.applicationStoppedStep(subCtx, exhibitId, stop)
+	if err != nil {
+		l.Log.Errorw("error stopping application", "exhibitId", exhibitId, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (l ApplicationLifecycle) cleanupApplication(ctx context.Context, exhibitId string, cleanup objectsFunc) (err error) {
+	subCtx, span := l.Provider.
+		Tracer("provisioner").
+		Start(ctx, "CleanupApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	defer func() {
+		l.Metrics.ObserveCleanup(exhibitId, err)
+	}()
+
+	exhibit, err := l.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := l.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			l.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = e
+		}
+	}(lock)
+
+	// check that exhibit is stopped+
+	if exhibit.RuntimeInfo.Status != domain.Stopped {
+		return errors.New(string("cannot cleanup application in state " + exhibit.RuntimeInfo.Status))
+	}
+
+	err = cleanup(subCtx, &exhibit)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("resetting runtime_info")
+	exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
+	exhibit.RuntimeInfo.Hostname = ""
+
+	err = l.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// enforceProfile checks all objects of an exhibit against the default profile before any of them is started
+func enforceProfile(profile domain.Profile, exhibit domain.Exhibit) error {
+	for _, object := range exhibit.Objects {
+		_, err := profile.Enforce(object)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/observability"
+	persistenceimpl "museum/persistence/impl"
+	service "museum/service/interface"
+	"museum/util"
+	"sync"
+	"testing"
+)
+
+// fakeLockService hands out process local locks, they aren't reentrant just like the ones in etcd
+type fakeLockService struct {
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+type fakeRwMutex struct {
+	*sync.RWMutex
+}
+
+func (f fakeRwMutex) RLock() error   { f.RWMutex.RLock(); return nil }
+func (f fakeRwMutex) RUnlock() error { f.RWMutex.RUnlock(); return nil }
+func (f fakeRwMutex) Lock() error    { f.RWMutex.Lock(); return nil }
+func (f fakeRwMutex) Unlock() error  { f.RWMutex.Unlock(); return nil }
+
+func (f *fakeLockService) GetRwLock(_ context.Context, id string, lockName string) util.RwErrMutex {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.locks == nil {
+		f.locks = make(map[string]*sync.RWMutex)
+	}
+
+	lock, ok := f.locks[id+"/"+lockName]
+	if !ok {
+		lock = &sync.RWMutex{}
+		f.locks[id+"/"+lockName] = lock
+	}
+
+	return fakeRwMutex{lock}
+}
+
+// fakeRuntimeInfoService keeps the runtime info of a single exhibit, reading it takes the runtime_info lock like the real one
+type fakeRuntimeInfoService struct {
+	locks *fakeLockService
+	mu    sync.Mutex
+	info  domain.ExhibitRuntimeInfo
+}
+
+func (f *fakeRuntimeInfoService) SetRuntimeInfo(_ context.Context, _ string, info domain.ExhibitRuntimeInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.info = info
+	return nil
+}
+
+func (f *fakeRuntimeInfoService) GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error) {
+	lock := f.locks.GetRwLock(ctx, id, "runtime_info")
+	_ = lock.RLock()
+	defer lock.RUnlock()
+	return f.GetRuntimeInfoInsideLock(ctx, id)
+}
+
+func (f *fakeRuntimeInfoService) GetRuntimeInfoInsideLock(context.Context, string) (domain.ExhibitRuntimeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.info, nil
+}
+
+// staleExhibitService returns an exhibit with the status it had when it was read, before someone else changed it
+type staleExhibitService struct {
+	service.ExhibitService
+	status domain.Status
+}
+
+func (s staleExhibitService) GetExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	return domain.Exhibit{Id: id, Name: "test", RuntimeInfo: &domain.ExhibitRuntimeInfo{Status: s.status}}, nil
+}
+
+type fakeLastAccessedService struct{}
+
+func (fakeLastAccessedService) GetLastAccessed(context.Context, string) (int64, error) { return 0, nil }
+func (fakeLastAccessedService) SetLastAccessed(context.Context, string, int64) error   { return nil }
+
+// newLifecycle returns a lifecycle which read the exhibit as stale, while its actual status is current
+func newLifecycle(stale domain.Status, current domain.Status) (ApplicationLifecycle, *fakeRuntimeInfoService) {
+	locks := &fakeLockService{}
+	runtimeInfo := &fakeRuntimeInfoService{locks: locks, info: domain.ExhibitRuntimeInfo{Status: current}}
+	log := zap.NewNop().Sugar()
+
+	return ApplicationLifecycle{
+		ExhibitService:      staleExhibitService{status: stale},
+		LockService:         locks,
+		RuntimeInfoService:  runtimeInfo,
+		LastAccessedService: fakeLastAccessedService{},
+		Eventing:            persistenceimpl.NoopEventing{Log: log},
+		Log:                 log,
+		Provider:            noop.NewTracerProvider(),
+		Metrics:             observability.NewMetrics(),
+	}, runtimeInfo
+}
+
+func TestLifecycleRacesAreConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   bool
+		current domain.Status
+		err     error
+	}{
+		{"start while starting", true, domain.Starting, domain.ErrExhibitStarting},
+		{"start while stopping", true, domain.Stopping, domain.ErrExhibitStopping},
+		{"start while running", true, domain.Running, domain.ErrExhibitRunning},
+		{"stop while starting", false, domain.Starting, domain.ErrExhibitStarting},
+		{"stop while stopping", false, domain.Stopping, domain.ErrExhibitStopping},
+		{"stop while stopped", false, domain.Stopped, domain.ErrExhibitNotRunning},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the status read before the lock allows the transition, the one behind the lock doesn't
+			stale := domain.Stopped
+			if !test.start {
+				stale = domain.Running
+			}
+			lifecycle, runtimeInfo := newLifecycle(stale, test.current)
+
+			called := false
+			objects := func(context.Context, *domain.Exhibit) error {
+				called = true
+				return nil
+			}
+
+			var err error
+			if test.start {
+				err = lifecycle.startApplication(context.Background(), "1234", objects)
+			} else {
+				err = lifecycle.stopApplication(context.Background(), "1234", objects)
+			}
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected %v, got %v", test.err, err)
+			}
+
+			if called {
+				t.Error("Expected the objects to be left alone")
+			}
+
+			if runtimeInfo.info.Status != test.current {
+				t.Errorf("Expected the status to stay %s, got %s", test.current, runtimeInfo.info.Status)
+			}
+		})
+	}
+}
+
+func TestLifecycleStartsStoppedExhibit(t *testing.T) {
+	lifecycle, runtimeInfo := newLifecycle(domain.Stopped, domain.Stopped)
+
+	err := lifecycle.startApplication(context.Background(), "1234", func(_ context.Context, exhibit *domain.Exhibit) error {
+		exhibit.RuntimeInfo.Status = domain.Running
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runtimeInfo.info.Status != domain.Running {
+		t.Errorf("Expected the exhibit to run, got %s", runtimeInfo.info.Status)
+	}
+}
@@ -3,6 +3,7 @@ package impl
 import (
 	"context"
 	"errors"
+	"fmt"
 	cloudevents "github.com/cloudevents/sdk-go/v2/event"
 	"museum/domain"
 	service "museum/service/interface"
@@ -12,20 +13,22 @@ type ApplicationProvisionerHandlerServiceImpl struct {
 	ApplicationProvisionerService service.ApplicationProvisionerService
 }
 
+// HandleEvent starts or stops an exhibit, events are idempotent, so starting a running or starting exhibit
+// (e.g. a warmup racing a request to it) and stopping a stopped or stopping one succeed
 func (a ApplicationProvisionerHandlerServiceImpl) HandleEvent(ctx context.Context, event *cloudevents.Event, id string) error {
 	switch event.Type() {
 	case domain.StartEventType:
 		err := a.ApplicationProvisionerService.StartApplication(ctx, id)
-		if err != nil {
+		if err != nil && !errors.Is(err, domain.ErrExhibitRunning) && !errors.Is(err, domain.ErrExhibitStarting) {
 			return err
 		}
 	case domain.StopEventType:
 		err := a.ApplicationProvisionerService.StopApplication(ctx, id)
-		if err != nil {
+		if err != nil && !errors.Is(err, domain.ErrExhibitNotRunning) && !errors.Is(err, domain.ErrExhibitStopping) {
 			return err
 		}
 	default:
-		return errors.New("unknown event type " + event.Type())
+		return fmt.Errorf("%w %s", domain.ErrUnknownEventType, event.Type())
 	}
 
 	return nil
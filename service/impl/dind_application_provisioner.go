@@ -0,0 +1,401 @@
+package impl
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	docker "github.com/docker/docker/client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"museum/config"
+	"museum/domain"
+	service "museum/service/interface"
+	"net/http"
+	"path"
+	"time"
+)
+
+// dindPort is the port the docker daemons listen on, they only accept clients with a certificate of their own CA
+const dindPort = "2376"
+
+// dindCertDir is where the daemons generate their CA, server and client certificates on the first start
+const dindCertDir = "/certs"
+
+// dindServerName is part of the server certificate of every daemon, their addresses change whenever they are restarted
+const dindServerName = "docker"
+
+// DindApplicationProvisionerService runs every exhibit in a docker daemon of its own, which runs in a privileged container on the host,
+// the objects are started inside of it like on the host by the docker provisioner
+type DindApplicationProvisionerService struct {
+	ApplicationLifecycle
+	EnvironmentTemplateResolver service.EnvironmentTemplateResolverService
+	VolumeProvisionerFactory    service.VolumeProvisionerFactoryService
+	// Client talks to the docker daemon on the host, which runs the daemons of the exhibits
+	Client *docker.Client
+	Config config.Config
+	// PollInterval is how often a starting daemon is checked
+	PollInterval time.Duration
+}
+
+// dindContainerName is the name of the container running the docker daemon of an exhibit
+func dindContainerName(exhibit domain.Exhibit) string {
+	return "dind_" + exhibit.Name
+}
+
+// dindAddress returns the address of the docker daemon of an exhibit on the network museum shares with it
+func dindAddress(ctx context.Context, client *docker.Client, config config.Config, exhibit domain.Exhibit) (string, error) {
+	inspect, err := client.ContainerInspect(ctx, dindContainerName(exhibit))
+	if err != nil {
+		return "", err
+	}
+
+	if !inspect.State.Running {
+		return "", errors.New("docker daemon of exhibit " + exhibit.Name + " is not running")
+	}
+
+	name := config.GetDindNetwork()
+	settings, ok := inspect.NetworkSettings.Networks[name]
+	if !ok || settings.IPAddress == "" {
+		return "", errors.New("docker daemon of exhibit " + exhibit.Name + " has no address in network " + name)
+	}
+
+	return settings.IPAddress, nil
+}
+
+func (d DindApplicationProvisionerService) StartApplication(ctx context.Context, exhibitId string) error {
+	return d.startApplication(ctx, exhibitId, d.startObjects)
+}
+
+func (d DindApplicationProvisionerService) StopApplication(ctx context.Context, exhibitId string) error {
+	return d.stopApplication(ctx, exhibitId, d.stopDaemon)
+}
+
+func (d DindApplicationProvisionerService) CleanupApplication(ctx context.Context, exhibitId string) error {
+	return d.cleanupApplication(ctx, exhibitId, d.removeDaemon)
+}
+
+func (d DindApplicationProvisionerService) startObjects(ctx context.Context, exhibit *domain.Exhibit) error {
+	name := dindContainerName(*exhibit)
+
+	ctx, span := d.Provider.
+		Tracer("dind provisioner").
+		Start(ctx, "startObjects", trace.WithAttributes(attribute.String("container", name), attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	span.AddEvent("starting docker daemon")
+	id, err := d.startDaemon(ctx, *exhibit)
+	if err != nil {
+		d.Log.Errorw("error starting docker daemon", "container", name, "exhibitId", exhibit.Id, "error", err)
+		d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+		return err
+	}
+
+	span.AddEvent("connecting to docker daemon")
+	inner, err := d.connectDaemon(ctx, *exhibit)
+	if err != nil {
+		d.Log.Errorw("error connecting to docker daemon", "container", name, "exhibitId", exhibit.Id, "error", err)
+		d.stopDaemonAfterError(ctx, *exhibit)
+		d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+		return err
+	}
+	defer inner.Close()
+
+	// the daemon keeps its images while the exhibit is stopped, they are only pulled on the first start
+	span.AddEvent("pulling images")
+	for _, object := range exhibit.Objects {
+		err = pullImage(ctx, inner, object.Image+":"+object.Label)
+		if err != nil {
+			d.Log.Errorw("error pulling image", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id, "error", err)
+			d.stopDaemonAfterError(ctx, *exhibit)
+			d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+			return err
+		}
+	}
+
+	provisioner := DockerApplicationProvisionerService{
+		ApplicationLifecycle: d.ApplicationLifecycle,
+		LivecheckFactoryService: &LivecheckFactoryServiceImpl{
+			HttpLivecheck: &DindHttpLivecheck{Client: d.Client, Inner: inner},
+			ExecLivecheck: &ExecLivecheck{Client: inner},
+		},
+		EnvironmentTemplateResolver: d.EnvironmentTemplateResolver,
+		Client:                      inner,
+		Config:                      d.Config,
+		// nfs and smb volumes are mounted by the daemon of the exhibit
+		VolumeProvisionerFactory: VolumeProvisionerFactoryServiceImpl{Config: d.Config, Client: inner},
+		PublishExposed:           true,
+	}
+
+	err = provisioner.startApplicationInsideLock(ctx, exhibit)
+	if err != nil {
+		d.stopDaemonAfterError(ctx, *exhibit)
+		return err
+	}
+
+	// the containers inside of the daemon are gone with it, only the daemon is related to the exhibit on the host
+	exhibit.RuntimeInfo.RelatedContainers = []string{id}
+	exhibit.RuntimeInfo.Hostname = name
+
+	return nil
+}
+
+// startDaemon creates the daemon of an exhibit if it doesn't exist yet and starts it
+func (d DindApplicationProvisionerService) startDaemon(ctx context.Context, exhibit domain.Exhibit) (string, error) {
+	name := dindContainerName(exhibit)
+
+	inspect, err := d.Client.ContainerInspect(ctx, name)
+	if err != nil && !docker.IsErrNotFound(err) {
+		return "", err
+	}
+
+	id := inspect.ID
+	if docker.IsErrNotFound(err) {
+		id, err = d.createDaemon(ctx, exhibit)
+		if err != nil {
+			return "", err
+		}
+	} else if inspect.State.Running {
+		return id, nil
+	}
+
+	return id, d.Client.ContainerStart(ctx, id, container.StartOptions{})
+}
+
+func (d DindApplicationProvisionerService) createDaemon(ctx context.Context, exhibit domain.Exhibit) (string, error) {
+	err := pullImage(ctx, d.Client, d.Config.GetDindImage())
+	if err != nil {
+		return "", err
+	}
+
+	hostConfig := &container.HostConfig{
+		// docker needs to manage cgroups, mounts and iptables, the daemon is isolated from the host by its own namespaces only
+		Privileged: true,
+	}
+
+	// volumes are mounted read only at the same path inside of the daemon, so the objects can bind them like on the host.
+	// The copies of scratch and pinned volumes are made whenever the exhibit starts, the daemon gets the directory keeping them instead
+	copies := false
+	for _, volume := range exhibit.Volumes {
+		if volume.IsCopy() {
+			copies = true
+			continue
+		}
+
+		// nfs and smb volumes are mounted by the daemon itself, it needs the credentials file of a share from the host
+		if volume.Driver.Type == "smb" && volume.Driver.Config["credentials"] != "" {
+			credentials := volume.Driver.Config["credentials"]
+			hostConfig.Binds = append(hostConfig.Binds, credentials+":"+credentials+":ro")
+		}
+
+		if volume.Driver.Type != "local" {
+			continue
+		}
+
+		provisioner, err := d.VolumeProvisionerFactory.GetForVolume(exhibit.Id, volume)
+		if err != nil {
+			return "", err
+		}
+
+		hostPath, err := provisioner.ProvisionStorage(ctx, volume.Driver.Config)
+		if err != nil {
+			return "", err
+		}
+
+		hostConfig.Binds = append(hostConfig.Binds, hostPath+":"+hostPath+":ro")
+	}
+
+	if copies {
+		dir, err := scratchDir(d.Config, exhibit.Id)
+		if err != nil {
+			return "", err
+		}
+
+		hostConfig.Binds = append(hostConfig.Binds, dir+":"+dir)
+	}
+
+	networkingConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{d.Config.GetDindNetwork(): {}},
+	}
+
+	create, err := d.Client.ContainerCreate(ctx, &container.Config{
+		Image: d.Config.GetDindImage(),
+		Env:   dindEnv(),
+		Labels: map[string]string{
+			"museum.exhibit-id": exhibit.Id,
+		},
+	}, hostConfig, networkingConfig, nil, dindContainerName(exhibit))
+	if err != nil {
+		return "", err
+	}
+
+	return create.ID, nil
+}
+
+// connectDaemon waits until the daemon of an exhibit answers and returns a client for it
+func (d DindApplicationProvisionerService) connectDaemon(ctx context.Context, exhibit domain.Exhibit) (*docker.Client, error) {
+	deadline := time.Now().Add(time.Duration(d.Config.GetStartingTimeout()) * time.Second)
+
+	for {
+		address, err := dindAddress(ctx, d.Client, d.Config, exhibit)
+		var tlsConfig *tls.Config
+		if err == nil {
+			// the certificates are generated by the daemon while it starts, they might not be there yet
+			tlsConfig, err = d.daemonTLSConfig(ctx, exhibit)
+		}
+
+		if err == nil {
+			var client *docker.Client
+			client, err = docker.NewClientWithOpts(
+				docker.WithHost("tcp://"+address+":"+dindPort),
+				docker.WithHTTPClient(&http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}),
+				docker.WithScheme("https"),
+				docker.WithAPIVersionNegotiation(),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = client.Ping(ctx)
+			if err == nil {
+				return client, nil
+			}
+
+			_ = client.Close()
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("docker daemon did not start in time: " + err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(d.PollInterval):
+		}
+	}
+}
+
+// dindEnv makes a daemon generate its certificates and listen with TLS, clients need a certificate signed by its CA
+func dindEnv() []string {
+	return []string{"DOCKER_TLS_CERTDIR=" + dindCertDir}
+}
+
+// daemonTLSConfig copies the client certificate out of the container of a daemon, through the daemon on the host.
+// The key never leaves the container otherwise, so neither other exhibits nor anything else on the network can use the daemon
+func (d DindApplicationProvisionerService) daemonTLSConfig(ctx context.Context, exhibit domain.Exhibit) (*tls.Config, error) {
+	archive, _, err := d.Client.CopyFromContainer(ctx, dindContainerName(exhibit), path.Join(dindCertDir, "client"))
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return readDindCertificates(archive)
+}
+
+// readDindCertificates builds the tls config of a client from the tar archive of the client certificate directory of a daemon
+func readDindCertificates(archive io.Reader) (*tls.Config, error) {
+	files := map[string][]byte{}
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		files[path.Base(header.Name)] = content
+	}
+
+	for _, name := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		if len(files[name]) == 0 {
+			return nil, errors.New("docker daemon has no " + name + " yet")
+		}
+	}
+
+	certificate, err := tls.X509KeyPair(files["cert.pem"], files["key.pem"])
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(files["ca.pem"]) {
+		return nil, errors.New("docker daemon has an invalid ca.pem")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      pool,
+		ServerName:   dindServerName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// stopDaemonAfterError stops the daemon of an exhibit that failed to start, the objects that are running already would be left behind otherwise
+func (d DindApplicationProvisionerService) stopDaemonAfterError(ctx context.Context, exhibit domain.Exhibit) {
+	err := d.stopDaemon(ctx, &exhibit)
+	if err != nil {
+		d.Log.Errorw("error stopping docker daemon of failed exhibit", "exhibitId", exhibit.Id, "error", err)
+	}
+}
+
+// stopDaemon stops the daemon of an exhibit with all of its objects, its images are kept until the exhibit is cleaned up
+// but the writes to scratch volumes are not
+func (d DindApplicationProvisionerService) stopDaemon(ctx context.Context, exhibit *domain.Exhibit) error {
+	err := d.Client.ContainerStop(ctx, dindContainerName(*exhibit), container.StopOptions{})
+	if docker.IsErrNotFound(err) {
+		trace.SpanFromContext(ctx).AddEvent("docker daemon not found, skipping")
+	} else if err != nil {
+		return err
+	}
+
+	return deprovisionVolumes(ctx, d.VolumeProvisionerFactory, *exhibit)
+}
+
+// removeDaemon removes the daemon of an exhibit together with its images and containers
+func (d DindApplicationProvisionerService) removeDaemon(ctx context.Context, exhibit *domain.Exhibit) error {
+	err := d.Client.ContainerRemove(ctx, dindContainerName(*exhibit), container.RemoveOptions{Force: true, RemoveVolumes: true})
+	if err != nil && !docker.IsErrNotFound(err) {
+		return err
+	}
+
+	return deprovisionVolumes(ctx, d.VolumeProvisionerFactory, *exhibit)
+}
+
+// pullImage pulls an image unless the daemon has it already
+func pullImage(ctx context.Context, client *docker.Client, name string) error {
+	_, _, err := client.ImageInspectWithRaw(ctx, name)
+	if err == nil {
+		return nil
+	}
+
+	if !docker.IsErrNotFound(err) {
+		return err
+	}
+
+	pull, err := client.ImagePull(ctx, name, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer pull.Close()
+
+	_, err = io.ReadAll(pull)
+	return err
+}
@@ -0,0 +1,224 @@
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// dindCertificates are certificates like the ones a daemon generates in its cert dir
+type dindCertificates struct {
+	ca     *x509.Certificate
+	server tls.Certificate
+	// client files as in the client directory of the daemon
+	client map[string][]byte
+}
+
+func issue(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, []byte, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+		key
+}
+
+func newDindCertificates(t *testing.T) dindCertificates {
+	notAfter := time.Now().Add(time.Hour)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "docker:dind CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caPem, _, caKey := issue(t, caTemplate, nil, nil)
+	ca, err := x509.ParseCertificate(mustDecode(t, caPem))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverPem, serverKeyPem, _ := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "docker:dind server"},
+		DNSNames:     []string{dindServerName, "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	server, err := tls.X509KeyPair(serverPem, serverKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientPem, clientKeyPem, _ := issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "docker:dind client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	return dindCertificates{
+		ca:     ca,
+		server: server,
+		client: map[string][]byte{"ca.pem": caPem, "cert.pem": clientPem, "key.pem": clientKeyPem},
+	}
+}
+
+func mustDecode(t *testing.T, data []byte) []byte {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("no pem block")
+	}
+	return block.Bytes
+}
+
+// tarDir is what CopyFromContainer returns for the client directory of a daemon
+func tarDir(t *testing.T, files map[string][]byte) *bytes.Buffer {
+	buffer := &bytes.Buffer{}
+	writer := tar.NewWriter(buffer)
+	err := writer.WriteHeader(&tar.Header{Name: "client/", Typeflag: tar.TypeDir, Mode: 0755})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		err = writer.WriteHeader(&tar.Header{Name: "client/" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = writer.Write(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer
+}
+
+// newDindServer stands in for a daemon listening with TLS, it only accepts clients with a certificate of its CA
+func newDindServer(certificates dindCertificates) *httptest.Server {
+	pool := x509.NewCertPool()
+	pool.AddCert(certificates.ca)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{certificates.server},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+
+	return server
+}
+
+func TestDindCertificatesConnectToTheirDaemon(t *testing.T) {
+	certificates := newDindCertificates(t)
+	server := newDindServer(certificates)
+	defer server.Close()
+
+	config, err := readDindCertificates(tarDir(t, certificates.client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	response, err := client.Get(server.URL + "/_ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected the daemon to answer, got %d", response.StatusCode)
+	}
+}
+
+func TestDindDaemonRejectsOtherClients(t *testing.T) {
+	certificates := newDindCertificates(t)
+	server := newDindServer(certificates)
+	defer server.Close()
+
+	// a client trusting the daemon but without a certificate, like another exhibit on the network
+	pool := x509.NewCertPool()
+	pool.AddCert(certificates.ca)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: dindServerName}}}
+	response, err := client.Get(server.URL + "/_ping")
+	if err == nil {
+		_ = response.Body.Close()
+		t.Fatal("expected a client without certificate to be rejected")
+	}
+
+	// the certificates of another daemon don't work either
+	other, err := readDindCertificates(tarDir(t, newDindCertificates(t).client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: other}}
+	response, err = client.Get(server.URL + "/_ping")
+	if err == nil {
+		_ = response.Body.Close()
+		t.Fatal("expected the certificates of another daemon to be rejected")
+	}
+}
+
+func TestDindCertificatesNotGeneratedYet(t *testing.T) {
+	certificates := newDindCertificates(t)
+	delete(certificates.client, "key.pem")
+
+	_, err := readDindCertificates(tarDir(t, certificates.client))
+	if err == nil {
+		t.Fatal("expected an error while the daemon has no key yet")
+	}
+}
+
+func TestDindDaemonUsesTLS(t *testing.T) {
+	for _, env := range dindEnv() {
+		if env == "DOCKER_TLS_CERTDIR=" {
+			t.Fatal("expected the daemon to generate certificates")
+		}
+	}
+
+	if dindPort == "2375" {
+		t.Fatal("expected the daemon to listen on the TLS port")
+	}
+}
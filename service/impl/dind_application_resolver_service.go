@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	docker "github.com/docker/docker/client"
+	"museum/config"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util/cache"
+)
+
+// DindApplicationResolverService resolves exhibits to the address of their docker daemon, which publishes the port of the exposed object
+type DindApplicationResolverService struct {
+	ExhibitService service.ExhibitService
+	IpCache        *cache.LRU[string, string]
+	Client         *docker.Client
+	Config         config.Config
+	Eventing       persistence.Eventing
+}
+
+func (d DindApplicationResolverService) ResolveApplication(ctx context.Context, exhibitId string) (string, error) {
+	exhibit, err := d.ExhibitService.GetExhibitById(ctx, exhibitId)
+	if err != nil {
+		return "", err
+	}
+
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		return "", errors.New("exhibit is not running")
+	}
+
+	if ip, ok := d.IpCache.Get(exhibit.Id); ok && ip != "" {
+		return ip, nil
+	}
+
+	ip, err := dindAddress(ctx, d.Client, d.Config, exhibit)
+	if err != nil {
+		return "", err
+	}
+
+	d.IpCache.Put(exhibit.Id, ip)
+
+	// the daemon may get another address when it is started again
+	go func() {
+		channel, c, err := d.Eventing.GetExhibitStoppingChannel(exhibitId, context.Background())
+		if err != nil {
+			return
+		}
+		defer c()
+
+		<-channel
+		d.IpCache.Put(exhibit.Id, "")
+	}()
+
+	return ip, nil
+}
+
+// ResolveExhibitObject resolves every object to the daemon, only the exposed object can be reached there
+func (d DindApplicationResolverService) ResolveExhibitObject(exhibit domain.Exhibit, _ domain.Object) (string, error) {
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		return "", errors.New("exhibit is not running")
+	}
+
+	return dindAddress(context.Background(), d.Client, d.Config, exhibit)
+}
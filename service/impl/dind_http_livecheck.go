@@ -0,0 +1,46 @@
+package impl
+
+import (
+	"context"
+	docker "github.com/docker/docker/client"
+	"museum/domain"
+)
+
+// DindHttpLivecheck checks objects running inside the docker daemon of an exhibit, which museum can't reach,
+// the request is sent by wget in the container of the daemon, so it is always a GET and succeeds on any 2xx status
+type DindHttpLivecheck struct {
+	// Client talks to the docker daemon on the host
+	Client *docker.Client
+	// Inner talks to the docker daemon of the exhibit
+	Inner *docker.Client
+}
+
+func (h *DindHttpLivecheck) Check(ctx context.Context, exhibit domain.Exhibit, object domain.Object) (retry bool, err error) {
+	inspect, err := h.Inner.ContainerInspect(ctx, exhibit.Name+"_"+object.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if !inspect.State.Running {
+		return true, nil
+	}
+
+	ip := inspect.NetworkSettings.DefaultNetworkSettings.IPAddress
+
+	port, ok := object.Livecheck.Config["port"]
+	if !ok {
+		port = "80"
+	}
+
+	path, ok := object.Livecheck.Config["path"]
+	if !ok {
+		path = "/"
+	}
+
+	exitCode, err := execInContainer(ctx, h.Client, dindContainerName(exhibit), []string{"wget", "-q", "-O", "/dev/null", "http://" + ip + ":" + port + path})
+	if err != nil {
+		return false, err
+	}
+
+	return exitCode != 0, nil
+}
@@ -7,49 +7,37 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
-	"go.uber.org/zap"
 	"museum/config"
 	"museum/domain"
-	"museum/persistence"
 	service "museum/service/interface"
-	"museum/util"
 	"strconv"
 	"syscall"
 	"time"
 )
 
 type DockerApplicationProvisionerService struct {
-	ExhibitService              service.ExhibitService
+	ApplicationLifecycle
 	LivecheckFactoryService     service.LivecheckFactoryService
 	EnvironmentTemplateResolver service.EnvironmentTemplateResolverService
 	Client                      *docker.Client
-	LockService                 service.LockService
-	RuntimeInfoService          service.RuntimeInfoService
-	LastAccessedService         service.LastAccessedService
-	Eventing                    persistence.Eventing
-	Log                         *zap.SugaredLogger
-	Provider                    trace.TracerProvider
 	Config                      config.Config
 	VolumeProvisionerFactory    service.VolumeProvisionerFactoryService
+	// PublishExposed publishes the port of the exposed object on the docker host, e.g. a docker in docker daemon
+	PublishExposed bool
 }
 
 func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx context.Context, exhibit *domain.Exhibit) error {
-	sortedObjects := exhibit.Objects
-	if exhibit.Order != nil {
-		sortedObjects = make([]domain.Object, 0)
-		for _, s := range exhibit.Order {
-			for _, o := range exhibit.Objects {
-				if s == o.Name {
-					sortedObjects = append(sortedObjects, o)
-				}
-			}
-		}
-	}
-
 	containerNameMapping := make(map[string]string)
 
+	// the default profile may have been tightened since the exhibit was validated
+	err := enforceProfile(d.Config.GetDefaultProfile(), *exhibit)
+	if err != nil {
+		return err
+	}
+
 	networkInspect, err := d.Client.NetworkInspect(ctx, exhibit.Name, network.InspectOptions{})
 	if err != nil {
 		d.Log.Warnw("network not found, creating", "exhibit", exhibit.Name)
@@ -74,27 +62,37 @@ func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx cont
 		}
 	}
 
-	stepCount := 1
+	// the volumes are provisioned before any object starts, which resets the scratch volumes
+	volumes, err := provisionVolumes(ctx, d.VolumeProvisionerFactory, *exhibit)
+	if err != nil {
+		d.Log.Errorw("error provisioning volumes", "exhibit", exhibit.Name, "error", err)
+		return err
+	}
+
+	progress := newStartProgress(d.Eventing)
 
-	// create a container on the swarm for each object
-	idx := 0
-	for _, o := range sortedObjects {
-		err := d.startExhibitObject(ctx, exhibit, o, networkInspect, idx, &stepCount, &containerNameMapping)
+	// create a container on the swarm for each object, as soon as the objects it depends on are ready
+	err = startGraph(ctx, *exhibit, func(ctx context.Context, idx int) error {
+		o := exhibit.Objects[idx]
+
+		err := d.startExhibitObject(ctx, exhibit, o, networkInspect, volumes, idx, progress, &containerNameMapping)
 		if err != nil {
 			d.Log.Warnw("error starting exhibit object", "exhibit", exhibit.Name, "object", o.Name, "error", err)
+		}
 
-			// set the status to stopped
-			exhibit.RuntimeInfo.Status = domain.Stopped
-			e := d.RuntimeInfoService.SetRuntimeInfo(ctx, exhibit.Id, *exhibit.RuntimeInfo)
-			if e != nil {
-				d.Log.Errorw("error setting runtime info", "exhibit", exhibit.Name, "error", e)
-				return e
-			}
-
-			d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
-			return err
+		return err
+	})
+	if err != nil {
+		// set the status to stopped
+		exhibit.RuntimeInfo.Status = domain.Stopped
+		e := d.RuntimeInfoService.SetRuntimeInfo(ctx, exhibit.Id, *exhibit.RuntimeInfo)
+		if e != nil {
+			d.Log.Errorw("error setting runtime info", "exhibit", exhibit.Name, "error", e)
+			return e
 		}
-		idx++
+
+		d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+		return err
 	}
 
 	exhibit.RuntimeInfo.Status = domain.Running
@@ -102,7 +100,7 @@ func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx cont
 	return nil
 }
 
-func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Context, exhibit *domain.Exhibit, object domain.Object, network network.Inspect, idx int, stepCount *int, templateContainer *map[string]string) error {
+func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Context, exhibit *domain.Exhibit, object domain.Object, network network.Inspect, volumes map[string]string, idx int, progress *startProgress, templateContainer *map[string]string) error {
 	containerImage := object.Image + ":" + object.Label
 	containerConfig := &container.Config{
 		Image: containerImage,
@@ -127,8 +125,12 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 		Start(ctx, "startApplicationInsideLock", trace.WithAttributes(attribute.String("container", name), attribute.String("exhibitId", exhibit.Id)))
 	defer span.End()
 
+	timer := d.Metrics.StartStepTimer()
+	defer timer.Stop()
+
 	span.AddEvent("inspecting container")
-	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+	timer.Step(domain.ObjectStartingStepClean.String())
+	progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 		Object: idx,
 		Step:   domain.ObjectStartingStepClean,
 	})
@@ -141,7 +143,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 		err = d.doCleanup(inspect, exhibit, ctx)
 		if err != nil {
 			d.Log.Errorw("error cleaning up container", "container", name, "exhibitId", exhibit.Id, "error", err)
-			d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+			progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 				Object: idx,
 				Step:   domain.ObjectStartingStepClean,
 				Error:  err,
@@ -151,7 +153,8 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	}
 
 	span.AddEvent("creating container")
-	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+	timer.Step(domain.ObjectStartingStepCreate.String())
+	progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 		Object: idx,
 		Step:   domain.ObjectStartingStepCreate,
 	})
@@ -160,50 +163,41 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	containerConfig.Hostname = name
 	containerConfig.Domainname = object.Name + "." + exhibit.Name
 
-	var hostConfig *container.HostConfig = nil
-
-	// setup container mounts
-	if len(object.Mounts) != 0 {
-		hostConfig = &container.HostConfig{}
-
-		for containerVolume, containerMount := range object.Mounts {
-			// find corresponding volume
-			volume := domain.Volume{}
-			for _, v := range exhibit.Volumes {
-				if v.Name == containerVolume {
-					volume = v
-					break
-				}
-			}
+	hostConfig := &container.HostConfig{}
 
-			provisioner, err := d.VolumeProvisionerFactory.GetForDriverType(volume.Driver.Type)
-			if err != nil {
-				d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
-					Object: idx,
-					Step:   domain.ObjectStartingStepCreate,
-					Error:  err,
-				})
-				return err
-			}
+	profile, err := d.Config.GetDefaultProfile().Enforce(object)
+	if err == nil {
+		err = applyDockerProfile(profile, containerConfig, hostConfig)
+	}
+	if err != nil {
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
+			Object: idx,
+			Step:   domain.ObjectStartingStepCreate,
+			Error:  err,
+		})
+		return err
+	}
 
-			hostPath, err := provisioner.ProvisionStorage(ctx, volume.Driver.Config)
-			if err != nil {
-				d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
-					Object: idx,
-					Step:   domain.ObjectStartingStepCreate,
-					Error:  err,
-				})
-				return err
-			}
+	// setup container mounts, read only unless the volume is a scratch volume
+	for containerVolume, containerMount := range object.Mounts {
+		hostConfig.Binds = append(hostConfig.Binds, volumeBind(findVolume(*exhibit, containerVolume), volumes[containerVolume], containerMount))
+	}
 
-			hostConfig.Binds = append(hostConfig.Binds, hostPath+":"+containerMount)
+	if d.PublishExposed && object.Name == exhibit.Expose {
+		port := "80"
+		if object.Port != nil {
+			port = *object.Port
 		}
+
+		exposed := nat.Port(port + "/tcp")
+		containerConfig.ExposedPorts = nat.PortSet{exposed: struct{}{}}
+		hostConfig.PortBindings = nat.PortMap{exposed: []nat.PortBinding{{HostPort: port}}}
 	}
 
 	create, err := d.Client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, name)
 	if err != nil {
 		d.Log.Errorw("error creating container", "container", name, "exhibitId", exhibit.Id, "error", err)
-		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepCreate,
 			Error:  err,
@@ -214,7 +208,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	err = d.Client.NetworkConnect(ctx, network.ID, create.ID, nil)
 	if err != nil {
 		d.Log.Errorw("error connecting container to network", "container", name, "exhibitId", exhibit.Id, "error", err)
-		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepCreate,
 			Error:  err,
@@ -223,7 +217,8 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	}
 
 	span.AddEvent("starting container")
-	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+	timer.Step(domain.ObjectStartingStepStart.String())
+	progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 		Object: idx,
 		Step:   domain.ObjectStartingStepStart,
 	})
@@ -232,7 +227,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	err = d.Client.ContainerStart(ctx, create.ID, container.StartOptions{})
 	if err != nil {
 		d.Log.Errorw("error starting container", "container", name, "exhibitId", exhibit.Id, "error", err)
-		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepStart,
 			Error:  err,
@@ -241,27 +236,30 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	}
 
 	if object.Name == exhibit.Expose {
-		exhibit.RuntimeInfo.Hostname = name
+		progress.update(func() {
+			exhibit.RuntimeInfo.Hostname = name
+		})
 	}
 
 	if object.Livecheck != nil {
 		span.AddEvent("doing livecheck")
-		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+		timer.Step(domain.ObjectStartingStepLivecheck.String())
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepLivecheck,
 		})
 
-		err := d.doLivecheck(ctx, *exhibit, object)
+		err := d.doLivecheck(ctx, progress.snapshot(*exhibit), object)
 		if err != nil {
 			d.Log.Warnw("error doing livecheck", "exhibitId", exhibit.Id, "error", err)
-			d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+			progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 				Object: idx,
 				Step:   domain.ObjectStartingStepLivecheck,
 				Error:  err,
 			})
 
 			d.Log.Debugw("livecheck failed, cleaning up container", "container", name, "exhibitId", exhibit.Id)
-			e := d.cleanupContainer(ctx, exhibit, create, name, object)
+			e := d.cleanupContainer(ctx, exhibit, create, name)
 			if e != nil {
 				return e
 			}
@@ -270,29 +268,34 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 		}
 	}
 
-	exhibit.RuntimeInfo.RelatedContainers = append(exhibit.RuntimeInfo.RelatedContainers, create.ID)
+	progress.update(func() {
+		exhibit.RuntimeInfo.RelatedContainers = append(exhibit.RuntimeInfo.RelatedContainers, create.ID)
+	})
 
 	// TODO: expose a random port and cache that instead of the container IP
 	// get container ip
 	inspect, err = d.Client.ContainerInspect(ctx, create.ID)
 	if err != nil {
 		d.Log.Errorw("error inspecting container", "container", name, "exhibitId", exhibit.Id, "error", err)
-		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepReady,
 			Error:  err,
 		})
 
-		e := d.cleanupContainer(ctx, exhibit, create, name, object)
+		e := d.cleanupContainer(ctx, exhibit, create, name)
 		if e != nil {
 			return e
 		}
 
 		return err
 	}
-	(*templateContainer)[object.Name] = inspect.NetworkSettings.Networks["bridge"].IPAddress
+	progress.update(func() {
+		(*templateContainer)[object.Name] = inspect.NetworkSettings.Networks["bridge"].IPAddress
+	})
 
-	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
+	timer.Stop()
+	progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
 		Object: idx,
 		Step:   domain.ObjectStartingStepReady,
 	})
@@ -300,7 +303,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	return nil
 }
 
-func (d DockerApplicationProvisionerService) cleanupContainer(ctx context.Context, exhibit *domain.Exhibit, create container.CreateResponse, name string, object domain.Object) error {
+func (d DockerApplicationProvisionerService) cleanupContainer(ctx context.Context, exhibit *domain.Exhibit, create container.CreateResponse, name string) error {
 	// stop the container
 	e := d.Client.ContainerStop(ctx, create.ID, container.StopOptions{})
 	if e != nil {
@@ -315,29 +318,6 @@ func (d DockerApplicationProvisionerService) cleanupContainer(ctx context.Contex
 		return e
 	}
 
-	for containerVolume := range object.Mounts {
-		// find corresponding volume
-		volume := domain.Volume{}
-		for _, v := range exhibit.Volumes {
-			if v.Name == containerVolume {
-				volume = v
-				break
-			}
-		}
-
-		provisioner, err := d.VolumeProvisionerFactory.GetForDriverType(volume.Driver.Type)
-		if err != nil {
-			d.Log.Errorw("error getting volume provisioner", "error", err)
-			return err
-		}
-
-		err = provisioner.DeprovisionStorage(ctx, volume.Driver.Config)
-		if err != nil {
-			d.Log.Errorw("error deprovisioning storage", "error", err)
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -370,18 +350,6 @@ func (d DockerApplicationProvisionerService) doCleanup(inspect types.ContainerJS
 		return err
 	}
 
-	for _, volume := range exhibit.Volumes {
-		provisioner, e := d.VolumeProvisionerFactory.GetForDriverType(volume.Driver.Type)
-		if e != nil {
-			return err
-		}
-
-		e = provisioner.DeprovisionStorage(subCtx, volume.Driver.Config)
-		if e != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -432,235 +400,26 @@ func (d DockerApplicationProvisionerService) doLivecheck(ctx context.Context, ex
 	return nil
 }
 
-func (d DockerApplicationProvisionerService) applicationStartingStep(ctx context.Context, exhibitId string) (err error) {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "applicationStartingStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("runtime_info lock acquired")
-
-	defer func(lock util.RwErrMutex) {
-		e := lock.Unlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(lock)
-
-	span.AddEvent("checking exhibit status")
-
-	// check that exhibit is not already started after lock is acquired
-	if exhibit.RuntimeInfo.Status == domain.Running {
-		return nil
-	}
-
-	if exhibit.RuntimeInfo.Status != domain.Stopped && exhibit.RuntimeInfo.Status != domain.NotCreated {
-		return errors.New(string("cannot start application in state " + exhibit.RuntimeInfo.Status))
-	}
-
-	span.AddEvent("setting exhibit status to starting")
-
-	exhibit.RuntimeInfo.Status = domain.Starting
-	exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
-
-	err = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("exhibit status set to starting")
-
-	err = d.LastAccessedService.SetLastAccessed(subCtx, exhibitId, time.Now().Unix())
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d DockerApplicationProvisionerService) applicationRunningStep(ctx context.Context, exhibitId string) (err error) {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "applicationRunningStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	exhibitRlock := d.LockService.GetRwLock(subCtx, exhibitId, "exhibit")
-	err = exhibitRlock.RLock()
-	if err != nil {
-		d.Log.Errorw("error locking exhibit", "exhibitId", exhibitId, "error", err)
-		return err
-	}
-
-	span.AddEvent("exhibit lock acquired")
-
-	defer func(lock util.RwErrMutex) {
-		e := lock.RUnlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking exhibit", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(exhibitRlock)
-
-	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("runtime_info lock acquired")
-
-	defer func(lock util.RwErrMutex) {
-		e := lock.Unlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(lock)
-
-	err = d.startApplicationInsideLock(subCtx, &exhibit)
-	if err != nil {
-		d.Log.Debugw("error starting application, reverting status to stopped", "exhibitId", exhibitId, "error", err)
-		span.AddEvent("error starting application, reverting status to stopped")
-
-		exhibit.RuntimeInfo.Status = domain.Stopped
-		exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
-		_ = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-		return err
-	}
-
-	return d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-}
-
 func (d DockerApplicationProvisionerService) StartApplication(ctx context.Context, exhibitId string) error {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "StartApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	err := d.applicationStartingStep(subCtx, exhibitId)
-	if err != nil {
-		d.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
-		return err
-	}
-
-	err = d.applicationRunningStep(subCtx, exhibitId)
-	if err != nil {
-		d.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
-		return err
-	}
-
-	return nil
+	return d.startApplication(ctx, exhibitId, d.startApplicationInsideLock)
 }
 
-func (d DockerApplicationProvisionerService) applicationStoppingStep(ctx context.Context, exhibitId string) (err error) {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "applicationStoppingStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
-	if err != nil {
-		return err
-	}
-
-	d.Eventing.DispatchExhibitStoppingEvent(subCtx, exhibit)
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("runtime_info lock acquired")
-
-	defer func(lock util.RwErrMutex) {
-		e := lock.Unlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(lock)
-
-	span.AddEvent("checking exhibit status")
-
-	// check that exhibit is not already stopped after lock is acquired
-	if exhibit.RuntimeInfo.Status == domain.Stopped {
-		return nil
-	}
-
-	if exhibit.RuntimeInfo.Status != domain.Running {
-		return errors.New(string("cannot stop application in state " + exhibit.RuntimeInfo.Status))
-	}
-
-	span.AddEvent("setting exhibit status to stopping")
-
-	exhibit.RuntimeInfo.Status = domain.Stopping
-	err = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-	if err != nil {
-		return err
-	}
-
-	return nil
+func (d DockerApplicationProvisionerService) StopApplication(ctx context.Context, exhibitId string) error {
+	return d.stopApplication(ctx, exhibitId, d.stopContainers)
 }
 
-func (d DockerApplicationProvisionerService) applicationStoppedStep(ctx context.Context, exhibitId string) (err error) {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "applicationStoppedStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("runtime_info lock acquired")
+func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Context, exhibitId string) error {
+	return d.cleanupApplication(ctx, exhibitId, d.cleanupContainers)
+}
 
-	defer func(lock util.RwErrMutex) {
-		e := lock.Unlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(lock)
+// stopContainers stops the containers of an exhibit, they are kept until the exhibit is cleaned up but the writes to scratch volumes are not
+func (d DockerApplicationProvisionerService) stopContainers(ctx context.Context, exhibit *domain.Exhibit) error {
+	span := trace.SpanFromContext(ctx)
 
 	for _, c := range exhibit.RuntimeInfo.RelatedContainers {
 		span.AddEvent("stopping container " + c)
 
-		err = d.Client.ContainerStop(subCtx, c, container.StopOptions{})
+		err := d.Client.ContainerStop(ctx, c, container.StopOptions{})
 		if docker.IsErrNotFound(err) {
 			span.AddEvent("container not found, skipping")
 			continue
@@ -671,74 +430,15 @@ func (d DockerApplicationProvisionerService) applicationStoppedStep(ctx context.
 		}
 	}
 
-	span.AddEvent("setting exhibit status to stopped")
-
-	exhibit.RuntimeInfo.Status = domain.Stopped
-	err = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return deprovisionVolumes(ctx, d.VolumeProvisionerFactory, *exhibit)
 }
 
-func (d DockerApplicationProvisionerService) StopApplication(ctx context.Context, exhibitId string) error {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "CleanupApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	err := d.applicationStoppingStep(subCtx, exhibitId)
-	if err != nil {
-		d.Log.Errorw("error stopping application", "exhibitId", exhibitId, "error", err)
-		return err
-	}
-
-	err = d.applicationStoppedStep(subCtx, exhibitId)
-	if err != nil {
-		d.Log.Errorw("error stopping application", "exhibitId", exhibitId, "error", err)
-		return err
-	}
-
-	return nil
-}
-
-func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Context, exhibitId string) (err error) {
-	subCtx, span := d.Provider.
-		Tracer("docker provisioner").
-		Start(ctx, "CleanupApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
-	defer span.End()
-
-	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("acquiring runtime_info lock")
-
-	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("runtime_info lock acquired")
-
-	defer func(lock util.RwErrMutex) {
-		e := lock.Unlock()
-		if e != nil {
-			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
-			err = e
-		}
-	}(lock)
-
-	// check that exhibit is stopped+
-	if exhibit.RuntimeInfo.Status != domain.Stopped {
-		return errors.New(string("cannot cleanup application in state " + exhibit.RuntimeInfo.Status))
-	}
+// cleanupContainers removes the containers and the network of an exhibit
+func (d DockerApplicationProvisionerService) cleanupContainers(ctx context.Context, exhibit *domain.Exhibit) error {
+	span := trace.SpanFromContext(ctx)
 
 	for _, containerId := range exhibit.RuntimeInfo.RelatedContainers {
-		inspect, err := d.Client.ContainerInspect(subCtx, containerId)
+		inspect, err := d.Client.ContainerInspect(ctx, containerId)
 		if docker.IsErrNotFound(err) {
 			span.AddEvent("container not found, skipping")
 			continue
@@ -748,13 +448,13 @@ func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Cont
 			return err
 		}
 
-		err = d.doCleanup(inspect, &exhibit, subCtx)
+		err = d.doCleanup(inspect, exhibit, ctx)
 		if err != nil {
 			return err
 		}
 	}
 
-	networks, err := d.Client.NetworkList(subCtx, network.ListOptions{})
+	networks, err := d.Client.NetworkList(ctx, network.ListOptions{})
 	if err != nil {
 		d.Log.Errorw("error listing networks", "error", err)
 		networks = make([]network.Inspect, 0)
@@ -762,7 +462,7 @@ func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Cont
 
 	for _, summary := range networks {
 		if summary.Name == exhibit.Name {
-			err = d.Client.NetworkRemove(subCtx, summary.ID)
+			err = d.Client.NetworkRemove(ctx, summary.ID)
 			if err != nil {
 				d.Log.Errorw("error removing network", "error", err)
 			}
@@ -770,14 +470,5 @@ func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Cont
 		}
 	}
 
-	span.AddEvent("resetting runtime_info")
-	exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
-	exhibit.RuntimeInfo.Hostname = ""
-
-	err = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return deprovisionVolumes(ctx, d.VolumeProvisionerFactory, *exhibit)
 }
@@ -9,18 +9,120 @@ import (
 	"museum/domain"
 	"museum/http"
 	service "museum/service/interface"
+	"net"
 	gohttp "net/http"
-	"net/url"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 )
 
 type DockerApplicationProxyService struct {
-	Resolver       service.ApplicationResolverService
-	RewriteService service.RewriteService
-	Log            *zap.SugaredLogger
-	Config         config.Config
+	Resolver            service.ApplicationResolverService
+	RewriteService      service.RewriteService
+	LastAccessedService service.LastAccessedService
+	Log                 *zap.SugaredLogger
+	Config              config.Config
+
+	ClientCache   map[string]*ProxyClient
+	ClientCacheMu *sync.RWMutex
+}
+
+// ProxyClient is a pooled http client for a single exhibit
+type ProxyClient struct {
+	Client      *gohttp.Client
+	IdleTimeout time.Duration
+	Timeout     time.Duration
+}
+
+// getClient returns the pooled http client of an exhibit, so keep-alive connections can be reused between requests
+func (d *DockerApplicationProxyService) getClient(exhibit domain.Exhibit) (*gohttp.Client, error) {
+	idleTimeout, err := exhibit.Proxy.GetIdleTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := exhibit.Proxy.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	d.ClientCacheMu.RLock()
+	c, ok := d.ClientCache[exhibit.Id]
+	d.ClientCacheMu.RUnlock()
+
+	if ok && c.IdleTimeout == idleTimeout && c.Timeout == timeout {
+		return c.Client, nil
+	}
+
+	d.ClientCacheMu.Lock()
+	defer d.ClientCacheMu.Unlock()
+
+	// another request might have created the client in the meantime
+	c, ok = d.ClientCache[exhibit.Id]
+	if ok && c.IdleTimeout == idleTimeout && c.Timeout == timeout {
+		return c.Client, nil
+	}
+
+	// the exhibit config changed, so we drop the old pool
+	if ok {
+		c.Client.CloseIdleConnections()
+	}
+
+	transport := gohttp.DefaultTransport.(*gohttp.Transport).Clone()
+	transport.Proxy = nil
+	transport.IdleConnTimeout = idleTimeout
+	transport.MaxIdleConnsPerHost = 32
+	// the timeout only applies until the application answers with its headers,
+	// streamed bodies (e.g. SSE) may take as long as they want
+	transport.ResponseHeaderTimeout = timeout
+
+	c = &ProxyClient{
+		Client: &gohttp.Client{
+			Transport: transport,
+			CheckRedirect: func(req *gohttp.Request, via []*gohttp.Request) error {
+				return gohttp.ErrUseLastResponse
+			},
+		},
+		IdleTimeout: idleTimeout,
+		Timeout:     timeout,
+	}
+	d.ClientCache[exhibit.Id] = c
+
+	return c.Client, nil
+}
+
+func (d *DockerApplicationProxyService) EvictClient(exhibitId string) {
+	d.ClientCacheMu.Lock()
+	defer d.ClientCacheMu.Unlock()
+
+	d.evictClient(exhibitId)
+}
+
+func (d *DockerApplicationProxyService) PruneClients(exhibits []domain.Exhibit) {
+	exists := make(map[string]bool, len(exhibits))
+	for _, exhibit := range exhibits {
+		exists[exhibit.Id] = true
+	}
+
+	d.ClientCacheMu.Lock()
+	defer d.ClientCacheMu.Unlock()
+
+	for id := range d.ClientCache {
+		if !exists[id] {
+			d.evictClient(id)
+		}
+	}
+}
+
+// evictClient closes the idle connections of a pooled client and drops it, the cache has to be locked
+func (d *DockerApplicationProxyService) evictClient(exhibitId string) {
+	c, ok := d.ClientCache[exhibitId]
+	if !ok {
+		return
+	}
+
+	c.Client.CloseIdleConnections()
+	delete(d.ClientCache, exhibitId)
 }
 
 func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, path string, res *http.Response, req *http.Request) error {
@@ -45,17 +147,38 @@ func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, p
 		}
 	}
 
-	//TODO: handle websocket
-	//TODO: handle SSE
+	if req.IsUpgrade() {
+		return d.forwardUpgrade(exhibit, ip+":"+port, path, res, req)
+	}
+
+	maxBodySize, err := exhibit.Proxy.GetMaxBodySize()
+	if err != nil {
+		d.Log.Warnw("invalid proxy max body size", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
+	}
+
+	var body io.Reader = req.Body
+	if maxBodySize > 0 {
+		body = gohttp.MaxBytesReader(res, req.Body, maxBodySize)
+	}
 
-	reqBody, err := io.ReadAll(req.Body)
+	reqBody, err := io.ReadAll(body)
 	if err != nil {
+		var maxBytesErr *gohttp.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			d.Log.Warnw("request body too large", "limit", maxBodySize, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			res.WriteHeader(gohttp.StatusRequestEntityTooLarge)
+			return err
+		}
+
 		d.Log.Warnw("error reading request body", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
 		res.WriteHeader(gohttp.StatusInternalServerError)
 		return err
 	}
 
 	// rewrite request
+	d.RewriteService.RewriteClientCookies(exhibit, ip+":"+port, req)
 	if exhibit.Rewrite != nil && *exhibit.Rewrite {
 		err = d.RewriteService.RewriteClientRequest(exhibit, ip+":"+port, req, &reqBody)
 		if err != nil {
@@ -70,15 +193,16 @@ func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, p
 		queryParams = "?" + req.RawQueryParams
 	}
 
-	// create http client
-	client := gohttp.Client{
-		CheckRedirect: func(req *gohttp.Request, via []*gohttp.Request) error {
-			return gohttp.ErrUseLastResponse
-		},
+	client, err := d.getClient(exhibit)
+	if err != nil {
+		d.Log.Warnw("error getting proxy client", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
 	}
 
 	// proxy the request
-	proxyReq, err := gohttp.NewRequest(req.Method, "http://"+ip+":"+port+"/"+path+queryParams, bytes.NewReader(reqBody))
+	ctx := req.Context()
+	proxyReq, err := gohttp.NewRequestWithContext(ctx, req.Method, "http://"+ip+":"+port+"/"+path+queryParams, bytes.NewReader(reqBody))
 	if err != nil {
 		d.Log.Warnw("error creating proxy request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
 		res.WriteHeader(gohttp.StatusInternalServerError)
@@ -88,42 +212,47 @@ func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, p
 	proxyReq.Header = req.Header
 	proxyReq.Host = req.Host
 
-	//do request with timeout
-	proxyRes := new(gohttp.Response)
-	resultChan := make(chan error)
-	go func() {
-		var err error
-		proxyRes, err = client.Do(proxyReq)
-		resultChan <- err
-	}()
+	proxyRes, err := client.Do(proxyReq)
+	if err != nil && ctx.Err() != nil {
+		d.Log.Debugw("client gone before the proxy request finished", "error", ctx.Err(), "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		return ctx.Err()
+	}
 
-	select {
-	case err := <-resultChan:
-		if err != nil {
-			if proxyRes == nil {
-				proxyRes = &gohttp.Response{
-					StatusCode: gohttp.StatusInternalServerError,
-				}
-			}
+	// the transport gives up once the exhibit doesn't answer with its headers in time
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		d.Log.Warnw("timeout doing proxy request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusGatewayTimeout)
+		return err
+	}
 
-			d.Log.Warnw("error doing proxy request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id, "httpStatus", proxyRes.StatusCode)
-			res.WriteHeader(gohttp.StatusInternalServerError)
-			return err
-		}
-	case <-time.After(5 * time.Second):
-		d.Log.Warnw("timeout doing proxy request", "requestId", req.RequestID, "exhibitId", exhibit.Id)
-		res.WriteHeader(gohttp.StatusInternalServerError)
-		return errors.New("timeout doing proxy request")
+	if err != nil {
+		d.Log.Warnw("error doing proxy request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusBadGateway)
+		return err
 	}
 
 	if proxyRes.Request.URL.Path != "/"+path && proxyReq.Method == "GET" {
 		// the application redirected us to a different path
 		// we need to redirect the user to the new path
-		res.Header().Set("Location", "/exhibit/"+exhibit.Id+proxyRes.Request.URL.Path)
+		res.Header().Set("Location", d.Config.GetRoutingMode().BasePath(exhibit.Id)+proxyRes.Request.URL.Path)
 		res.WriteHeader(gohttp.StatusTemporaryRedirect)
 		return nil
 	}
 
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(proxyRes.Body)
+
+	// rewrite the urls inside the headers (e.g. Location or Set-Cookie), they would leave the exhibit otherwise,
+	// only the body is left alone without rewrite
+	d.RewriteService.RewriteServerResponseHeaders(exhibit, ip+":"+port, proxyRes)
+
+	// stream everything we don't have to rewrite (e.g. SSE, long polling or large downloads)
+	if !d.mustBufferResponse(exhibit, proxyRes) {
+		return d.streamResponse(exhibit, proxyRes, res, req)
+	}
+
 	// read entire body
 	resBody, err := func() (*[]byte, error) {
 		// this might look a bit hacky, but it's actually
@@ -142,42 +271,17 @@ func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, p
 	}
 
 	// rewrite response
-	if exhibit.Rewrite != nil && *exhibit.Rewrite {
-		resBody, err = d.RewriteService.RewriteServerResponse(exhibit, ip+":"+port, proxyRes, resBody)
-		if err != nil {
-			d.Log.Warnw("error rewriting host", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
-			res.WriteHeader(gohttp.StatusInternalServerError)
-			return err
-		}
-		proxyRes.Header.Set("Content-Length", strconv.Itoa(len(*resBody)))
+	resBody, err = d.RewriteService.RewriteServerResponse(exhibit, ip+":"+port, proxyRes, resBody)
+	if err != nil {
+		d.Log.Warnw("error rewriting host", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
 	}
+	proxyRes.Header.Set("Content-Length", strconv.Itoa(len(*resBody)))
 
+	// all values have to be copied, there might be more than one Set-Cookie header
 	for k, v := range proxyRes.Header {
-		res.Header().Set(k, v[0])
-	}
-
-	if proxyRes.StatusCode > 299 && proxyRes.StatusCode < 400 {
-		// the application redirected us to a different path
-		// we need to redirect the user to the new path
-		redirectUrl, err := url.Parse(proxyRes.Header.Get("Location"))
-		if err != nil {
-			d.Log.Warnw("error parsing redirect url", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
-			res.WriteHeader(gohttp.StatusInternalServerError)
-			return err
-		}
-
-		// restructure from http://localhost:8080/foo/bar
-		// to http://localhost:8080/exhibit/123/foo/bar
-		// if, somehow, the redirect url already contains the exhibit id, we don't want to add it again
-
-		if strings.Contains(redirectUrl.String(), "/exhibit/"+exhibit.Id) {
-			res.WriteHeader(gohttp.StatusTemporaryRedirect)
-			return nil
-		}
-
-		res.Header().Set("Location", "/exhibit/"+exhibit.Id+redirectUrl.Path)
-		res.WriteHeader(gohttp.StatusTemporaryRedirect)
-		return nil
+		res.Header()[k] = v
 	}
 
 	res.WriteHeader(proxyRes.StatusCode)
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"errors"
+	"io"
+	"museum/domain"
+	"museum/http"
+	"museum/util/rewrite"
+	gohttp "net/http"
+)
+
+// size of the chunks that are read from the application before being flushed to the client
+const streamChunkSize = 32 * 1024
+
+// mustBufferResponse checks if the response body has to be read entirely before it can be sent to the client
+// this is only the case if the body will be rewritten
+func (d *DockerApplicationProxyService) mustBufferResponse(exhibit domain.Exhibit, proxyRes *gohttp.Response) bool {
+	if exhibit.Rewrite == nil || !*exhibit.Rewrite {
+		return false
+	}
+
+	return rewrite.CanRewrite(proxyRes.Header.Get("Content-Type"))
+}
+
+func (d *DockerApplicationProxyService) streamResponse(exhibit domain.Exhibit, proxyRes *gohttp.Response, res *http.Response, req *http.Request) error {
+	for k, v := range proxyRes.Header {
+		res.Header()[k] = v
+	}
+
+	res.WriteHeader(proxyRes.StatusCode)
+	res.Flush()
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, err := proxyRes.Body.Read(buf)
+		if n > 0 {
+			_, writeErr := res.Write(buf[:n])
+			if writeErr != nil {
+				d.Log.Debugw("client went away while streaming", "error", writeErr, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+				return nil
+			}
+			res.Flush()
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			// the client cancelled the request, there is nobody left to tell
+			if req.Context().Err() != nil {
+				d.Log.Debugw("request cancelled while streaming", "requestId", req.RequestID, "exhibitId", exhibit.Id)
+				return nil
+			}
+
+			d.Log.Warnw("error streaming body", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			return err
+		}
+	}
+}
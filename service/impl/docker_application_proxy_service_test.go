@@ -0,0 +1,168 @@
+package impl
+
+import (
+	"context"
+	"go.uber.org/zap"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"museum/http"
+	"net"
+	gohttp "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixedResolver resolves every exhibit to the same address
+type fixedResolver struct {
+	ip string
+}
+
+func (f fixedResolver) ResolveApplication(context.Context, string) (string, error) {
+	return f.ip, nil
+}
+
+func (f fixedResolver) ResolveExhibitObject(domain.Exhibit, domain.Object) (string, error) {
+	return f.ip, nil
+}
+
+// newProxyTest returns a proxy and an exhibit exposing the handler with a proxy timeout of 100ms
+func newProxyTest(t *testing.T, handler gohttp.HandlerFunc) (*DockerApplicationProxyService, domain.Exhibit) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := configimpl.EnvConfig{Hostname: "localhost", Port: "8080", RoutingMode: "path"}
+	proxy := &DockerApplicationProxyService{
+		Resolver:       fixedResolver{ip: host},
+		RewriteService: &RewriteServiceImpl{Config: config, Log: zap.NewNop().Sugar()},
+		Config:         config,
+		Log:            zap.NewNop().Sugar(),
+		ClientCache:    make(map[string]*ProxyClient),
+		ClientCacheMu:  &sync.RWMutex{},
+	}
+
+	exhibit := domain.Exhibit{
+		Id:      "1234",
+		Name:    "test",
+		Expose:  "app",
+		Objects: []domain.Object{{Name: "app", Port: &port}},
+		Proxy:   &domain.ProxyConfig{Timeout: "100ms"},
+	}
+
+	return proxy, exhibit
+}
+
+func forward(proxy *DockerApplicationProxyService, exhibit domain.Exhibit) (*httptest.ResponseRecorder, error) {
+	rec := httptest.NewRecorder()
+	req := &http.Request{Request: httptest.NewRequest(gohttp.MethodGet, "/exhibits/test/", nil)}
+	err := proxy.ForwardRequest(exhibit, "", &http.Response{ResponseWriter: rec}, req)
+	return rec, err
+}
+
+func TestProxyTimeoutWaitingForHeaders(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+
+	rec, err := forward(proxy, exhibit)
+	if err == nil || rec.Code != gohttp.StatusGatewayTimeout {
+		t.Errorf("Expected a gateway timeout, got %d (%v)", rec.Code, err)
+	}
+}
+
+func TestProxyTimeoutLeavesStreamedBodiesAlone(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(gohttp.StatusOK)
+		w.(gohttp.Flusher).Flush()
+
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("data: late\n\n"))
+	})
+
+	rec, err := forward(proxy, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != gohttp.StatusOK || rec.Body.String() != "data: late\n\n" {
+		t.Errorf("Expected the whole stream after the timeout, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestProxyRedirectsStayInsideTheExhibitWithoutRewrite(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		w.Header().Set("Location", "/login")
+		w.WriteHeader(gohttp.StatusFound)
+	})
+
+	rec, err := forward(proxy, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != gohttp.StatusFound || rec.Header().Get("Location") != "/exhibit/1234/login" {
+		t.Errorf("Expected a redirect to /exhibit/1234/login, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestProxyScopesCookiesWithoutRewrite(t *testing.T) {
+	var received string
+	proxy, exhibit := newProxyTest(t, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		received = r.Header.Get("Cookie")
+		w.Header().Add("Set-Cookie", "session=abc; Path=/")
+	})
+	exhibit.Id = "0b5e7a4c-1a1b-4c1d-9e1f-123456789abc"
+	exhibit.Proxy.NamespaceCookies = true
+
+	rec := httptest.NewRecorder()
+	req := &http.Request{Request: httptest.NewRequest(gohttp.MethodGet, "/exhibit/"+exhibit.Id+"/", nil)}
+	req.Header.Set("Cookie", exhibit.Id+"_session=abc; 1f7e9c2a-3b4d-4e5f-8a9b-0c1d2e3f4a5b_session=other")
+	err := proxy.ForwardRequest(exhibit, "", &http.Response{ResponseWriter: rec}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != "session=abc" {
+		t.Errorf("Expected only the cookie of the exhibit without its prefix, got %q", received)
+	}
+
+	if cookie := rec.Header().Get("Set-Cookie"); cookie != exhibit.Id+"_session=abc; Path=/exhibit/"+exhibit.Id+"/" {
+		t.Errorf("Expected the cookie to be namespaced and scoped to the exhibit, got %q", cookie)
+	}
+}
+
+func TestProxyEvictsClients(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, func(w gohttp.ResponseWriter, r *gohttp.Request) {})
+
+	_, err := forward(proxy, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := exhibit
+	other.Id = "5678"
+	_, err = forward(proxy, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy.PruneClients([]domain.Exhibit{exhibit})
+	if _, ok := proxy.ClientCache[other.Id]; ok || len(proxy.ClientCache) != 1 {
+		t.Errorf("Expected the client of the deleted exhibit to be pruned, got %v", proxy.ClientCache)
+	}
+
+	proxy.EvictClient(exhibit.Id)
+	if len(proxy.ClientCache) != 0 {
+		t.Errorf("Expected the client to be evicted, got %v", proxy.ClientCache)
+	}
+}
@@ -0,0 +1,166 @@
+package impl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"museum/domain"
+	"museum/http"
+	"net"
+	gohttp "net/http"
+	"time"
+)
+
+// interval in which the last_accessed time of an exhibit is refreshed while an upgraded connection is open
+const upgradeKeepaliveInterval = 5 * time.Second
+
+func (d *DockerApplicationProxyService) forwardUpgrade(exhibit domain.Exhibit, host string, path string, res *http.Response, req *http.Request) error {
+	// rewrite request headers (e.g. Origin)
+	d.RewriteService.RewriteClientCookies(exhibit, host, req)
+	if exhibit.Rewrite != nil && *exhibit.Rewrite {
+		body := make([]byte, 0)
+		err := d.RewriteService.RewriteClientRequest(exhibit, host, req, &body)
+		if err != nil {
+			d.Log.Warnw("error rewriting upgrade request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			res.WriteHeader(gohttp.StatusInternalServerError)
+			return err
+		}
+	}
+
+	queryParams := ""
+	if req.RawQueryParams != "" {
+		queryParams = "?" + req.RawQueryParams
+	}
+
+	timeout, err := exhibit.Proxy.GetTimeout()
+	if err != nil {
+		d.Log.Warnw("invalid proxy timeout", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
+	}
+
+	upstream, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		d.Log.Warnw("error connecting to upstream", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusBadGateway)
+		return err
+	}
+	defer func(upstream net.Conn) {
+		_ = upstream.Close()
+	}(upstream)
+
+	proxyReq, err := gohttp.NewRequest(req.Method, "http://"+host+"/"+path+queryParams, nil)
+	if err != nil {
+		d.Log.Warnw("error creating upgrade request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
+	}
+
+	proxyReq.Header = req.Header
+	proxyReq.Host = req.Host
+
+	err = proxyReq.Write(upstream)
+	if err != nil {
+		d.Log.Warnw("error writing upgrade request", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusBadGateway)
+		return err
+	}
+
+	upstreamReader := bufio.NewReader(upstream)
+	proxyRes, err := gohttp.ReadResponse(upstreamReader, proxyReq)
+	if err != nil {
+		d.Log.Warnw("error reading upgrade response", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusBadGateway)
+		return err
+	}
+
+	// the application refused the upgrade, just pass on whatever it answered
+	if proxyRes.StatusCode != gohttp.StatusSwitchingProtocols {
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(proxyRes.Body)
+
+		for k, v := range proxyRes.Header {
+			res.Header()[k] = v
+		}
+		res.WriteHeader(proxyRes.StatusCode)
+
+		_, err = io.Copy(res, proxyRes.Body)
+		return err
+	}
+
+	client, clientBuf, err := res.Hijack()
+	if err != nil {
+		d.Log.Warnw("error hijacking connection", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		res.WriteHeader(gohttp.StatusInternalServerError)
+		return err
+	}
+	defer func(client net.Conn) {
+		_ = client.Close()
+	}(client)
+
+	// the status line and headers have to be written by hand, the connection doesn't belong to net/http anymore
+	_, err = fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", proxyRes.Status)
+	if err == nil {
+		err = proxyRes.Header.Write(clientBuf)
+	}
+	if err == nil {
+		_, err = clientBuf.WriteString("\r\n")
+	}
+	if err == nil {
+		err = clientBuf.Flush()
+	}
+	if err != nil {
+		d.Log.Warnw("error writing upgrade response", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+		return err
+	}
+
+	d.Log.Debugw("connection upgraded", "requestId", req.RequestID, "exhibitId", exhibit.Id, "upgrade", proxyRes.Header.Get("Upgrade"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go d.keepUpgradedExhibitAlive(ctx, exhibit, req)
+
+	// pipe bytes in both directions until one side hangs up
+	// buffered readers are used because both of them might already hold data that was sent right after the handshake
+	errChan := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(upstream, clientBuf.Reader)
+		errChan <- err
+	}()
+	go func() {
+		_, err := io.Copy(client, upstreamReader)
+		errChan <- err
+	}()
+
+	err = <-errChan
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		d.Log.Debugw("upgraded connection closed with error", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+	}
+
+	d.Log.Debugw("upgraded connection closed", "requestId", req.RequestID, "exhibitId", exhibit.Id)
+
+	return nil
+}
+
+// keepUpgradedExhibitAlive refreshes the last_accessed time of an exhibit for as long as the context is not done
+// so that the cleanup service doesn't stop an exhibit while a client is still connected
+func (d *DockerApplicationProxyService) keepUpgradedExhibitAlive(ctx context.Context, exhibit domain.Exhibit, req *http.Request) {
+	ticker := time.NewTicker(upgradeKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.LastAccessedService.SetLastAccessed(ctx, exhibit.Id, time.Now().Unix())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				d.Log.Warnw("error refreshing last accessed", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-units"
+	"museum/domain"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// applyDockerProfile sets the resource limits and security settings of an object on its container
+func applyDockerProfile(profile domain.Profile, containerConfig *container.Config, hostConfig *container.HostConfig) error {
+	r := profile.Resources
+
+	if r.Cpus != "" {
+		cpus, err := strconv.ParseFloat(r.Cpus, 64)
+		if err != nil {
+			return err
+		}
+		hostConfig.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	if r.Memory != "" {
+		memory, err := units.RAMInBytes(r.Memory)
+		if err != nil {
+			return err
+		}
+		hostConfig.Memory = memory
+	}
+
+	if r.Pids != 0 {
+		pids := r.Pids
+		hostConfig.PidsLimit = &pids
+	}
+
+	s := profile.Security
+
+	hostConfig.ReadonlyRootfs = s.ReadOnly != nil && *s.ReadOnly
+
+	if len(s.Tmpfs) != 0 {
+		hostConfig.Tmpfs = make(map[string]string)
+		for _, tmpfs := range s.Tmpfs {
+			path, options, _ := strings.Cut(tmpfs, ":")
+			hostConfig.Tmpfs[path] = options
+		}
+	}
+
+	hostConfig.CapDrop = strslice.StrSlice(s.CapDrop)
+	hostConfig.CapAdd = strslice.StrSlice(s.CapAdd)
+
+	if s.NoNewPrivileges != nil && *s.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+
+	switch s.Seccomp {
+	case "", domain.SeccompDefault:
+	case domain.SeccompUnconfined:
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp=unconfined")
+	default:
+		// the daemon takes the profile itself, not its path,
+		// paths other than the one of the default profile are relaxations the validation only lets through with security.relax
+		content, err := os.ReadFile(s.Seccomp)
+		if err != nil {
+			return err
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+string(content))
+	}
+
+	if s.AppArmor != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "apparmor="+s.AppArmor)
+	}
+
+	containerConfig.User = s.User
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/docker/docker/api/types/volume"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// dockerVolumeNameRegex matches the characters docker doesn't allow in volume names
+var dockerVolumeNameRegex = regexp.MustCompile("[^a-zA-Z0-9_.-]")
+
+// mountOptionRegex matches a single mount option, e.g. nolock or timeo=600
+var mountOptionRegex = regexp.MustCompile("^[a-zA-Z0-9_.:/-]+(=[a-zA-Z0-9_.:/-]+)?$")
+
+// dockerVolumeName is the name of the docker volume mounting a volume of an exhibit
+func dockerVolumeName(exhibitId string, volume string) string {
+	return "museum_" + exhibitId + "_" + dockerVolumeNameRegex.ReplaceAllString(volume, "-")
+}
+
+// provisionDockerVolume creates a docker volume with the local driver, which mounts a share with the given options
+// once a container using it starts. A volume left behind with other options is replaced
+func provisionDockerVolume(ctx context.Context, client *docker.Client, name string, labels map[string]string, options map[string]string) (string, error) {
+	if client == nil || name == "" {
+		return "", errors.New("docker volumes need a docker daemon and a volume name")
+	}
+
+	inspect, err := client.VolumeInspect(ctx, name)
+	if err == nil {
+		if inspect.Driver == "local" && maps.Equal(inspect.Options, options) {
+			return name, nil
+		}
+
+		err = client.VolumeRemove(ctx, name, false)
+		if errdefs.IsConflict(err) {
+			return "", errors.New("docker volume " + name + " has other options and is still used, clean up the exhibit first")
+		}
+	}
+
+	if err != nil && !docker.IsErrNotFound(err) {
+		return "", err
+	}
+
+	_, err = client.VolumeCreate(ctx, volume.CreateOptions{
+		Name:       name,
+		Driver:     "local",
+		DriverOpts: options,
+		Labels:     labels,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// deprovisionDockerVolume removes a docker volume, docker unmounts it as soon as no container using it runs.
+// Stopped containers keep the volume until they are removed as well, so it is removed on cleanup then
+func deprovisionDockerVolume(ctx context.Context, client *docker.Client, name string) error {
+	if client == nil || name == "" {
+		return nil
+	}
+
+	err := client.VolumeRemove(ctx, name, false)
+	if docker.IsErrNotFound(err) || errdefs.IsConflict(err) {
+		return nil
+	}
+
+	return err
+}
+
+// checkMountValue makes sure a value can't add options to the mount options, docker splits them by commas
+func checkMountValue(key string, value string) error {
+	if strings.ContainsAny(value, ",\n") {
+		return errors.New(key + " cannot contain commas")
+	}
+
+	return nil
+}
+
+// checkMountOptions checks additional mount options, which may not make the share writable
+func checkMountOptions(options string) error {
+	if options == "" {
+		return nil
+	}
+
+	for _, option := range strings.Split(options, ",") {
+		if !mountOptionRegex.MatchString(option) {
+			return errors.New("mount option " + option + " is not valid")
+		}
+
+		if option == "rw" {
+			return errors.New("shares are always mounted read only")
+		}
+	}
+
+	return nil
+}
+
+// mountOptions joins mount options, empty ones are left out
+func mountOptions(options ...string) string {
+	nonEmpty := make([]string, 0, len(options))
+	for _, option := range options {
+		if option != "" {
+			nonEmpty = append(nonEmpty, option)
+		}
+	}
+
+	return strings.Join(nonEmpty, ",")
+}
@@ -2,12 +2,12 @@ package impl
 
 import (
 	"museum/config"
+	proxymode "museum/config/proxy-mode"
 	"museum/domain"
-	"regexp"
 )
 
-var addressRegex = regexp.MustCompile(`\{\{ *@([\w-+_.]+) *}}`)
-var hostRegex = regexp.MustCompile(`\{\{ *host *}}`)
+var addressRegex = domain.ObjectAddressRegex
+var hostRegex = domain.HostRegex
 
 type EnvironmentTemplateResolverServiceImpl struct {
 	Config config.Config
@@ -25,7 +25,7 @@ func (s *EnvironmentTemplateResolverServiceImpl) FillEnvironmentTemplate(exhibit
 			if addressRegex.MatchString(v) {
 				matches := addressRegex.FindStringSubmatch(v)
 				if len(matches) == 2 {
-					v = addressRegex.ReplaceAllString(v, exhibit.Name+"_"+matches[1])
+					v = addressRegex.ReplaceAllString(v, s.objectAddress(exhibit, matches[1]))
 
 					/*if name, ok := (*templateContainer)[matches[1]]; ok {
 						v = addressRegex.ReplaceAllString(v, name)
@@ -39,7 +39,8 @@ func (s *EnvironmentTemplateResolverServiceImpl) FillEnvironmentTemplate(exhibit
 			if hostRegex.MatchString(v) {
 				matches := hostRegex.FindStringSubmatch(v)
 				if len(matches) == 1 {
-					v = hostRegex.ReplaceAllString(v, s.Config.GetHostname()+":"+s.Config.GetPort()+"/exhibit/"+exhibit.Id)
+					mode := s.Config.GetRoutingMode()
+					v = hostRegex.ReplaceAllString(v, mode.Host(exhibit.Name, s.Config.GetHostname()+":"+s.Config.GetPort())+mode.BasePath(exhibit.Id))
 				}
 			}
 
@@ -49,3 +50,12 @@ func (s *EnvironmentTemplateResolverServiceImpl) FillEnvironmentTemplate(exhibit
 
 	return nil, res
 }
+
+// objectAddress is the host name an object is reached at by the other objects of its exhibit
+func (s *EnvironmentTemplateResolverServiceImpl) objectAddress(exhibit *domain.Exhibit, object string) string {
+	if s.Config.GetProxyMode() == proxymode.ModeK8s {
+		return kubernetesObjectName(*exhibit, object)
+	}
+
+	return exhibit.Name + "_" + object
+}
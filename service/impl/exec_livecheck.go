@@ -20,33 +20,43 @@ func (e *ExecLivecheck) Check(ctx context.Context, exhibit domain.Exhibit, objec
 		command = "true"
 	}
 
-	exec, err := e.Client.ContainerExecCreate(ctx, objectContainerName, container.ExecOptions{
-		Cmd: []string{"sh", "-c", command},
-	})
+	exitCode, err := execInContainer(ctx, e.Client, objectContainerName, []string{"sh", "-c", command})
 	if err != nil {
 		return false, err
 	}
 
-	res, err := e.Client.ContainerExecAttach(ctx, exec.ID, container.ExecStartOptions{})
+	if exitCode != 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// execInContainer runs a command in a running container and returns its exit code
+func execInContainer(ctx context.Context, client *docker.Client, containerName string, cmd []string) (int, error) {
+	exec, err := client.ContainerExecCreate(ctx, containerName, container.ExecOptions{
+		Cmd: cmd,
+	})
 	if err != nil {
-		return false, err
+		return 0, err
+	}
+
+	res, err := client.ContainerExecAttach(ctx, exec.ID, container.ExecStartOptions{})
+	if err != nil {
+		return 0, err
 	}
 
 	_, err = io.ReadAll(res.Reader)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 
 	res.Close()
 
-	inspect, err := e.Client.ContainerExecInspect(ctx, exec.ID)
+	inspect, err := client.ContainerExecInspect(ctx, exec.ID)
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 
-	if inspect.ExitCode != 0 {
-		return true, nil
-	}
-
-	return false, nil
+	return inspect.ExitCode, nil
 }
@@ -2,12 +2,15 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"museum/config"
 	"museum/domain"
 	service "museum/service/interface"
+	"museum/util"
+	"os"
 	"time"
 )
 
@@ -15,6 +18,9 @@ type ExhibitCleanupServiceImpl struct {
 	ExhibitService                service.ExhibitService
 	LockService                   service.LockService
 	ApplicationProvisionerService service.ApplicationProvisionerService
+	ApplicationProxyService       service.ApplicationProxyService
+	RuntimeInfoService            service.RuntimeInfoService
+	VolumeProvisionerFactory      service.VolumeProvisionerFactoryService
 	Provider                      trace.TracerProvider
 	Log                           *zap.SugaredLogger
 	Config                        config.Config
@@ -75,6 +81,10 @@ func (e ExhibitCleanupServiceImpl) Cleanup() error {
 	span.AddEvent("getting all exhibits")
 
 	exhibits := e.ExhibitService.GetAllExhibits(ctx)
+
+	// exhibits deleted by another instance leave their pooled clients behind on this one
+	e.ApplicationProxyService.PruneClients(exhibits)
+
 	for i, exhibit := range exhibits {
 		span.AddEvent("checking exhibit " + exhibit.Id)
 		e.cleanupExhibit(exhibit, i, ctx)
@@ -84,3 +94,112 @@ func (e ExhibitCleanupServiceImpl) Cleanup() error {
 
 	return nil
 }
+
+// DeleteExhibit tears an exhibit down (containers, network and volumes) and removes it from the state afterwards
+// a starting exhibit is only deleted with force, which waits for the start to finish first
+func (e ExhibitCleanupServiceImpl) DeleteExhibit(ctx context.Context, exhibitId string, force bool) error {
+	subCtx, span := e.Provider.
+		Tracer("cleanup-service").
+		Start(ctx, "DeleteExhibit("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.Bool("force", force)))
+	defer span.End()
+
+	exhibit, err := e.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	status := exhibit.RuntimeInfo.Status
+	if status == domain.Starting && !force {
+		return domain.ErrExhibitStarting
+	}
+
+	if status == domain.Starting || status == domain.Stopping {
+		span.AddEvent("waiting for exhibit to settle")
+		status, err = e.settle(subCtx, exhibitId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if status == domain.Running {
+		span.AddEvent("stopping exhibit")
+		err = e.ApplicationProvisionerService.StopApplication(subCtx, exhibitId)
+		// someone else stopped it in the meantime, it is cleaned up all the same
+		if err != nil && !errors.Is(err, domain.ErrExhibitNotRunning) {
+			return err
+		}
+		status = domain.Stopped
+	}
+
+	if status == domain.Stopped {
+		span.AddEvent("cleaning up exhibit")
+		err = e.ApplicationProvisionerService.CleanupApplication(subCtx, exhibitId)
+		if err != nil {
+			return err
+		}
+	}
+
+	span.AddEvent("deprovisioning volumes")
+	err = deprovisionVolumes(subCtx, e.VolumeProvisionerFactory, exhibit)
+	if err != nil {
+		return err
+	}
+
+	// the snapshots belong to the exhibit, nothing can restore or pin them once it is gone
+	span.AddEvent("deleting snapshots")
+	snapshots, err := pathUnder(e.Config.GetSnapshotPath(), exhibitId)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(snapshots)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("deleting exhibit")
+	err = e.ExhibitService.DeleteExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	e.ApplicationProxyService.EvictClient(exhibitId)
+	return nil
+}
+
+// settle waits until no one is starting or stopping an exhibit anymore, by acquiring its runtime_info lock
+// an exhibit still starting afterwards was left behind by an instance that is gone, one still stopping is about to be stopped anyway,
+// so both are considered stopped (the cleanup removes their containers no matter if they run)
+func (e ExhibitCleanupServiceImpl) settle(ctx context.Context, exhibitId string) (status domain.Status, err error) {
+	lock := e.LockService.GetRwLock(ctx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return "", err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			err = e
+		}
+	}(lock)
+
+	// the lock isn't reentrant, so the runtime info is read without taking it again
+	runtimeInfo, err := e.RuntimeInfoService.GetRuntimeInfoInsideLock(ctx, exhibitId)
+	if err != nil {
+		return "", err
+	}
+
+	if runtimeInfo.Status != domain.Starting && runtimeInfo.Status != domain.Stopping {
+		return runtimeInfo.Status, nil
+	}
+
+	e.Log.Warnw("exhibit still in transition, considering it stopped", "exhibitId", exhibitId, "status", runtimeInfo.Status)
+	runtimeInfo.Status = domain.Stopped
+	err = e.RuntimeInfoService.SetRuntimeInfo(ctx, exhibitId, runtimeInfo)
+	if err != nil {
+		return "", err
+	}
+
+	return domain.Stopped, nil
+}
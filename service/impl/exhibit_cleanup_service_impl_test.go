@@ -0,0 +1,59 @@
+package impl
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func TestSettleConsidersTransitionsStopped(t *testing.T) {
+	tests := []struct {
+		current domain.Status
+		settled domain.Status
+	}{
+		{domain.Starting, domain.Stopped},
+		{domain.Stopping, domain.Stopped},
+		{domain.Running, domain.Running},
+		{domain.Stopped, domain.Stopped},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.current), func(t *testing.T) {
+			locks := &fakeLockService{}
+			runtimeInfo := &fakeRuntimeInfoService{locks: locks, info: domain.ExhibitRuntimeInfo{Status: test.current}}
+			cleanup := ExhibitCleanupServiceImpl{
+				LockService:        locks,
+				RuntimeInfoService: runtimeInfo,
+				Provider:           noop.NewTracerProvider(),
+				Log:                zap.NewNop().Sugar(),
+			}
+
+			done := make(chan struct{})
+			var status domain.Status
+			var err error
+			go func() {
+				status, err = cleanup.settle(context.Background(), "exhibit")
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("settle deadlocked on the runtime_info lock")
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != test.settled {
+				t.Errorf("expected %s, got %s", test.settled, status)
+			}
+			if runtimeInfo.info.Status != test.settled {
+				t.Errorf("expected stored status %s, got %s", test.settled, runtimeInfo.info.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"museum/domain"
+	"strconv"
+)
+
+// diffExhibits returns a unified diff between the yaml definitions of two revisions of an exhibit
+func diffExhibits(previous domain.Exhibit, previousRevision int, next domain.Exhibit, nextRevision int) (string, error) {
+	a, err := yaml.Marshal(redactExhibit(previous, next, redactedPrevious))
+	if err != nil {
+		return "", err
+	}
+
+	b, err := yaml.Marshal(redactExhibit(next, previous, redactedChanged))
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "revision " + strconv.Itoa(previousRevision),
+		ToFile:   "revision " + strconv.Itoa(nextRevision),
+		Context:  3,
+	})
+}
+
+const (
+	redactedUnchanged = "<unchanged>"
+	redactedPrevious  = "<redacted>"
+	redactedChanged   = "<changed>"
+)
+
+// secretDriverConfig are the keys of volume driver configs holding credentials
+var secretDriverConfig = []string{"password"}
+
+// redactExhibit replaces environment values and driver passwords with markers, they often contain secrets
+// which the api never returns. values equal to the ones in the other revision become <unchanged>, the others the given marker,
+// so a changed value still shows up in the diff without revealing anything about it
+func redactExhibit(exhibit domain.Exhibit, other domain.Exhibit, changed string) domain.Exhibit {
+	otherEnvironments := make(map[string]domain.StringMap, len(other.Objects))
+	for _, object := range other.Objects {
+		otherEnvironments[object.Name] = object.Environment
+	}
+
+	objects := make([]domain.Object, len(exhibit.Objects))
+	for i, object := range exhibit.Objects {
+		objects[i] = object
+		if object.Environment == nil {
+			continue
+		}
+
+		objects[i].Environment = make(domain.StringMap, len(object.Environment))
+		for k, v := range object.Environment {
+			objects[i].Environment[k] = redactValue(v, otherEnvironments[object.Name], k, changed)
+		}
+	}
+
+	exhibit.Objects = objects
+	if exhibit.Volumes == nil {
+		return exhibit
+	}
+
+	otherConfigs := make(map[string]domain.StringMap, len(other.Volumes))
+	for _, volume := range other.Volumes {
+		otherConfigs[volume.Name] = volume.Driver.Config
+	}
+
+	volumes := make([]domain.Volume, len(exhibit.Volumes))
+	for i, volume := range exhibit.Volumes {
+		volumes[i] = volume
+		volumes[i].Driver.Config = maps.Clone(volume.Driver.Config)
+		for _, key := range secretDriverConfig {
+			if v, ok := volume.Driver.Config[key]; ok {
+				volumes[i].Driver.Config[key] = redactValue(v, otherConfigs[volume.Name], key, changed)
+			}
+		}
+	}
+
+	exhibit.Volumes = volumes
+	return exhibit
+}
+
+func redactValue(value string, other domain.StringMap, key string, changed string) string {
+	if v, ok := other[key]; ok && v == value {
+		return redactedUnchanged
+	}
+
+	return changed
+}
@@ -0,0 +1,90 @@
+package impl
+
+import (
+	"museum/domain"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffExhibits(t *testing.T) {
+	previous := domain.Exhibit{Name: "test", Lease: "5m", Objects: []domain.Object{
+		{Name: "db", Image: "postgres", Environment: domain.StringMap{"POSTGRES_PASSWORD": "secret"}},
+	}}
+	next := domain.Exhibit{Name: "test", Lease: "10m", Objects: []domain.Object{
+		{Name: "db", Image: "postgres", Environment: domain.StringMap{"POSTGRES_PASSWORD": "other secret"}},
+	}}
+
+	diff, err := diffExhibits(previous, 1, next, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(diff, "-lease: 5m\n") || !strings.Contains(diff, "+lease: 10m\n") {
+		t.Errorf("Expected the lease change in the diff, got %s", diff)
+	}
+
+	if strings.Contains(diff, "secret") {
+		t.Errorf("Expected environment values to be redacted, got %s", diff)
+	}
+
+	if !regexp.MustCompile(`-\s+POSTGRES_PASSWORD: <redacted>\n\+\s+POSTGRES_PASSWORD: <changed>`).MatchString(diff) {
+		t.Errorf("Expected the changed environment value in the diff, got %s", diff)
+	}
+
+	if previous.Objects[0].Environment["POSTGRES_PASSWORD"] != "secret" {
+		t.Errorf("Expected the exhibit to be left untouched")
+	}
+}
+
+func TestDiffExhibitsRedactsDriverPasswords(t *testing.T) {
+	share := func(password string) domain.Exhibit {
+		return domain.Exhibit{Name: "test", Volumes: []domain.Volume{{Name: "data", Driver: domain.Driver{Type: "smb", Config: domain.StringMap{
+			"server": "files.example.org", "share": "data", "username": "museum", "password": password,
+		}}}}}
+	}
+
+	previous := share("secret")
+	diff, err := diffExhibits(previous, 1, share("other secret"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(diff, "secret") {
+		t.Errorf("Expected driver passwords to be redacted, got %s", diff)
+	}
+
+	if !regexp.MustCompile(`\+\s+password: <changed>`).MatchString(diff) || strings.Contains(diff, "+        username") {
+		t.Errorf("Expected only the changed password in the diff, got %s", diff)
+	}
+
+	if previous.Volumes[0].Driver.Config["password"] != "secret" {
+		t.Errorf("Expected the exhibit to be left untouched")
+	}
+}
+
+func TestDiffExhibitsDoesntRevealEqualValues(t *testing.T) {
+	exhibit := func(lease string, password string) domain.Exhibit {
+		return domain.Exhibit{Name: "test", Lease: lease, Objects: []domain.Object{
+			{Name: "db", Image: "postgres", Environment: domain.StringMap{"POSTGRES_PASSWORD": password, "POSTGRES_USER": "museum"}},
+		}}
+	}
+
+	diff, err := diffExhibits(exhibit("5m", "secret"), 1, exhibit("10m", "secret"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if regexp.MustCompile(`[-+]\s+POSTGRES_PASSWORD`).MatchString(diff) {
+		t.Errorf("Expected the unchanged password as context only, got %s", diff)
+	}
+
+	diff, err = diffExhibits(exhibit("5m", "museum"), 1, exhibit("5m", "other"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(diff, "<changed>") != 1 || strings.Contains(diff, "museum") {
+		t.Errorf("Expected a single marker without any trace of the values, got %s", diff)
+	}
+}
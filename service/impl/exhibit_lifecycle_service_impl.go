@@ -0,0 +1,138 @@
+package impl
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	service "museum/service/interface"
+	"time"
+)
+
+type ExhibitLifecycleServiceImpl struct {
+	ExhibitService                service.ExhibitService
+	ApplicationProvisionerService service.ApplicationProvisionerService
+	LastAccessedService           service.LastAccessedService
+	Provider                      trace.TracerProvider
+	Log                           *zap.SugaredLogger
+}
+
+// checkTransition returns why an exhibit can't leave its current status, wantRunning is the status the action needs
+func checkTransition(status domain.Status, wantRunning bool) error {
+	switch {
+	case status == domain.Starting:
+		return domain.ErrExhibitStarting
+	case status == domain.Stopping:
+		return domain.ErrExhibitStopping
+	case wantRunning && status != domain.Running:
+		return domain.ErrExhibitNotRunning
+	case !wantRunning && status == domain.Running:
+		return domain.ErrExhibitRunning
+	}
+
+	return nil
+}
+
+// StartExhibit starts a stopped exhibit in the background, the client can follow it on /api/exhibits/{id}/status
+func (e ExhibitLifecycleServiceImpl) StartExhibit(ctx context.Context, exhibitId string) error {
+	subCtx, span := e.Provider.
+		Tracer("lifecycle-service").
+		Start(ctx, "StartExhibit("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	exhibit, err := e.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	err = checkTransition(exhibit.RuntimeInfo.Status, false)
+	if err != nil {
+		return err
+	}
+
+	e.start(subCtx, exhibitId)
+	return nil
+}
+
+// StopExhibit stops a running exhibit, its containers are kept until the cleanup removes them
+func (e ExhibitLifecycleServiceImpl) StopExhibit(ctx context.Context, exhibitId string) error {
+	subCtx, span := e.Provider.
+		Tracer("lifecycle-service").
+		Start(ctx, "StopExhibit("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	exhibit, err := e.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	err = checkTransition(exhibit.RuntimeInfo.Status, true)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("stopping exhibit")
+	return e.ApplicationProvisionerService.StopApplication(subCtx, exhibitId)
+}
+
+// RestartExhibit stops a running exhibit and starts it again in the background
+func (e ExhibitLifecycleServiceImpl) RestartExhibit(ctx context.Context, exhibitId string) error {
+	subCtx, span := e.Provider.
+		Tracer("lifecycle-service").
+		Start(ctx, "RestartExhibit("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	err := e.StopExhibit(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	e.start(subCtx, exhibitId)
+	return nil
+}
+
+// RenewExhibit renews the lease of a running exhibit, as if it had just been accessed
+func (e ExhibitLifecycleServiceImpl) RenewExhibit(ctx context.Context, exhibitId string) (domain.Exhibit, error) {
+	subCtx, span := e.Provider.
+		Tracer("lifecycle-service").
+		Start(ctx, "RenewExhibit("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	exhibit, err := e.ExhibitService.GetExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	// a stopped exhibit has no lease, it is started again by the next request anyway
+	err = checkTransition(exhibit.RuntimeInfo.Status, true)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	lastAccessed := time.Now().Unix()
+	err = e.LastAccessedService.SetLastAccessed(subCtx, exhibitId, lastAccessed)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	e.Log.Infow("renewed lease", "exhibitId", exhibitId, "lease", exhibit.Lease)
+	exhibit.RuntimeInfo.LastAccessed = lastAccessed
+
+	return exhibit, nil
+}
+
+// start starts an exhibit without waiting for it, starting outlives the request that triggered it
+func (e ExhibitLifecycleServiceImpl) start(ctx context.Context, exhibitId string) {
+	trace.SpanFromContext(ctx).AddEvent("starting exhibit")
+
+	go func() {
+		err := e.ApplicationProvisionerService.StartApplication(context.WithoutCancel(ctx), exhibitId)
+		if err != nil {
+			e.Log.Warnw("error starting exhibit", "error", err, "exhibitId", exhibitId)
+			return
+		}
+
+		e.Log.Infow("exhibit started", "exhibitId", exhibitId)
+	}()
+}
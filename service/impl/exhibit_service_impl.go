@@ -3,6 +3,7 @@ package impl
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/docker/docker/api/types/image"
 	docker "github.com/docker/docker/client"
 	"github.com/google/uuid"
@@ -10,11 +11,13 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"io"
+	"museum/config"
+	routingmode "museum/config/routing-mode"
 	"museum/domain"
 	"museum/persistence"
 	service "museum/service/interface"
 	"museum/util"
-	"strconv"
+	"museum/validation"
 	"time"
 )
 
@@ -27,6 +30,7 @@ type ExhibitServiceImpl struct {
 	Log                      *zap.SugaredLogger
 	DockerClient             *docker.Client
 	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	Config                   config.Config
 }
 
 func (e ExhibitServiceImpl) GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
@@ -73,6 +77,15 @@ func (e ExhibitServiceImpl) GetExhibitById(ctx context.Context, id string) (doma
 	return exhibit, nil
 }
 
+func (e ExhibitServiceImpl) GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error) {
+	exhibit, err := e.State.GetExhibitByName(ctx, name)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	return e.GetExhibitById(ctx, exhibit.Id)
+}
+
 func (e ExhibitServiceImpl) hydrateExhibit(ctx context.Context, id string, exhibit *domain.Exhibit) error {
 	subCtx, span := e.Provider.
 		Tracer("exhibit-service").
@@ -128,27 +141,82 @@ func (e ExhibitServiceImpl) GetAllExhibits(ctx context.Context) []domain.Exhibit
 	return exhibits
 }
 
+// DeleteExhibitById removes all keys of an exhibit from the state, the exhibit has to be torn down already
 func (e ExhibitServiceImpl) DeleteExhibitById(ctx context.Context, id string) error {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "DeleteExhibitById("+id+")", trace.WithAttributes(attribute.String("exhibitId", id)))
+	defer span.End()
+
+	// the global lock keeps everyone from acquiring the exhibit lock again, until its key is gone
+	globalLock := e.LockService.GetRwLock(subCtx, "all", "exhibits")
+	err := globalLock.Lock()
+	if err != nil {
+		e.Log.Errorw("error locking global lock", "error", err)
+		return err
+	}
+
+	defer func(globalLock util.RwErrMutex) {
+		err := globalLock.Unlock()
+		if err != nil {
+			e.Log.Errorw("error unlocking global lock", "error", err)
+		}
+	}(globalLock)
+
+	exhibit, err := e.deleteExhibitInsideLock(subCtx, id)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("deleting locks")
+	err = e.State.DeleteLocks(subCtx, id)
+	if err != nil {
+		e.Log.Warnw("error deleting locks", "error", err, "exhibitId", id)
+	}
+
+	e.Eventing.DispatchExhibitDeletedEvent(subCtx, exhibit)
+	e.Log.Infow("deleted exhibit", "exhibitId", id)
+
+	return nil
+}
+
+func (e ExhibitServiceImpl) deleteExhibitInsideLock(ctx context.Context, id string) (domain.Exhibit, error) {
 	lock := e.LockService.GetRwLock(ctx, id, "exhibit")
 	err := lock.Lock()
 	if err != nil {
 		e.Log.Errorw("error locking exhibit lock", "error", err, "exhibitId", id)
-		return err
+		return domain.Exhibit{}, err
 	}
 
-	//TODO: span
-
-	defer func() {
+	defer func(lock util.RwErrMutex) {
 		err := lock.Unlock()
 		if err != nil {
 			e.Log.Errorw("error unlocking exhibit lock", "error", err, "exhibitId", id)
 		}
-		// TODO: delete lock
-	}()
+	}(lock)
 
-	//stop exhibit if running
+	exhibit, err := e.State.GetExhibitById(ctx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
 
-	return e.State.DeleteExhibitById(ctx, id)
+	// the meta key goes last, as long as it exists the exhibit can be deleted again
+	err = e.State.DeleteLastAccessed(ctx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	err = e.State.DeleteRuntimeInfo(ctx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	err = e.State.DeleteExhibitById(ctx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	return exhibit, nil
 }
 
 func (e ExhibitServiceImpl) CreateExhibit(ctx context.Context, createExhibitRequest domain.CreateExhibit) (string, error) {
@@ -176,199 +244,355 @@ func (e ExhibitServiceImpl) CreateExhibit(ctx context.Context, createExhibitRequ
 		}
 	}(globalLock)
 
-	// check that exhibit name is unique
-	exhibits := e.State.GetAllExhibits(subCtx)
-	for _, e := range exhibits {
-		if e.Name == createExhibitRequest.Exhibit.Name {
-			return "", errors.New("exhibit with name " + createExhibitRequest.Exhibit.Name + " already exists")
-		}
+	err = e.ValidateExhibit(subCtx, &createExhibitRequest.Exhibit)
+	if err != nil {
+		return "", err
+	}
+
+	// give exhibit a unique id
+	createExhibitRequest.Exhibit.Id = uuid.New().String()
+	createExhibitRequest.Exhibit.Revision = 1
+
+	// set runtime state
+	createExhibitRequest.Exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{
+		Status:            domain.NotCreated,
+		RelatedContainers: []string{},
 	}
 
-	// check that a container is exposed
-	if createExhibitRequest.Exhibit.Expose == "" {
-		return "", errors.New("exhibit must expose a container")
+	//---------------------------------------------------
+
+	err = e.pullImages(subCtx, createExhibitRequest.Exhibit)
+	if err != nil {
+		return "", err
 	}
 
-	// check that exposed container has an exposed port
-	{
-		found := false
-		for i, c := range createExhibitRequest.Exhibit.Objects {
-			if c.Name == createExhibitRequest.Exhibit.Expose {
-				if c.Port == nil || *c.Port == "" {
-					createExhibitRequest.Exhibit.Objects[i].Port = new(string)
-					*createExhibitRequest.Exhibit.Objects[i].Port = "80"
-				}
-				found = true
-			}
-		}
+	err = e.State.SetLastAccessed(subCtx, createExhibitRequest.Exhibit.Id, time.Now().Unix())
+	if err != nil {
+		return "", err
+	}
 
-		if !found {
-			return "", errors.New("exhibit must expose a container that is part of the exhibit")
-		}
+	err = e.State.SetRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id, *createExhibitRequest.Exhibit.RuntimeInfo)
+	if err != nil {
+		e.Log.Errorw("error setting runtime info, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
+		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
+		return "", err
 	}
 
-	//---------------------------------------------------
+	err = e.State.CreateExhibit(subCtx, domain.ExhibitRevision{
+		Revision:  createExhibitRequest.Exhibit.Revision,
+		Author:    createExhibitRequest.Author,
+		Timestamp: time.Now().Unix(),
+		Exhibit:   createExhibitRequest.Exhibit,
+	})
+	if err != nil {
+		e.Log.Errorw("error creating exhibit, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
+		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
+		err = e.State.DeleteRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id)
+		return "", err
+	}
 
-	// validate mount paths
-	mounts := make([]string, 0)
-	for _, c := range createExhibitRequest.Exhibit.Objects {
-		if len(c.Mounts) != 0 {
-			for mount := range c.Mounts {
-				mounts = append(mounts, mount)
-			}
-		}
+	e.Eventing.DispatchExhibitCreatedEvent(subCtx, createExhibitRequest.Exhibit)
+	e.Log.Debugw("created new exhibit", "exhibitId", createExhibitRequest.Exhibit.Id)
+
+	return createExhibitRequest.Exhibit.Id, nil
+}
+
+// UpdateExhibit replaces the definition of an exhibit with a new revision, keeping its id
+// it doesn't care about the runtime state, running exhibits have to be redeployed by the caller
+func (e ExhibitServiceImpl) UpdateExhibit(ctx context.Context, updateExhibitRequest domain.UpdateExhibit) (domain.Exhibit, error) {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "UpdateExhibit("+updateExhibitRequest.Exhibit.Id+")", trace.WithAttributes(attribute.String("exhibitId", updateExhibitRequest.Exhibit.Id)))
+	defer span.End()
+
+	id := updateExhibitRequest.Exhibit.Id
+	e.Log.Infow("updating exhibit", "exhibitId", id)
+
+	// the global lock keeps the name unique, nobody can create an exhibit with the same name in the meantime
+	globalLock := e.LockService.GetRwLock(subCtx, "all", "exhibits")
+	err := globalLock.Lock()
+	if err != nil {
+		e.Log.Errorw("error locking global lock", "error", err)
+		return domain.Exhibit{}, err
 	}
 
-	// check that volumes have required mounts
-	for _, m := range mounts {
-		found := false
-		for _, v := range createExhibitRequest.Exhibit.Volumes {
-			if v.Name == m {
-				found = true
-			}
+	defer func(globalLock util.RwErrMutex) {
+		err := globalLock.Unlock()
+		if err != nil {
+			e.Log.Errorw("error unlocking global lock", "error", err)
 		}
+	}(globalLock)
 
-		if !found {
-			return "", errors.New("mount " + m + " does not have a corresponding volume")
-		}
+	lock := e.LockService.GetRwLock(subCtx, id, "exhibit")
+	err = lock.Lock()
+	if err != nil {
+		e.Log.Errorw("error locking exhibit lock", "error", err, "exhibitId", id)
+		return domain.Exhibit{}, err
 	}
 
-	for _, v := range createExhibitRequest.Exhibit.Volumes {
-		vp, err := e.VolumeProvisionerFactory.GetForDriverType(v.Driver.Type)
+	defer func(lock util.RwErrMutex) {
+		err := lock.Unlock()
 		if err != nil {
-			return "", err
+			e.Log.Errorw("error unlocking exhibit lock", "error", err, "exhibitId", id)
 		}
+	}(lock)
+
+	current, err := e.State.GetExhibitById(subCtx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	exhibit := updateExhibitRequest.Exhibit
+	err = e.ValidateExhibit(subCtx, &exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
 
-		err = vp.CheckValidity(v.Driver.Config)
+	// exhibits created before revisions were introduced have none stored, their definition becomes revision 1
+	if current.Revision == 0 {
+		span.AddEvent("storing initial revision")
+		current.Revision = 1
+		err = e.State.UpdateExhibit(subCtx, domain.ExhibitRevision{
+			Revision:  current.Revision,
+			Author:    "unknown",
+			Timestamp: time.Now().Unix(),
+			Comment:   "created before revisions were recorded",
+			Exhibit:   current,
+		})
 		if err != nil {
-			return "", err
+			return domain.Exhibit{}, err
 		}
 	}
 
-	//---------------------------------------------------
+	exhibit.Revision = current.Revision + 1
 
-	// validate lease time
-	_, err = time.ParseDuration(createExhibitRequest.Exhibit.Lease)
+	diff, err := diffExhibits(current, current.Revision, exhibit, exhibit.Revision)
 	if err != nil {
-		return "", errors.New("lease time must be a valid duration")
+		return domain.Exhibit{}, err
 	}
 
-	// validate livechecks
-	for _, object := range createExhibitRequest.Exhibit.Objects {
-		l := object.Livecheck
-		if l == nil {
-			continue
-		}
+	err = e.pullImages(subCtx, exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	span.AddEvent("storing revision")
+	err = e.storeStoppedRevision(subCtx, domain.ExhibitRevision{
+		Revision:  exhibit.Revision,
+		Author:    updateExhibitRequest.Author,
+		Timestamp: time.Now().Unix(),
+		Comment:   updateExhibitRequest.Comment,
+		Diff:      diff,
+		Exhibit:   exhibit,
+	})
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	e.Log.Infow("updated exhibit", "exhibitId", id, "revision", exhibit.Revision)
+
+	err = e.hydrateExhibit(subCtx, id, &exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	return exhibit, nil
+}
+
+// storeStoppedRevision stores a revision of an exhibit that isn't running, the runtime_info lock is held from the check
+// through the write, so nobody can start the old revision in the meantime and end up running under the new one.
+// it is taken after the exhibit lock, in the same order as everywhere else
+func (e ExhibitServiceImpl) storeStoppedRevision(ctx context.Context, revision domain.ExhibitRevision) (err error) {
+	id := revision.Exhibit.Id
+
+	lock := e.LockService.GetRwLock(ctx, id, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
 
-		// validate livecheck type
-		if l.Type != domain.LivecheckTypeHttp && l.Type != domain.LivecheckTypeExec {
-			return "", errors.New("livecheck type must be one of: " + domain.LivecheckTypeHttp + ", " + domain.LivecheckTypeExec + " (in object " + object.Name + ")")
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			err = e
 		}
+	}(lock)
+
+	runtimeInfo, err := e.RuntimeInfoService.GetRuntimeInfoInsideLock(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch runtimeInfo.Status {
+	case domain.Stopped, domain.NotCreated:
+	case domain.Running:
+		return domain.ErrExhibitRunning
+	case domain.Starting:
+		return domain.ErrExhibitStarting
+	case domain.Stopping:
+		return domain.ErrExhibitStopping
+	default:
+		return errors.New(string("cannot update exhibit in state " + runtimeInfo.Status))
+	}
+
+	return e.State.UpdateExhibit(ctx, revision)
+}
+
+// GetExhibitRevisions returns the revision history of an exhibit, oldest first
+func (e ExhibitServiceImpl) GetExhibitRevisions(ctx context.Context, id string) ([]domain.ExhibitRevision, error) {
+	// revisions are immutable, only the exhibit itself has to exist
+	_, err := e.State.GetExhibitById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-		// check http livecheck
-		if l.Type == domain.LivecheckTypeHttp {
-			// check valid http method
-			method, ok := l.Config["method"]
-			if ok {
-				if method != "GET" && method != "POST" && method != "PUT" && method != "DELETE" {
-					return "", errors.New("http livecheck method must be one of: GET, POST, PUT, DELETE (in object " + object.Name + ")")
+	return e.State.GetExhibitRevisions(ctx, id)
+}
+
+func (e ExhibitServiceImpl) GetExhibitRevision(ctx context.Context, id string, revision int) (domain.ExhibitRevision, error) {
+	return e.State.GetExhibitRevision(ctx, id, revision)
+}
+
+func (e ExhibitServiceImpl) Count() int {
+	return len(e.State.GetAllExhibits(context.Background()))
+}
+
+// ValidateExhibit checks an exhibit definition and fills in defaults (e.g. the port of the exposed container),
+// without creating or updating anything
+func (e ExhibitServiceImpl) ValidateExhibit(ctx context.Context, exhibit *domain.Exhibit) error {
+	problems := e.validator(ctx).Validate(*exhibit)
+	if problems.HasErrors() {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidExhibit, problems)
+	}
+
+	fillDefaults(exhibit)
+	return nil
+}
+
+// PrepareExhibit validates an exhibit definition and pulls its images, everything that can fail
+// before a running exhibit is stopped to be updated
+func (e ExhibitServiceImpl) PrepareExhibit(ctx context.Context, exhibit *domain.Exhibit) error {
+	err := e.ValidateExhibit(ctx, exhibit)
+	if err != nil {
+		return err
+	}
+
+	return e.pullImages(ctx, *exhibit)
+}
+
+// LintExhibit reads an exhibit definition from yaml or json and returns all of its problems, with their line numbers
+func (e ExhibitServiceImpl) LintExhibit(ctx context.Context, content []byte) (domain.Exhibit, validation.Problems) {
+	return validation.Lint(content, e.validator(ctx))
+}
+
+// validator checks exhibits against the state and config of this instance
+func (e ExhibitServiceImpl) validator(ctx context.Context) validation.Validator {
+	exhibits := e.State.GetAllExhibits(ctx)
+	profile := e.Config.GetDefaultProfile()
+
+	return validation.Validator{
+		Profile:     &profile,
+		HostRouting: e.Config.GetRoutingMode() == routingmode.ModeHost,
+		NameTaken: func(name string, id string) bool {
+			for _, other := range exhibits {
+				if other.Name == name && other.Id != id {
+					return true
 				}
 			}
 
-			// check valid http status
-			status, ok := l.Config["status"]
-			if ok {
-				_, err := strconv.Atoi(status)
-				if err != nil {
-					return "", errors.New("http livecheck status must be a valid integer (in object " + object.Name + ")")
-				}
+			return false
+		},
+		CheckVolume: func(volume domain.Volume) error {
+			vp, err := e.VolumeProvisionerFactory.GetForVolume("", volume)
+			if err != nil {
+				return err
 			}
 
-			// check valid port
-			port, ok := l.Config["port"]
-			if ok {
-				_, err := strconv.Atoi(port)
-				if err != nil {
-					return "", errors.New("http livecheck port must be a valid integer (in object " + object.Name + ")")
-				}
+			return vp.CheckValidity(volume.Driver.Config)
+		},
+		CheckSnapshot: func(exhibitId string, volume domain.Volume) error {
+			// the snapshots of an exhibit are taken once it exists
+			if exhibitId == "" {
+				return fmt.Errorf("%w: %s, a new exhibit has no snapshots yet", domain.ErrSnapshotNotFound, volume.Snapshot)
 			}
-		}
-	}
 
-	//---------------------------------------------------
+			vp, err := e.VolumeProvisionerFactory.GetForVolume(exhibitId, domain.Volume{Name: volume.Name, Driver: volume.Driver})
+			if err != nil {
+				return err
+			}
 
-	// give exhibit a unique id
-	createExhibitRequest.Exhibit.Id = uuid.New().String()
+			snapshotter, ok := vp.(service.VolumeSnapshotter)
+			if !ok {
+				return fmt.Errorf("%w: %s", domain.ErrSnapshotsNotSupported, volume.Driver.Type)
+			}
 
-	// set runtime state
-	createExhibitRequest.Exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{
-		Status:            domain.NotCreated,
-		RelatedContainers: []string{},
+			snapshots, err := snapshotter.GetSnapshots(ctx, volume.Driver.Config)
+			if err != nil {
+				return err
+			}
+
+			for _, snapshot := range snapshots {
+				if snapshot.Name == volume.Snapshot {
+					return nil
+				}
+			}
+
+			return fmt.Errorf("%w: %s", domain.ErrSnapshotNotFound, volume.Snapshot)
+		},
 	}
+}
 
-	//---------------------------------------------------
+// fillDefaults sets the defaults of a valid exhibit
+func fillDefaults(exhibit *domain.Exhibit) {
+	// the exposed container listens on port 80 unless told otherwise
+	for i, c := range exhibit.Objects {
+		if c.Name == exhibit.Expose && (c.Port == nil || *c.Port == "") {
+			exhibit.Objects[i].Port = new(string)
+			*exhibit.Objects[i].Port = "80"
+		}
+	}
+}
 
-	e.Log.Infow("pulling images", "exhibitId", createExhibitRequest.Exhibit.Id)
-	for _, object := range createExhibitRequest.Exhibit.Objects {
-		e.Log.Debugw("pulling image", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id)
+func (e ExhibitServiceImpl) pullImages(ctx context.Context, exhibit domain.Exhibit) error {
+	// without a docker daemon (e.g. on kubernetes) the images are pulled when the objects are started
+	if e.DockerClient == nil {
+		return nil
+	}
+
+	e.Log.Infow("pulling images", "exhibitId", exhibit.Id)
+	for _, object := range exhibit.Objects {
+		e.Log.Debugw("pulling image", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id)
 
-		inspect, _, err := e.DockerClient.ImageInspectWithRaw(subCtx, object.Image+":"+object.Label)
+		inspect, _, err := e.DockerClient.ImageInspectWithRaw(ctx, object.Image+":"+object.Label)
 		if err != nil && !docker.IsErrNotFound(err) {
-			e.Log.Errorw("error inspecting image", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			e.Log.Errorw("error inspecting image", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		if inspect.ID != "" {
-			e.Log.Debugw("image already pulled", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id)
+			e.Log.Debugw("image already pulled", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id)
 			continue
 		}
 
 		containerImage := object.Image + ":" + object.Label
 		pull, err := e.DockerClient.ImagePull(ctx, containerImage, image.PullOptions{})
 		if err != nil {
-			e.Log.Errorw("error pulling image", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			e.Log.Errorw("error pulling image", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		_, err = io.ReadAll(pull)
 		if err != nil {
-			e.Log.Errorw("error reading pull response", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			e.Log.Errorw("error reading pull response", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		err = pull.Close()
 		if err != nil {
-			e.Log.Errorw("error closing pull response", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			e.Log.Errorw("error closing pull response", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 	}
 
-	err = e.State.SetLastAccessed(subCtx, createExhibitRequest.Exhibit.Id, time.Now().Unix())
-	if err != nil {
-		return "", err
-	}
-
-	err = e.State.SetRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id, *createExhibitRequest.Exhibit.RuntimeInfo)
-	if err != nil {
-		e.Log.Errorw("error setting runtime info, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
-		return "", err
-	}
-
-	err = e.State.CreateExhibit(subCtx, createExhibitRequest.Exhibit)
-	if err != nil {
-		e.Log.Errorw("error creating exhibit, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id)
-		return "", err
-	}
-
-	e.Eventing.DispatchExhibitCreatedEvent(subCtx, createExhibitRequest.Exhibit)
-	e.Log.Debugw("created new exhibit", "exhibitId", createExhibitRequest.Exhibit.Id)
-
-	return createExhibitRequest.Exhibit.Id, nil
-}
-
-func (e ExhibitServiceImpl) Count() int {
-	return len(e.State.GetAllExhibits(context.Background()))
+	return nil
 }
@@ -0,0 +1,127 @@
+package impl
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	service "museum/service/interface"
+	"museum/spec"
+	"strconv"
+)
+
+type ExhibitUpdateServiceImpl struct {
+	ExhibitService                service.ExhibitService
+	ApplicationProvisionerService service.ApplicationProvisionerService
+	Provider                      trace.TracerProvider
+	Log                           *zap.SugaredLogger
+}
+
+// UpdateExhibit stores a new revision of an exhibit, a running exhibit is torn down and started again on the new revision.
+// the status is checked again when the revision is stored, an exhibit started in between makes the update fail
+func (e ExhibitUpdateServiceImpl) UpdateExhibit(ctx context.Context, updateExhibitRequest domain.UpdateExhibit) (domain.Exhibit, error) {
+	id := updateExhibitRequest.Exhibit.Id
+
+	subCtx, span := e.Provider.
+		Tracer("update-service").
+		Start(ctx, "UpdateExhibit("+id+")", trace.WithAttributes(attribute.String("exhibitId", id), attribute.String("requestId", updateExhibitRequest.RequestID)))
+	defer span.End()
+
+	current, err := e.ExhibitService.GetExhibitById(subCtx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	status := current.RuntimeInfo.Status
+	switch status {
+	case domain.Starting:
+		return domain.Exhibit{}, domain.ErrExhibitStarting
+	case domain.Stopping:
+		return domain.Exhibit{}, domain.ErrExhibitStopping
+	}
+
+	// a broken definition or an image that can't be pulled must not take a running exhibit down
+	span.AddEvent("preparing exhibit")
+	exhibit := updateExhibitRequest.Exhibit
+	err = e.ExhibitService.PrepareExhibit(subCtx, &exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	running := status == domain.Running
+	if running {
+		span.AddEvent("stopping exhibit")
+		err = e.ApplicationProvisionerService.StopApplication(subCtx, id)
+		if err != nil {
+			return domain.Exhibit{}, err
+		}
+	}
+
+	// the containers and the network belong to the old revision (e.g. they are named after it)
+	if running || status == domain.Stopped {
+		span.AddEvent("cleaning up exhibit")
+		err = e.ApplicationProvisionerService.CleanupApplication(subCtx, id)
+		if err != nil {
+			return domain.Exhibit{}, err
+		}
+	}
+
+	span.AddEvent("updating exhibit")
+	updated, err := e.ExhibitService.UpdateExhibit(subCtx, updateExhibitRequest)
+	if err != nil {
+		if running {
+			// it is only running if someone started it again in the meantime, on its old revision
+			e.Log.Warnw("error updating exhibit, it isn't started again", "error", err, "exhibitId", id)
+		}
+		return domain.Exhibit{}, err
+	}
+
+	if !running {
+		return updated, nil
+	}
+
+	// starting takes a while, the client can follow it on /api/exhibits/{id}/status
+	span.AddEvent("starting exhibit")
+	go func() {
+		err := e.ApplicationProvisionerService.StartApplication(context.WithoutCancel(subCtx), id)
+		if err != nil {
+			e.Log.Warnw("error starting updated exhibit", "error", err, "exhibitId", id, "revision", updated.Revision)
+			return
+		}
+
+		e.Log.Infow("updated exhibit started", "exhibitId", id, "revision", updated.Revision)
+	}()
+
+	return updated, nil
+}
+
+// RollbackExhibit stores the definition of an old revision as a new revision, the history itself is never rewritten
+func (e ExhibitUpdateServiceImpl) RollbackExhibit(ctx context.Context, rollbackExhibitRequest domain.RollbackExhibit) (domain.Exhibit, error) {
+	id := rollbackExhibitRequest.ExhibitId
+
+	subCtx, span := e.Provider.
+		Tracer("update-service").
+		Start(ctx, "RollbackExhibit("+id+")", trace.WithAttributes(attribute.String("exhibitId", id), attribute.Int("revision", rollbackExhibitRequest.Revision), attribute.String("requestId", rollbackExhibitRequest.RequestID)))
+	defer span.End()
+
+	revision, err := e.ExhibitService.GetExhibitRevision(subCtx, id, rollbackExhibitRequest.Revision)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	e.Log.Infow("rolling back exhibit", "exhibitId", id, "revision", revision.Revision)
+
+	// revisions stored before the spec version was recorded are all v1
+	exhibit := revision.Exhibit
+	if exhibit.Spec == "" {
+		exhibit.Spec = spec.V1
+	}
+
+	return e.UpdateExhibit(subCtx, domain.UpdateExhibit{
+		Exhibit:   exhibit,
+		RequestID: rollbackExhibitRequest.RequestID,
+		Author:    rollbackExhibitRequest.Author,
+		Comment:   "rollback to revision " + strconv.Itoa(revision.Revision),
+	})
+}
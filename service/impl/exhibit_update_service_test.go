@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"sync"
+	"testing"
+)
+
+// fakeUpdateExhibitService knows a single exhibit, preparing it fails with prepareErr
+type fakeUpdateExhibitService struct {
+	service.ExhibitService
+	exhibit    domain.Exhibit
+	prepareErr error
+	updated    bool
+}
+
+func (f *fakeUpdateExhibitService) GetExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	if id != f.exhibit.Id {
+		return domain.Exhibit{}, errors.New("exhibit not found")
+	}
+	return f.exhibit, nil
+}
+
+func (f *fakeUpdateExhibitService) PrepareExhibit(_ context.Context, _ *domain.Exhibit) error {
+	return f.prepareErr
+}
+
+func (f *fakeUpdateExhibitService) UpdateExhibit(_ context.Context, update domain.UpdateExhibit) (domain.Exhibit, error) {
+	f.updated = true
+	update.Exhibit.Revision = f.exhibit.Revision + 1
+	return update.Exhibit, nil
+}
+
+// recordingProvisioner records the lifecycle calls, starting is done in the background by the update
+type recordingProvisioner struct {
+	mu      sync.Mutex
+	calls   []string
+	started chan struct{}
+}
+
+func (r *recordingProvisioner) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recordingProvisioner) StartApplication(_ context.Context, _ string) error {
+	r.record("start")
+	close(r.started)
+	return nil
+}
+
+func (r *recordingProvisioner) StopApplication(_ context.Context, _ string) error {
+	r.record("stop")
+	return nil
+}
+
+func (r *recordingProvisioner) CleanupApplication(_ context.Context, _ string) error {
+	r.record("cleanup")
+	return nil
+}
+
+func newUpdateService(status domain.Status, prepareErr error) (ExhibitUpdateServiceImpl, *fakeUpdateExhibitService, *recordingProvisioner) {
+	exhibits := &fakeUpdateExhibitService{
+		exhibit: domain.Exhibit{
+			Id:          "1234",
+			Name:        "test",
+			Revision:    1,
+			RuntimeInfo: &domain.ExhibitRuntimeInfo{Status: status},
+		},
+		prepareErr: prepareErr,
+	}
+	provisioner := &recordingProvisioner{started: make(chan struct{})}
+
+	return ExhibitUpdateServiceImpl{
+		ExhibitService:                exhibits,
+		ApplicationProvisionerService: provisioner,
+		Provider:                      noop.NewTracerProvider(),
+		Log:                           zap.NewNop().Sugar(),
+	}, exhibits, provisioner
+}
+
+func TestUpdateKeepsExhibitRunningWhenPreparingFails(t *testing.T) {
+	pullErr := errors.New("manifest for app:2 not found")
+	updater, exhibits, provisioner := newUpdateService(domain.Running, pullErr)
+
+	_, err := updater.UpdateExhibit(context.Background(), domain.UpdateExhibit{Exhibit: domain.Exhibit{Id: "1234", Name: "test"}})
+	if !errors.Is(err, pullErr) {
+		t.Fatalf("expected the pull error, got %v", err)
+	}
+
+	if len(provisioner.calls) != 0 {
+		t.Errorf("expected the running exhibit to be left alone, got %v", provisioner.calls)
+	}
+
+	if exhibits.updated {
+		t.Error("expected no revision to be stored")
+	}
+}
+
+func TestUpdateRestartsRunningExhibitAfterPreparing(t *testing.T) {
+	updater, exhibits, provisioner := newUpdateService(domain.Running, nil)
+
+	updated, err := updater.UpdateExhibit(context.Background(), domain.UpdateExhibit{Exhibit: domain.Exhibit{Id: "1234", Name: "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !exhibits.updated || updated.Revision != 2 {
+		t.Errorf("expected revision 2 to be stored, got %d", updated.Revision)
+	}
+
+	<-provisioner.started
+	provisioner.mu.Lock()
+	defer provisioner.mu.Unlock()
+	if len(provisioner.calls) != 3 || provisioner.calls[0] != "stop" || provisioner.calls[1] != "cleanup" || provisioner.calls[2] != "start" {
+		t.Errorf("expected stop, cleanup and start, got %v", provisioner.calls)
+	}
+}
+
+// revisionState records the revisions stored
+type revisionState struct {
+	persistence.State
+	stored []domain.ExhibitRevision
+}
+
+func (r *revisionState) UpdateExhibit(_ context.Context, revision domain.ExhibitRevision) error {
+	r.stored = append(r.stored, revision)
+	return nil
+}
+
+func TestStoringRevisionChecksStatusInsideLock(t *testing.T) {
+	tests := []struct {
+		status domain.Status
+		err    error
+	}{
+		{domain.Stopped, nil},
+		{domain.NotCreated, nil},
+		{domain.Running, domain.ErrExhibitRunning},
+		{domain.Starting, domain.ErrExhibitStarting},
+		{domain.Stopping, domain.ErrExhibitStopping},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.status), func(t *testing.T) {
+			locks := &fakeLockService{}
+			state := &revisionState{}
+			exhibits := ExhibitServiceImpl{
+				State:              state,
+				LockService:        locks,
+				RuntimeInfoService: &fakeRuntimeInfoService{locks: locks, info: domain.ExhibitRuntimeInfo{Status: test.status}},
+			}
+
+			// the fake locks aren't reentrant, reading the status with a lock of its own would hang here
+			err := exhibits.storeStoppedRevision(context.Background(), domain.ExhibitRevision{Revision: 2, Exhibit: domain.Exhibit{Id: "1234"}})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+
+			if stored := len(state.stored) == 1; stored != (test.err == nil) {
+				t.Errorf("expected the revision to be stored only if the exhibit isn't running, stored %v", state.stored)
+			}
+		})
+	}
+}
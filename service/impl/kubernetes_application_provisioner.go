@@ -0,0 +1,512 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"museum/config"
+	"museum/domain"
+	service "museum/service/interface"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// pods waiting for one of these reasons won't start without a change to the exhibit
+var kubernetesFailureReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CrashLoopBackOff":           true,
+}
+
+// KubernetesApplicationProvisionerService runs every object of an exhibit as a pod with a headless service of the same name,
+// livechecks become readiness probes
+type KubernetesApplicationProvisionerService struct {
+	ApplicationLifecycle
+	EnvironmentTemplateResolver service.EnvironmentTemplateResolverService
+	VolumeProvisionerFactory    service.VolumeProvisionerFactoryService
+	Client                      kubernetes.Interface
+	Config                      config.Config
+	// PollInterval is how often the state of a starting pod is checked
+	PollInterval time.Duration
+}
+
+func (k KubernetesApplicationProvisionerService) StartApplication(ctx context.Context, exhibitId string) error {
+	return k.startApplication(ctx, exhibitId, k.startObjects)
+}
+
+func (k KubernetesApplicationProvisionerService) StopApplication(ctx context.Context, exhibitId string) error {
+	return k.stopApplication(ctx, exhibitId, k.stopObjects)
+}
+
+func (k KubernetesApplicationProvisionerService) CleanupApplication(ctx context.Context, exhibitId string) error {
+	return k.cleanupApplication(ctx, exhibitId, k.cleanupObjects)
+}
+
+func (k KubernetesApplicationProvisionerService) namespace() string {
+	return k.Config.GetK8sNamespace()
+}
+
+func (k KubernetesApplicationProvisionerService) startObjects(ctx context.Context, exhibit *domain.Exhibit) error {
+	progress := newStartProgress(k.Eventing)
+
+	// the default profile may have been tightened since the exhibit was validated
+	err := enforceProfile(k.Config.GetDefaultProfile(), *exhibit)
+	if err != nil {
+		k.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+		return err
+	}
+
+	err = startGraph(ctx, *exhibit, func(ctx context.Context, idx int) error {
+		o := exhibit.Objects[idx]
+
+		err := k.startObject(ctx, exhibit, o, idx, progress)
+		if err != nil {
+			k.Log.Warnw("error starting exhibit object", "exhibit", exhibit.Name, "object", o.Name, "error", err)
+		}
+
+		return err
+	})
+	if err != nil {
+		// the objects that are running already would be left behind otherwise
+		e := k.deleteObjects(ctx, *exhibit, true)
+		if e != nil {
+			k.Log.Errorw("error removing objects of failed exhibit", "exhibit", exhibit.Name, "error", e)
+		}
+
+		k.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
+		return err
+	}
+
+	exhibit.RuntimeInfo.Status = domain.Running
+
+	return nil
+}
+
+func (k KubernetesApplicationProvisionerService) startObject(ctx context.Context, exhibit *domain.Exhibit, object domain.Object, idx int, progress *startProgress) error {
+	name := kubernetesObjectName(*exhibit, object.Name)
+
+	ctx, span := k.Provider.
+		Tracer("kubernetes provisioner").
+		Start(ctx, "startObject", trace.WithAttributes(attribute.String("pod", name), attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	timer := k.Metrics.StartStepTimer()
+	defer timer.Stop()
+
+	step := func(s domain.ObjectStartingStep, err error) {
+		progress.dispatch(ctx, *exhibit, domain.ExhibitStartingStep{
+			Object: idx,
+			Step:   s,
+			Error:  err,
+		})
+	}
+
+	span.AddEvent("removing leftover pod")
+	timer.Step(domain.ObjectStartingStepClean.String())
+	step(domain.ObjectStartingStepClean, nil)
+
+	err := k.deletePod(ctx, *exhibit, name)
+	if err != nil {
+		k.Log.Errorw("error removing leftover pod", "pod", name, "exhibitId", exhibit.Id, "error", err)
+		step(domain.ObjectStartingStepClean, err)
+		return err
+	}
+
+	span.AddEvent("creating pod")
+	timer.Step(domain.ObjectStartingStepCreate.String())
+	step(domain.ObjectStartingStepCreate, nil)
+
+	pod, err := k.buildPod(ctx, *exhibit, object)
+	if err != nil {
+		step(domain.ObjectStartingStepCreate, err)
+		return err
+	}
+
+	err = k.ensureService(ctx, *exhibit, object)
+	if err != nil {
+		k.Log.Errorw("error creating service", "service", name, "exhibitId", exhibit.Id, "error", err)
+		step(domain.ObjectStartingStepCreate, err)
+		return err
+	}
+
+	_, err = k.Client.CoreV1().Pods(k.namespace()).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		k.Log.Errorw("error creating pod", "pod", name, "exhibitId", exhibit.Id, "error", err)
+		step(domain.ObjectStartingStepCreate, err)
+		return err
+	}
+
+	progress.update(func() {
+		exhibit.RuntimeInfo.RelatedContainers = append(exhibit.RuntimeInfo.RelatedContainers, name)
+	})
+
+	span.AddEvent("waiting for pod to run")
+	timer.Step(domain.ObjectStartingStepStart.String())
+	step(domain.ObjectStartingStepStart, nil)
+
+	err = k.waitForPod(ctx, name, time.Duration(k.Config.GetStartingTimeout())*time.Second, podRunning)
+	if err != nil {
+		k.Log.Warnw("pod did not start", "pod", name, "exhibitId", exhibit.Id, "error", err)
+		step(domain.ObjectStartingStepStart, err)
+		return err
+	}
+
+	if object.Name == exhibit.Expose {
+		progress.update(func() {
+			exhibit.RuntimeInfo.Hostname = kubernetesObjectHost(*exhibit, object.Name, k.namespace())
+		})
+	}
+
+	if object.Livecheck != nil {
+		span.AddEvent("waiting for readiness probe")
+		timer.Step(domain.ObjectStartingStepLivecheck.String())
+		step(domain.ObjectStartingStepLivecheck, nil)
+
+		maxRetries, interval, err := livecheckTiming(*object.Livecheck)
+		if err == nil {
+			err = k.waitForPod(ctx, name, time.Duration(maxRetries)*interval, podReady)
+		}
+
+		if err != nil {
+			k.Log.Warnw("error doing livecheck", "pod", name, "exhibitId", exhibit.Id, "error", err)
+			err = errors.New("livecheck failed: " + err.Error())
+			step(domain.ObjectStartingStepLivecheck, err)
+			return err
+		}
+	}
+
+	timer.Stop()
+	step(domain.ObjectStartingStepReady, nil)
+
+	return nil
+}
+
+func (k KubernetesApplicationProvisionerService) buildPod(ctx context.Context, exhibit domain.Exhibit, object domain.Object) (*corev1.Pod, error) {
+	err, env := k.EnvironmentTemplateResolver.FillEnvironmentTemplate(&exhibit, object, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := corev1.Container{
+		Name:  kubernetesName(object.Name),
+		Image: object.Image + ":" + object.Label,
+		Env:   make([]corev1.EnvVar, 0, len(env)),
+	}
+
+	for key, value := range env {
+		c.Env = append(c.Env, corev1.EnvVar{Name: key, Value: value})
+	}
+	sort.Slice(c.Env, func(i, j int) bool {
+		return c.Env[i].Name < c.Env[j].Name
+	})
+
+	if object.Livecheck != nil {
+		c.ReadinessProbe, err = readinessProbe(*object.Livecheck)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	profile, err := k.Config.GetDefaultProfile().Enforce(object)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := applyKubernetesProfile(profile, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	for volumeName, mountPath := range object.Mounts {
+		volume := findVolume(exhibit, volumeName)
+
+		provisioner, err := k.VolumeProvisionerFactory.GetForVolume(exhibit.Id, volume)
+		if err != nil {
+			return nil, err
+		}
+
+		claim, err := provisioner.ProvisionStorage(ctx, volume.Driver.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		name := kubernetesName(volumeName)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim, ReadOnly: true},
+			},
+		})
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: mountPath, ReadOnly: true})
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   kubernetesObjectName(exhibit, object.Name),
+			Labels: objectLabels(exhibit, object),
+		},
+		Spec: corev1.PodSpec{
+			Containers:    []corev1.Container{c},
+			Volumes:       volumes,
+			RestartPolicy: corev1.RestartPolicyAlways,
+		},
+	}, nil
+}
+
+// ensureService creates the headless service that gives an object its host name, it is kept while the exhibit is stopped
+func (k KubernetesApplicationProvisionerService) ensureService(ctx context.Context, exhibit domain.Exhibit, object domain.Object) error {
+	labels := objectLabels(exhibit, object)
+
+	_, err := k.Client.CoreV1().Services(k.namespace()).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   kubernetesObjectName(exhibit, object.Name),
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  labels,
+			// objects are reachable by name as soon as they run, like docker containers
+			PublishNotReadyAddresses: true,
+		},
+	}, metav1.CreateOptions{})
+	if !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	existing, err := k.Client.CoreV1().Services(k.namespace()).Get(ctx, kubernetesObjectName(exhibit, object.Name), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	return ownedBy(existing.ObjectMeta, exhibit)
+}
+
+// waitForPod polls a pod until done reports true, an error or the timeout ends the wait
+func (k KubernetesApplicationProvisionerService) waitForPod(ctx context.Context, name string, timeout time.Duration, done func(pod *corev1.Pod) (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		pod, err := k.Client.CoreV1().Pods(k.namespace()).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		ok, err := done(pod)
+		if err != nil || ok {
+			return err
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for pod " + name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(k.PollInterval):
+		}
+	}
+}
+
+// deletePod removes a pod of an exhibit and waits until it is gone, so a new one with the same name can be created.
+// A pod of the same name that belongs to something else is left alone
+func (k KubernetesApplicationProvisionerService) deletePod(ctx context.Context, exhibit domain.Exhibit, name string) error {
+	pods := k.Client.CoreV1().Pods(k.namespace())
+
+	pod, err := pods.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = ownedBy(pod.ObjectMeta, exhibit)
+	if err != nil {
+		return err
+	}
+
+	// the uid makes sure a pod that was replaced in the meantime isn't deleted instead
+	err = pods.Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: new(int64), Preconditions: metav1.NewUIDPreconditions(string(pod.UID))})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = k.waitForPod(ctx, name, time.Duration(k.Config.GetStartingTimeout())*time.Second, func(*corev1.Pod) (bool, error) {
+		return false, nil
+	})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// deleteObjects removes the pods of an exhibit, and its services as well if withServices is set
+func (k KubernetesApplicationProvisionerService) deleteObjects(ctx context.Context, exhibit domain.Exhibit, withServices bool) error {
+	span := trace.SpanFromContext(ctx)
+	selector := metav1.ListOptions{LabelSelector: kubernetesSelector(exhibit)}
+
+	pods, err := k.Client.CoreV1().Pods(k.namespace()).List(ctx, selector)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		span.AddEvent("deleting pod " + pod.Name)
+
+		err = k.Client.CoreV1().Pods(k.namespace()).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if !withServices {
+		return nil
+	}
+
+	services, err := k.Client.CoreV1().Services(k.namespace()).List(ctx, selector)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range services.Items {
+		span.AddEvent("deleting service " + s.Name)
+
+		err = k.Client.CoreV1().Services(k.namespace()).Delete(ctx, s.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stopObjects removes the pods of an exhibit, there is nothing like a stopped pod
+func (k KubernetesApplicationProvisionerService) stopObjects(ctx context.Context, exhibit *domain.Exhibit) error {
+	return k.deleteObjects(ctx, *exhibit, false)
+}
+
+// cleanupObjects removes the pods and services of an exhibit
+func (k KubernetesApplicationProvisionerService) cleanupObjects(ctx context.Context, exhibit *domain.Exhibit) error {
+	return k.deleteObjects(ctx, *exhibit, true)
+}
+
+func objectLabels(exhibit domain.Exhibit, object domain.Object) map[string]string {
+	labels := kubernetesLabels(exhibit)
+	labels[kubernetesObjectLabel] = kubernetesName(object.Name)
+	return labels
+}
+
+// readinessProbe maps a livecheck to a probe, http probes always use GET and accept any 2xx and 3xx status
+func readinessProbe(livecheck domain.Livecheck) (*corev1.Probe, error) {
+	_, interval, err := livecheckTiming(livecheck)
+	if err != nil {
+		return nil, err
+	}
+
+	period := int32(interval / time.Second)
+	if period < 1 {
+		period = 1
+	}
+
+	probe := &corev1.Probe{PeriodSeconds: period}
+
+	switch livecheck.Type {
+	case domain.LivecheckTypeHttp:
+		port := 80
+		if p, ok := livecheck.Config["port"]; ok {
+			port, err = strconv.Atoi(p)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		path, ok := livecheck.Config["path"]
+		if !ok {
+			path = "/"
+		}
+
+		probe.HTTPGet = &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt32(int32(port))}
+	case domain.LivecheckTypeExec:
+		command, ok := livecheck.Config["command"]
+		if !ok {
+			command = "true"
+		}
+
+		probe.Exec = &corev1.ExecAction{Command: []string{"sh", "-c", command}}
+	default:
+		return nil, errors.New("livecheck type not found")
+	}
+
+	return probe, nil
+}
+
+// livecheckTiming reads how often and in which interval a livecheck is tried
+func livecheckTiming(livecheck domain.Livecheck) (maxRetries int, interval time.Duration, err error) {
+	maxRetries = 10
+	if r, ok := livecheck.Config["maxRetries"]; ok {
+		maxRetries, err = strconv.Atoi(r)
+		if err != nil {
+			return
+		}
+	}
+
+	interval = 1 * time.Second
+	if i, ok := livecheck.Config["interval"]; ok {
+		interval, err = time.ParseDuration(i)
+	}
+
+	return
+}
+
+// podFailure reports why a pod won't ever start
+func podFailure(pod *corev1.Pod) error {
+	if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded {
+		return errors.New("pod " + pod.Name + " exited")
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && kubernetesFailureReasons[waiting.Reason] {
+			return errors.New(waiting.Reason + ": " + waiting.Message)
+		}
+	}
+
+	return nil
+}
+
+func podRunning(pod *corev1.Pod) (bool, error) {
+	if err := podFailure(pod); err != nil {
+		return false, err
+	}
+
+	return pod.Status.Phase == corev1.PodRunning, nil
+}
+
+func podReady(pod *corev1.Pod) (bool, error) {
+	if err := podFailure(pod); err != nil {
+		return false, err
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,277 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"museum/observability"
+	persistenceimpl "museum/persistence/impl"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newKubernetesProvisioner returns a provisioner on a fake cluster, the pods of which run and get ready right after they are created
+func newKubernetesProvisioner(status corev1.PodStatus) (KubernetesApplicationProvisionerService, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		pod.Status = status
+		return false, pod, nil
+	})
+
+	log := zap.NewNop().Sugar()
+	config := configimpl.EnvConfig{ProxyMode: "k8s", K8sNamespace: "museum", StartingTimeout: 1, DefaultMemory: "512m", DefaultNoNewPrivileges: true}
+
+	return KubernetesApplicationProvisionerService{
+		ApplicationLifecycle: ApplicationLifecycle{
+			Eventing: persistenceimpl.NoopEventing{Log: log},
+			Log:      log,
+			Provider: noop.NewTracerProvider(),
+			Metrics:  observability.NewMetrics(),
+		},
+		EnvironmentTemplateResolver: &EnvironmentTemplateResolverServiceImpl{Config: config},
+		VolumeProvisionerFactory:    VolumeProvisionerFactoryServiceImpl{Config: config},
+		Client:                      client,
+		Config:                      config,
+		PollInterval:                10 * time.Millisecond,
+	}, client
+}
+
+var readyStatus = corev1.PodStatus{
+	Phase:      corev1.PodRunning,
+	Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+}
+
+func kubernetesTestExhibit() *domain.Exhibit {
+	return &domain.Exhibit{
+		Id:     "1234",
+		Name:   "test",
+		Expose: "app",
+		Objects: []domain.Object{
+			{
+				Name:      "db",
+				Image:     "postgres",
+				Label:     "16",
+				Livecheck: &domain.Livecheck{Type: domain.LivecheckTypeExec, Config: domain.StringMap{"command": "pg_isready"}},
+				Mounts:    domain.StringMap{"data": "/var/lib/postgresql/data"},
+			},
+			{
+				Name:        "app",
+				Image:       "app",
+				Label:       "latest",
+				Environment: domain.StringMap{"DB_HOST": "{{ @db }}"},
+				Livecheck:   &domain.Livecheck{Type: domain.LivecheckTypeHttp, Config: domain.StringMap{"path": "/health", "port": "8080"}},
+			},
+		},
+		Volumes: []domain.Volume{
+			{Name: "data", Driver: domain.Driver{Type: "pvc", Config: domain.StringMap{"claim": "test-data"}}},
+		},
+		RuntimeInfo: &domain.ExhibitRuntimeInfo{Status: domain.Starting},
+	}
+}
+
+func TestKubernetesStartObjects(t *testing.T) {
+	k, client := newKubernetesProvisioner(readyStatus)
+	ctx := context.Background()
+	exhibit := kubernetesTestExhibit()
+
+	err := k.startObjects(ctx, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		t.Errorf("Expected exhibit to be running, got %s", exhibit.RuntimeInfo.Status)
+	}
+
+	if exhibit.RuntimeInfo.Hostname != kubernetesObjectName(*exhibit, "app")+".museum.svc" {
+		t.Errorf("Expected hostname of the app service, got %s", exhibit.RuntimeInfo.Hostname)
+	}
+
+	db, err := client.CoreV1().Pods("museum").Get(ctx, kubernetesObjectName(*exhibit, "db"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Labels[kubernetesExhibitLabel] != "1234" || db.Labels[kubernetesObjectLabel] != "db" {
+		t.Errorf("Expected exhibit and object labels, got %v", db.Labels)
+	}
+
+	if probe := db.Spec.Containers[0].ReadinessProbe; probe == nil || probe.Exec == nil || probe.Exec.Command[2] != "pg_isready" {
+		t.Errorf("Expected exec readiness probe, got %v", probe)
+	}
+
+	if volumes := db.Spec.Volumes; len(volumes) != 1 || volumes[0].PersistentVolumeClaim.ClaimName != "test-data" {
+		t.Errorf("Expected the claim test-data to be mounted, got %v", volumes)
+	}
+
+	if mounts := db.Spec.Containers[0].VolumeMounts; len(mounts) != 1 || !mounts[0].ReadOnly {
+		t.Errorf("Expected the claim to be mounted read only, got %v", mounts)
+	}
+
+	if memory := db.Spec.Containers[0].Resources.Limits.Memory(); memory.Value() != 512*1024*1024 {
+		t.Errorf("Expected the default memory limit, got %v", memory)
+	}
+
+	if sc := db.Spec.Containers[0].SecurityContext; sc == nil || sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Errorf("Expected privilege escalation to be forbidden, got %v", sc)
+	}
+
+	app, err := client.CoreV1().Pods("museum").Get(ctx, kubernetesObjectName(*exhibit, "app"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if env := app.Spec.Containers[0].Env; len(env) != 1 || env[0].Value != kubernetesObjectName(*exhibit, "db") {
+		t.Errorf("Expected DB_HOST to be the db service, got %v", env)
+	}
+
+	if probe := app.Spec.Containers[0].ReadinessProbe; probe == nil || probe.HTTPGet == nil || probe.HTTPGet.Path != "/health" || probe.HTTPGet.Port.IntValue() != 8080 {
+		t.Errorf("Expected http readiness probe, got %v", probe)
+	}
+
+	service, err := client.CoreV1().Services("museum").Get(ctx, kubernetesObjectName(*exhibit, "db"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if service.Spec.ClusterIP != corev1.ClusterIPNone || service.Spec.Selector[kubernetesObjectLabel] != "db" {
+		t.Errorf("Expected headless service selecting the db pod, got %v", service.Spec)
+	}
+
+	err = k.stopObjects(ctx, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pods, _ := client.CoreV1().Pods("museum").List(ctx, metav1.ListOptions{})
+	services, _ := client.CoreV1().Services("museum").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 || len(services.Items) != 2 {
+		t.Errorf("Expected stop to remove the pods only, got %d pods and %d services", len(pods.Items), len(services.Items))
+	}
+
+	err = k.cleanupObjects(ctx, exhibit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services, _ = client.CoreV1().Services("museum").List(ctx, metav1.ListOptions{})
+	if len(services.Items) != 0 {
+		t.Errorf("Expected cleanup to remove the services, got %d", len(services.Items))
+	}
+}
+
+func TestKubernetesStartObjectsFailing(t *testing.T) {
+	k, client := newKubernetesProvisioner(corev1.PodStatus{
+		Phase: corev1.PodPending,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}},
+		}},
+	})
+	ctx := context.Background()
+
+	err := k.startObjects(ctx, kubernetesTestExhibit())
+	if err == nil {
+		t.Fatal("Expected the image pull error")
+	}
+
+	pods, _ := client.CoreV1().Pods("museum").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected the pods of the failed exhibit to be removed, got %d", len(pods.Items))
+	}
+}
+
+func TestKubernetesName(t *testing.T) {
+	tests := map[string][]string{
+		"my-app-db": {"my_app", "DB"},
+		"x-1-web":   {"1", "web"},
+	}
+
+	for expected, names := range tests {
+		if name := kubernetesName(names...); name != expected {
+			t.Errorf("Expected %s for %v, got %s", expected, names, name)
+		}
+	}
+
+	long := kubernetesName(strings.Repeat("a", 100), "db")
+	if len(long) > 63 {
+		t.Errorf("Expected names to be cut to 63 characters, got %d", len(long))
+	}
+}
+
+func TestKubernetesObjectNamesDontCollide(t *testing.T) {
+	first := kubernetesObjectName(domain.Exhibit{Id: "1", Name: "a-b"}, "c")
+	second := kubernetesObjectName(domain.Exhibit{Id: "2", Name: "a"}, "b-c")
+	if first == second {
+		t.Errorf("Expected different names for a-b/c and a/b-c, got %s", first)
+	}
+
+	// objects of one exhibit that only differ in characters kubernetes doesn't allow
+	exhibit := domain.Exhibit{Id: "1", Name: "test"}
+	if kubernetesObjectName(exhibit, "my_db") == kubernetesObjectName(exhibit, "my-db") {
+		t.Errorf("Expected different names for my_db and my-db")
+	}
+
+	if kubernetesObjectName(exhibit, "db") != kubernetesObjectName(exhibit, "db") {
+		t.Errorf("Expected names to be stable")
+	}
+}
+
+func TestKubernetesKeepsPodsOfOtherExhibits(t *testing.T) {
+	k, client := newKubernetesProvisioner(readyStatus)
+	ctx := context.Background()
+	exhibit := kubernetesTestExhibit()
+
+	// a pod that happens to have the name of an object of the exhibit
+	name := kubernetesObjectName(*exhibit, "db")
+	_, err := client.CoreV1().Pods("museum").Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{kubernetesExhibitLabel: "5678"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = k.deletePod(ctx, *exhibit, name)
+	if err == nil {
+		t.Fatal("Expected the pod of another exhibit to be refused")
+	}
+
+	pod, err := client.CoreV1().Pods("museum").Get(ctx, name, metav1.GetOptions{})
+	if err != nil || pod.Labels[kubernetesExhibitLabel] != "5678" {
+		t.Errorf("Expected the pod of the other exhibit to be left alone, got %v", err)
+	}
+}
+
+func TestKubernetesEnforcesTightenedProfile(t *testing.T) {
+	k, client := newKubernetesProvisioner(readyStatus)
+	ctx := context.Background()
+
+	// the exhibit was stored while the default limit was higher
+	exhibit := kubernetesTestExhibit()
+	exhibit.Objects[0].Resources = &domain.Resources{Memory: "1g"}
+
+	err := k.startObjects(ctx, exhibit)
+	if !errors.Is(err, domain.ErrProfileRelaxed) {
+		t.Fatalf("Expected the start to be refused, got %v", err)
+	}
+
+	pods, _ := client.CoreV1().Pods("museum").List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("Expected no pods to be created, got %d", len(pods.Items))
+	}
+
+	exhibit.Objects[0].Security = &domain.Security{Relax: true}
+	err = k.startObjects(ctx, exhibit)
+	if err != nil {
+		t.Fatalf("Expected a relaxed object to start, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"museum/config"
+	"museum/domain"
+	service "museum/service/interface"
+)
+
+// KubernetesApplicationResolverService resolves objects to the host names of their headless services
+type KubernetesApplicationResolverService struct {
+	ExhibitService service.ExhibitService
+	Config         config.Config
+}
+
+func (k KubernetesApplicationResolverService) ResolveApplication(ctx context.Context, exhibitId string) (string, error) {
+	exhibit, err := k.ExhibitService.GetExhibitById(ctx, exhibitId)
+	if err != nil {
+		return "", err
+	}
+
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		return "", errors.New("exhibit is not running")
+	}
+
+	return kubernetesObjectHost(exhibit, exhibit.Expose, k.Config.GetK8sNamespace()), nil
+}
+
+func (k KubernetesApplicationResolverService) ResolveExhibitObject(exhibit domain.Exhibit, object domain.Object) (string, error) {
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		return "", errors.New("exhibit is not running")
+	}
+
+	return kubernetesObjectHost(exhibit, object.Name, k.Config.GetK8sNamespace()), nil
+}
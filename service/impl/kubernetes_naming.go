@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"museum/domain"
+	"regexp"
+	"strings"
+)
+
+const (
+	kubernetesManagedByLabel = "app.kubernetes.io/managed-by"
+	kubernetesExhibitLabel   = "museum/exhibit-id"
+	kubernetesObjectLabel    = "museum/object"
+)
+
+var kubernetesInvalidChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// kubernetesName joins names to a valid DNS label (e.g. of a pod or service),
+// names that are too long are cut and get a hash, so they stay unique
+func kubernetesName(names ...string) string {
+	name := strings.ToLower(strings.Join(names, "-"))
+	name = kubernetesInvalidChars.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+
+	// service names have to start with a letter
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "x-" + name
+	}
+
+	if len(name) > 63 {
+		sum := sha256.Sum256([]byte(strings.Join(names, "-")))
+		name = strings.TrimRight(name[:54], "-") + "-" + hex.EncodeToString(sum[:])[:8]
+	}
+
+	return name
+}
+
+// kubernetesObjectName is the name of the pod and the service of an exhibit object, the names alone may collide
+// once they are joined and cleaned up (e.g. a-b/c and a/b-c), a hash of the exhibit id and the object keeps them apart
+func kubernetesObjectName(exhibit domain.Exhibit, object string) string {
+	sum := sha256.Sum256([]byte(exhibit.Id + "/" + object))
+	return kubernetesName(exhibit.Name, object, hex.EncodeToString(sum[:])[:8])
+}
+
+// ownedBy checks that an object in the cluster belongs to an exhibit, before it is replaced or removed by name
+func ownedBy(meta metav1.ObjectMeta, exhibit domain.Exhibit) error {
+	if meta.Labels[kubernetesExhibitLabel] != exhibit.Id {
+		return fmt.Errorf("%s belongs to exhibit %q, not to %q", meta.Name, meta.Labels[kubernetesExhibitLabel], exhibit.Id)
+	}
+
+	return nil
+}
+
+// kubernetesObjectHost is the host an exhibit object is reached at from anywhere in the cluster
+func kubernetesObjectHost(exhibit domain.Exhibit, object string, namespace string) string {
+	return kubernetesObjectName(exhibit, object) + "." + namespace + ".svc"
+}
+
+// kubernetesLabels are the labels of all pods and services of an exhibit
+func kubernetesLabels(exhibit domain.Exhibit) map[string]string {
+	return map[string]string{
+		kubernetesManagedByLabel: "museum",
+		kubernetesExhibitLabel:   exhibit.Id,
+	}
+}
+
+func kubernetesSelector(exhibit domain.Exhibit) string {
+	return kubernetesManagedByLabel + "=museum," + kubernetesExhibitLabel + "=" + exhibit.Id
+}
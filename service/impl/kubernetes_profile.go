@@ -0,0 +1,126 @@
+package impl
+
+import (
+	"errors"
+	"github.com/docker/go-units"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"museum/domain"
+	"strconv"
+	"strings"
+)
+
+// applyKubernetesProfile sets the resource limits and security settings of an object on its container and
+// returns the volumes of its tmpfs mounts, pods can't limit the number of processes though
+func applyKubernetesProfile(profile domain.Profile, c *corev1.Container) ([]corev1.Volume, error) {
+	r := profile.Resources
+	limits := corev1.ResourceList{}
+
+	if r.Cpus != "" {
+		cpus, err := resource.ParseQuantity(r.Cpus)
+		if err != nil {
+			return nil, err
+		}
+		limits[corev1.ResourceCPU] = cpus
+	}
+
+	if r.Memory != "" {
+		memory, err := units.RAMInBytes(r.Memory)
+		if err != nil {
+			return nil, err
+		}
+		limits[corev1.ResourceMemory] = *resource.NewQuantity(memory, resource.BinarySI)
+	}
+
+	if len(limits) != 0 {
+		c.Resources.Limits = limits
+	}
+
+	s := profile.Security
+	sc := &corev1.SecurityContext{ReadOnlyRootFilesystem: s.ReadOnly}
+
+	if len(s.CapDrop) != 0 || len(s.CapAdd) != 0 {
+		sc.Capabilities = &corev1.Capabilities{
+			Drop: kubernetesCapabilities(s.CapDrop),
+			Add:  kubernetesCapabilities(s.CapAdd),
+		}
+	}
+
+	if s.NoNewPrivileges != nil && *s.NoNewPrivileges {
+		allow := false
+		sc.AllowPrivilegeEscalation = &allow
+	}
+
+	switch s.Seccomp {
+	case "":
+	case domain.SeccompDefault:
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	case domain.SeccompUnconfined:
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+	default:
+		// the kubelet reads profiles from its own seccomp directory
+		path := strings.TrimPrefix(s.Seccomp, "/")
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &path}
+	}
+
+	switch s.AppArmor {
+	case "":
+	case "unconfined":
+		sc.AppArmorProfile = &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeUnconfined}
+	case "docker-default", "runtime/default":
+		sc.AppArmorProfile = &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault}
+	default:
+		name := s.AppArmor
+		sc.AppArmorProfile = &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost, LocalhostProfile: &name}
+	}
+
+	if s.User != "" {
+		user, group, hasGroup := strings.Cut(s.User, ":")
+
+		uid, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			return nil, errors.New("user " + s.User + " must be numeric on kubernetes")
+		}
+		sc.RunAsUser = &uid
+
+		if hasGroup {
+			gid, err := strconv.ParseInt(group, 10, 64)
+			if err != nil {
+				return nil, errors.New("user " + s.User + " must be numeric on kubernetes")
+			}
+			sc.RunAsGroup = &gid
+		}
+	}
+
+	c.SecurityContext = sc
+
+	volumes := make([]corev1.Volume, 0)
+	for i, tmpfs := range s.Tmpfs {
+		path, options, _ := strings.Cut(tmpfs, ":")
+		name := "tmpfs-" + strconv.Itoa(i)
+
+		emptyDir := &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}
+		for _, option := range strings.Split(options, ",") {
+			if size, ok := strings.CutPrefix(option, "size="); ok {
+				bytes, err := units.RAMInBytes(size)
+				if err != nil {
+					return nil, err
+				}
+				emptyDir.SizeLimit = resource.NewQuantity(bytes, resource.BinarySI)
+			}
+		}
+
+		volumes = append(volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}})
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: path})
+	}
+
+	return volumes, nil
+}
+
+func kubernetesCapabilities(capabilities []string) []corev1.Capability {
+	res := make([]corev1.Capability, 0, len(capabilities))
+	for _, c := range capabilities {
+		res = append(res, corev1.Capability(domain.NormalizeCapability(c)))
+	}
+	return res
+}
@@ -8,6 +8,8 @@ import (
 )
 
 type LocalVolumeProvisionerService struct {
+	// SnapshotPath is the directory keeping the snapshots of the volume, it is empty if the provisioner only checks configs
+	SnapshotPath string
 }
 
 func (l LocalVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
@@ -0,0 +1,205 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// snapshotExtension is the extension of the gzipped tars keeping the snapshots of local volumes
+const snapshotExtension = ".tar.gz"
+
+// snapshotDir is the directory keeping the snapshots of a volume of an exhibit
+func snapshotDir(snapshotPath string, exhibitId string, volume string) (string, error) {
+	return pathUnder(snapshotPath, exhibitId, volume)
+}
+
+func (l LocalVolumeProvisionerService) snapshotFile(name string) (string, error) {
+	if l.SnapshotPath == "" {
+		return "", errors.New("snapshots are only taken of the volumes of an exhibit")
+	}
+
+	err := domain.CheckSnapshotName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(l.SnapshotPath, name+snapshotExtension), nil
+}
+
+func (l LocalVolumeProvisionerService) GetSnapshots(_ context.Context, _ domain.StringMap) ([]domain.Snapshot, error) {
+	snapshots := make([]domain.Snapshot, 0)
+
+	entries, err := os.ReadDir(l.SnapshotPath)
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExtension)
+		if !ok || !entry.Type().IsRegular() || domain.CheckSnapshotName(name) != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, domain.Snapshot{Name: name, Created: info.ModTime(), Size: info.Size()})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+func (l LocalVolumeProvisionerService) CreateSnapshot(_ context.Context, config domain.StringMap, name string) (domain.Snapshot, error) {
+	file, err := l.snapshotFile(name)
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	if _, err := os.Stat(file); err == nil {
+		return domain.Snapshot{}, fmt.Errorf("%w: %s", domain.ErrSnapshotExists, name)
+	}
+
+	if _, err := os.Stat(config["path"]); os.IsNotExist(err) {
+		return domain.Snapshot{}, errors.New("path does not exist")
+	}
+
+	err = os.MkdirAll(l.SnapshotPath, 0755)
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	// the snapshot is written next to its final name, nobody sees a snapshot that is only half written
+	tmp, err := os.CreateTemp(l.SnapshotPath, ".snapshot-*")
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = writeArchive(config["path"], tmp)
+	if err != nil {
+		_ = tmp.Close()
+		return domain.Snapshot{}, err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	// unlike a rename, a link fails if someone created a snapshot with the same name in the meantime
+	err = os.Link(tmp.Name(), file)
+	if os.IsExist(err) {
+		return domain.Snapshot{}, fmt.Errorf("%w: %s", domain.ErrSnapshotExists, name)
+	}
+
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	return domain.Snapshot{Name: name, Created: info.ModTime(), Size: info.Size()}, nil
+}
+
+func (l LocalVolumeProvisionerService) RestoreSnapshot(ctx context.Context, config domain.StringMap, name string) error {
+	path := config["path"]
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return errors.New("path does not exist")
+	}
+
+	// the snapshot is extracted next to the volume first, a broken snapshot leaves the volume as it is
+	tmp, err := os.MkdirTemp(filepath.Dir(path), "."+filepath.Base(path)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	extracted := filepath.Join(tmp, "volume")
+	err = l.ExtractSnapshot(ctx, config, name, extracted)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = os.RemoveAll(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err = os.ReadDir(extracted)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = os.Rename(filepath.Join(extracted, entry.Name()), filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(extracted)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(path, info.Mode().Perm())
+}
+
+func (l LocalVolumeProvisionerService) DeleteSnapshot(_ context.Context, _ domain.StringMap, name string) error {
+	file, err := l.snapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", domain.ErrSnapshotNotFound, name)
+	}
+
+	return err
+}
+
+func (l LocalVolumeProvisionerService) ExtractSnapshot(_ context.Context, _ domain.StringMap, name string, target string) error {
+	file, err := l.snapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", domain.ErrSnapshotNotFound, name)
+	}
+
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return extractArchive(f, target)
+}
@@ -0,0 +1,174 @@
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalVolumeSnapshots(t *testing.T) {
+	volume := t.TempDir()
+	err := os.WriteFile(filepath.Join(volume, "db"), []byte("archived"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("db", filepath.Join(volume, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	config := domain.StringMap{"path": volume}
+	local := LocalVolumeProvisionerService{SnapshotPath: filepath.Join(t.TempDir(), "1234", "data")}
+
+	_, err = local.CreateSnapshot(ctx, config, "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = local.CreateSnapshot(ctx, config, "original"); !errors.Is(err, domain.ErrSnapshotExists) {
+		t.Errorf("Expected the name to be taken, got %v", err)
+	}
+
+	if _, err = local.CreateSnapshot(ctx, config, "../escape"); !errors.Is(err, domain.ErrInvalidSnapshotName) {
+		t.Errorf("Expected the name to be invalid, got %v", err)
+	}
+
+	// the data changes, the snapshot brings it back
+	err = os.WriteFile(filepath.Join(volume, "db"), []byte("changed"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(volume, "new"), []byte("new"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := local.GetSnapshots(ctx, config)
+	if err != nil || len(snapshots) != 1 || snapshots[0].Name != "original" || snapshots[0].Size == 0 {
+		t.Errorf("Expected the snapshot to be listed, got %v (%v)", snapshots, err)
+	}
+
+	err = local.RestoreSnapshot(ctx, config, "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(volume, "link"))
+	if err != nil || string(content) != "archived" {
+		t.Errorf("Expected the files of the snapshot to be restored, got %s (%v)", content, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(volume, "new")); !os.IsNotExist(err) {
+		t.Errorf("Expected files created after the snapshot to be removed, got %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(volume, "db")); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("Expected the permissions to be restored, got %v (%v)", info, err)
+	}
+
+	// a pinned volume is mounted from a copy of its snapshot
+	root := t.TempDir()
+	pinned := PinnedVolumeProvisionerService{Inner: local, Snapshots: local, Snapshot: "original", volumeCopy: volumeCopy{Root: root, Path: filepath.Join(root, "1234", "data")}}
+	path, err := pinned.ProvisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = os.ReadFile(filepath.Join(path, "db"))
+	if string(content) != "archived" {
+		t.Errorf("Expected the pinned snapshot to be provisioned, got %s", content)
+	}
+
+	err = pinned.DeprovisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the copy of the snapshot to be removed, got %v", err)
+	}
+
+	err = local.DeleteSnapshot(ctx, config, "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = local.DeleteSnapshot(ctx, config, "original"); !errors.Is(err, domain.ErrSnapshotNotFound) {
+		t.Errorf("Expected the snapshot to be gone, got %v", err)
+	}
+}
+
+func TestPinnedVolumeFactory(t *testing.T) {
+	factory := VolumeProvisionerFactoryServiceImpl{Config: configimpl.EnvConfig{ProxyMode: "swarm", ScratchPath: "/scratch", SnapshotPath: "/snapshots"}}
+
+	provisioner, err := factory.GetForVolume("1234", domain.Volume{Name: "data", Driver: domain.Driver{Type: "local"}, Snapshot: "original"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, ok := provisioner.(*PinnedVolumeProvisionerService)
+	if !ok || pinned.Path != "/scratch/1234/data" || pinned.Inner.(*LocalVolumeProvisionerService).SnapshotPath != "/snapshots/1234/data" {
+		t.Errorf("Expected a pinned provisioner copying to the scratch path, got %#v", provisioner)
+	}
+
+	_, err = factory.GetForVolume("1234", domain.Volume{Name: "data", Driver: domain.Driver{Type: "nfs"}, Snapshot: "original"})
+	if err == nil {
+		t.Error("Expected nfs volumes not to be pinned")
+	}
+
+	// snapshots are read and written below the snapshot path only
+	_, err = factory.GetForVolume("1234", domain.Volume{Name: "../../etc", Driver: domain.Driver{Type: "local"}})
+	if err == nil {
+		t.Error("Expected a volume name leaving the snapshot path to be rejected")
+	}
+}
+
+func TestExtractArchiveDoesntFollowSymlinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []tar.Header
+	}{
+		{"file", []tar.Header{{Name: "a/x", Typeflag: tar.TypeReg, Mode: 0644}}},
+		{"directory", []tar.Header{{Name: "a/", Typeflag: tar.TypeDir, Mode: 0777}}},
+		{"nested", []tar.Header{{Name: "a/b/", Typeflag: tar.TypeDir, Mode: 0755}, {Name: "a/b/x", Typeflag: tar.TypeReg, Mode: 0644}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outside := t.TempDir()
+			target := filepath.Join(t.TempDir(), "copy")
+
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			headers := append([]tar.Header{{Name: "a", Typeflag: tar.TypeSymlink, Linkname: outside}}, test.headers...)
+			for _, header := range headers {
+				if err := tw.WriteHeader(&header); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = tw.Close()
+			_ = gz.Close()
+
+			if err := extractArchive(&buf, target); err == nil {
+				t.Error("expected the archive to be rejected")
+			}
+
+			entries, _ := os.ReadDir(outside)
+			info, _ := os.Stat(outside)
+			if len(entries) != 0 || info.Mode().Perm() == 0777 {
+				t.Errorf("expected nothing to be written outside of the target, got %v (%v)", entries, info.Mode())
+			}
+		})
+	}
+}
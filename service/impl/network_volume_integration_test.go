@@ -0,0 +1,207 @@
+//go:build integration
+
+// The shares are mounted by a real docker daemon from real nfs and samba servers running in containers next to it,
+// run with `go test -tags integration ./service/impl -run Integration`. The docker host needs the nfs and cifs mount helpers
+// and has to allow privileged containers for the nfs server, the tests are skipped without a docker daemon
+
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/docker/docker/api/types/container"
+	docker "github.com/docker/docker/client"
+	"io"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	integrationSambaImage = "dperson/samba:latest"
+	integrationNfsImage   = "erichough/nfs-server:latest"
+	integrationReadImage  = "busybox:latest"
+)
+
+func integrationClient(t *testing.T) *docker.Client {
+	client, err := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Skip("no docker client: " + err.Error())
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err = client.Ping(context.Background())
+	if err != nil {
+		t.Skip("no docker daemon: " + err.Error())
+	}
+
+	return client
+}
+
+// startFileServer runs a file server with hello.txt in dir and returns its address on the bridge network
+func startFileServer(t *testing.T, client *docker.Client, config *container.Config, privileged bool, dir string) string {
+	ctx := context.Background()
+
+	err := pullImage(ctx, client, config.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create, err := client.ContainerCreate(ctx, config, &container.HostConfig{Privileged: privileged}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.ContainerRemove(context.Background(), create.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	})
+
+	archive := &bytes.Buffer{}
+	writer := tar.NewWriter(archive)
+	content := []byte("hello from the share\n")
+	_ = writer.WriteHeader(&tar.Header{Name: "hello.txt", Mode: 0644, Size: int64(len(content))})
+	_, _ = writer.Write(content)
+	_ = writer.Close()
+
+	err = client.CopyToContainer(ctx, create.ID, dir, archive, container.CopyToContainerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.ContainerStart(ctx, create.ID, container.StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inspect, err := client.ContainerInspect(ctx, create.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return inspect.NetworkSettings.Networks["bridge"].IPAddress
+}
+
+// readShare reads hello.txt from a docker volume in a container, the volume is mounted when the container starts
+func readShare(ctx context.Context, client *docker.Client, volume string) (string, error) {
+	err := pullImage(ctx, client, integrationReadImage)
+	if err != nil {
+		return "", err
+	}
+
+	create, err := client.ContainerCreate(ctx, &container.Config{
+		Image: integrationReadImage,
+		Cmd:   []string{"sh", "-c", "cat /share/hello.txt && ! touch /share/written"},
+		Tty:   true,
+	}, &container.HostConfig{Binds: []string{volume + ":/share:ro"}}, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer client.ContainerRemove(context.Background(), create.ID, container.RemoveOptions{Force: true})
+
+	err = client.ContainerStart(ctx, create.ID, container.StartOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	var status container.WaitResponse
+	wait, errs := client.ContainerWait(ctx, create.ID, container.WaitConditionNotRunning)
+	select {
+	case err = <-errs:
+		return "", err
+	case status = <-wait:
+	}
+
+	logs, err := client.ContainerLogs(ctx, create.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	output, err := io.ReadAll(logs)
+	if err != nil {
+		return "", err
+	}
+
+	// the share has to be readable but not writable
+	if status.StatusCode != 0 {
+		return "", errors.New("reading the share failed: " + string(output))
+	}
+
+	return string(output), nil
+}
+
+// mountShare provisions a volume until the server answers, reads it and removes it again
+func mountShare(t *testing.T, client *docker.Client, volume domain.Volume) string {
+	ctx := context.Background()
+	factory := VolumeProvisionerFactoryServiceImpl{Config: configimpl.EnvConfig{ProxyMode: "swarm"}, Client: client}
+
+	provisioner, err := factory.GetForVolume("integration", volume)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provisioner.CheckValidity(volume.Driver.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Minute)
+	for {
+		name, err := provisioner.ProvisionStorage(ctx, volume.Driver.Config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := readShare(ctx, client, name)
+		if err == nil {
+			err = provisioner.DeprovisionStorage(ctx, volume.Driver.Config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return output
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("share could not be mounted: " + err.Error())
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func TestIntegrationSmbVolume(t *testing.T) {
+	client := integrationClient(t)
+
+	address := startFileServer(t, client, &container.Config{
+		Image: integrationSambaImage,
+		// name;path;browseable;read only;guest;users
+		Cmd: []string{"-u", "museum;secret", "-s", "data;/share;no;yes;no;museum"},
+	}, false, "/share")
+
+	output := mountShare(t, client, domain.Volume{Name: "smb", Driver: domain.Driver{Type: "smb", Config: domain.StringMap{
+		"server": address, "share": "data", "username": "museum", "password": "secret", "version": "3.0",
+	}}})
+
+	if !strings.Contains(output, "hello from the share") {
+		t.Errorf("Expected the file of the share, got %q", output)
+	}
+}
+
+func TestIntegrationNfsVolume(t *testing.T) {
+	client := integrationClient(t)
+
+	address := startFileServer(t, client, &container.Config{
+		Image: integrationNfsImage,
+		Env:   []string{"NFS_EXPORT_0=/export *(ro,no_subtree_check,insecure,fsid=0)", "NFS_DISABLE_VERSION_3=1"},
+	}, true, "/export")
+
+	output := mountShare(t, client, domain.Volume{Name: "nfs", Driver: domain.Driver{Type: "nfs", Config: domain.StringMap{
+		"server": address, "share": "/", "version": "4.2",
+	}}})
+
+	if !strings.Contains(output, "hello from the share") {
+		t.Errorf("Expected the file of the export, got %q", output)
+	}
+}
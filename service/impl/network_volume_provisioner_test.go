@@ -0,0 +1,200 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/docker/docker/api/types/volume"
+	docker "github.com/docker/docker/client"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// fakeVolumeDaemon stands in for a docker daemon with nfs and smb servers behind it, it only knows volumes
+type fakeVolumeDaemon struct {
+	mu      sync.Mutex
+	volumes map[string]volume.Volume
+	// inUse are the volumes used by containers, which can't be removed
+	inUse map[string]bool
+}
+
+var fakeVolumePath = regexp.MustCompile(`^(/v[0-9.]+)?/volumes/?(.*)$`)
+
+func (f *fakeVolumeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	match := fakeVolumePath.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	name := match[2]
+	switch {
+	case r.Method == http.MethodPost && name == "create":
+		options := volume.CreateOptions{}
+		_ = json.NewDecoder(r.Body).Decode(&options)
+		v := volume.Volume{Name: options.Name, Driver: options.Driver, Options: options.DriverOpts, Labels: options.Labels}
+		f.volumes[v.Name] = v
+		_ = json.NewEncoder(w).Encode(v)
+	case r.Method == http.MethodGet:
+		v, ok := f.volumes[name]
+		if !ok {
+			http.Error(w, `{"message":"no such volume"}`, http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	case r.Method == http.MethodDelete:
+		if _, ok := f.volumes[name]; !ok {
+			http.Error(w, `{"message":"no such volume"}`, http.StatusNotFound)
+			return
+		}
+		if f.inUse[name] {
+			http.Error(w, `{"message":"volume is in use"}`, http.StatusConflict)
+			return
+		}
+		delete(f.volumes, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, `{"message":"not implemented"}`, http.StatusNotImplemented)
+	}
+}
+
+func newFakeVolumeDaemon(t *testing.T) (*fakeVolumeDaemon, *docker.Client) {
+	daemon := &fakeVolumeDaemon{volumes: make(map[string]volume.Volume), inUse: make(map[string]bool)}
+	server := httptest.NewServer(daemon)
+	t.Cleanup(server.Close)
+
+	client, err := docker.NewClientWithOpts(docker.WithHost("tcp://"+server.Listener.Addr().String()), docker.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return daemon, client
+}
+
+func TestNfsVolumeProvisioner(t *testing.T) {
+	daemon, client := newFakeVolumeDaemon(t)
+	ctx := context.Background()
+
+	factory := VolumeProvisionerFactoryServiceImpl{Config: configimpl.EnvConfig{ProxyMode: "swarm"}, Client: client}
+	v := domain.Volume{Name: "data", Driver: domain.Driver{Type: "nfs", Config: domain.StringMap{"server": "files.example.org", "share": "/exports/data", "version": "4.1"}}}
+
+	provisioner, err := factory.GetForVolume("1234", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provisioner.CheckValidity(v.Driver.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := provisioner.ProvisionStorage(ctx, v.Driver.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := daemon.volumes[name]
+	if created.Options["type"] != "nfs" || created.Options["device"] != ":/exports/data" || created.Options["o"] != "addr=files.example.org,ro,nfsvers=4.1" {
+		t.Errorf("Expected a read only nfs volume, got %v", created.Options)
+	}
+
+	if created.Labels["museum.exhibit-id"] != "1234" {
+		t.Errorf("Expected the volume to be labeled with the exhibit, got %v", created.Labels)
+	}
+
+	// a volume left behind with other options is replaced, unless it is in use
+	v.Driver.Config["share"] = "/exports/other"
+	daemon.inUse[name] = true
+	if _, err = provisioner.ProvisionStorage(ctx, v.Driver.Config); err == nil {
+		t.Error("Expected an error for a volume in use with other options")
+	}
+
+	daemon.inUse[name] = false
+	if _, err = provisioner.ProvisionStorage(ctx, v.Driver.Config); err != nil || daemon.volumes[name].Options["device"] != ":/exports/other" {
+		t.Errorf("Expected the volume to be replaced, got %v (%v)", daemon.volumes[name].Options, err)
+	}
+
+	// stopped containers keep the volume until they are cleaned up
+	daemon.inUse[name] = true
+	if err = provisioner.DeprovisionStorage(ctx, v.Driver.Config); err != nil || len(daemon.volumes) != 1 {
+		t.Errorf("Expected a volume in use to be kept, got %d volumes (%v)", len(daemon.volumes), err)
+	}
+
+	daemon.inUse[name] = false
+	if err = provisioner.DeprovisionStorage(ctx, v.Driver.Config); err != nil || len(daemon.volumes) != 0 {
+		t.Errorf("Expected the volume to be removed, got %d volumes (%v)", len(daemon.volumes), err)
+	}
+}
+
+func TestSmbVolumeProvisioner(t *testing.T) {
+	daemon, client := newFakeVolumeDaemon(t)
+	ctx := context.Background()
+
+	provisioner := SmbVolumeProvisionerService{Client: client, Name: "museum_1234_data"}
+	config := domain.StringMap{"server": "files.example.org", "share": "/research/data", "username": "museum", "password": "secret", "domain": "UNIVIE", "version": "3.0"}
+
+	err := provisioner.CheckValidity(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = provisioner.ProvisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := daemon.volumes["museum_1234_data"]
+	if created.Options["type"] != "cifs" || created.Options["device"] != "//files.example.org/research/data" ||
+		created.Options["o"] != "addr=files.example.org,ro,username=museum,password=secret,domain=UNIVIE,vers=3.0" {
+		t.Errorf("Expected a read only cifs volume, got %v", created.Options)
+	}
+
+	guest := smbDriverOptions(domain.StringMap{"server": "files.example.org", "share": "public"})
+	if guest["o"] != "addr=files.example.org,ro,guest" {
+		t.Errorf("Expected a guest mount without a username, got %s", guest["o"])
+	}
+
+	file := domain.StringMap{"server": "files.example.org", "share": "research", "credentials": "/etc/museum/research.cred"}
+	if err = provisioner.CheckValidity(file); err != nil {
+		t.Fatal(err)
+	}
+
+	if o := smbDriverOptions(file)["o"]; o != "addr=files.example.org,ro,credentials=/etc/museum/research.cred" {
+		t.Errorf("Expected the credentials file to be passed on, got %s", o)
+	}
+}
+
+func TestNetworkVolumeValidity(t *testing.T) {
+	nfs := NfsVolumeProvisionerService{}
+	smb := SmbVolumeProvisionerService{}
+
+	invalid := map[string]struct {
+		provisioner interface{ CheckValidity(domain.StringMap) error }
+		config      domain.StringMap
+	}{
+		"nfs without server":       {nfs, domain.StringMap{"share": "/data"}},
+		"nfs relative share":       {nfs, domain.StringMap{"server": "files", "share": "data"}},
+		"nfs unknown version":      {nfs, domain.StringMap{"server": "files", "share": "/data", "version": "5"}},
+		"nfs writable":             {nfs, domain.StringMap{"server": "files", "share": "/data", "options": "nolock,rw"}},
+		"smb without share":        {smb, domain.StringMap{"server": "files"}},
+		"smb password only":        {smb, domain.StringMap{"server": "files", "share": "data", "password": "secret"}},
+		"smb injected option":      {smb, domain.StringMap{"server": "files", "share": "data", "username": "museum", "password": "x,rw"}},
+		"smb invalid mount option": {smb, domain.StringMap{"server": "files", "share": "data", "options": "uid=$(id)"}},
+		"smb relative credentials": {smb, domain.StringMap{"server": "files", "share": "data", "credentials": "research.cred"}},
+		"smb credentials and user": {smb, domain.StringMap{"server": "files", "share": "data", "credentials": "/etc/research.cred", "username": "museum"}},
+	}
+
+	for name, test := range invalid {
+		if err := test.provisioner.CheckValidity(test.config); err == nil {
+			t.Errorf("Expected %s to be invalid", name)
+		}
+	}
+}
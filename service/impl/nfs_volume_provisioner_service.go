@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	docker "github.com/docker/docker/client"
+	"museum/domain"
+	"strings"
+)
+
+var nfsVersions = map[string]bool{"3": true, "4": true, "4.0": true, "4.1": true, "4.2": true}
+
+// NfsVolumeProvisionerService mounts an export of an nfs server read only, through a docker volume
+type NfsVolumeProvisionerService struct {
+	// Client is the docker daemon running the objects, which mounts the export
+	Client *docker.Client
+	// Name is the name of the docker volume, it is empty if the provisioner only checks configs
+	Name   string
+	Labels map[string]string
+}
+
+func (n NfsVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
+	if config["server"] == "" {
+		return errors.New("server is required")
+	}
+
+	if !strings.HasPrefix(config["share"], "/") {
+		return errors.New("share is required and must be an absolute path, e.g. /exports/data")
+	}
+
+	for _, key := range []string{"server", "share"} {
+		if err := checkMountValue(key, config[key]); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := config["version"]; ok && !nfsVersions[v] {
+		return errors.New("version must be one of 3, 4, 4.0, 4.1 or 4.2")
+	}
+
+	return checkMountOptions(config["options"])
+}
+
+func (n NfsVolumeProvisionerService) ProvisionStorage(ctx context.Context, config domain.StringMap) (string, error) {
+	return provisionDockerVolume(ctx, n.Client, n.Name, n.Labels, nfsDriverOptions(config))
+}
+
+func (n NfsVolumeProvisionerService) DeprovisionStorage(ctx context.Context, _ domain.StringMap) error {
+	return deprovisionDockerVolume(ctx, n.Client, n.Name)
+}
+
+// nfsDriverOptions are the options of the local docker volume driver mounting an export
+func nfsDriverOptions(config domain.StringMap) map[string]string {
+	version := ""
+	if v, ok := config["version"]; ok {
+		version = "nfsvers=" + v
+	}
+
+	return map[string]string{
+		"type":   "nfs",
+		"o":      mountOptions("addr="+config["server"], "ro", version, config["options"]),
+		"device": ":" + config["share"],
+	}
+}
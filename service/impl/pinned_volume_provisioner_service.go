@@ -0,0 +1,35 @@
+package impl
+
+import (
+	"context"
+	"museum/domain"
+	service "museum/service/interface"
+)
+
+// PinnedVolumeProvisionerService provisions a copy of a snapshot of a volume instead of the volume itself
+type PinnedVolumeProvisionerService struct {
+	Inner     service.VolumeProvisionerService
+	Snapshots service.VolumeSnapshotter
+	// Snapshot is the name of the pinned snapshot
+	Snapshot string
+	volumeCopy
+}
+
+func (p PinnedVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
+	return p.Inner.CheckValidity(config)
+}
+
+func (p PinnedVolumeProvisionerService) ProvisionStorage(ctx context.Context, config domain.StringMap) (string, error) {
+	return p.provision(func(path string) error {
+		return p.Snapshots.ExtractSnapshot(ctx, config, p.Snapshot, path)
+	})
+}
+
+func (p PinnedVolumeProvisionerService) DeprovisionStorage(ctx context.Context, config domain.StringMap) error {
+	err := p.remove()
+	if err != nil {
+		return err
+	}
+
+	return p.Inner.DeprovisionStorage(ctx, config)
+}
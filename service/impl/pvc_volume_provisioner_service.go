@@ -0,0 +1,39 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"museum/domain"
+)
+
+// PvcVolumeProvisionerService mounts an existing persistent volume claim on kubernetes,
+// the claim is managed outside of museum and is never deleted
+type PvcVolumeProvisionerService struct {
+}
+
+func (p PvcVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
+	claim, ok := config["claim"]
+	if !ok {
+		return errors.New("claim is required")
+	}
+
+	if claim == "" {
+		return errors.New("claim cannot be empty")
+	}
+
+	return nil
+}
+
+// ProvisionStorage returns the name of the claim
+func (p PvcVolumeProvisionerService) ProvisionStorage(_ context.Context, config domain.StringMap) (string, error) {
+	err := p.CheckValidity(config)
+	if err != nil {
+		return "", err
+	}
+
+	return config["claim"], nil
+}
+
+func (p PvcVolumeProvisionerService) DeprovisionStorage(context.Context, domain.StringMap) error {
+	return nil
+}
@@ -1,17 +1,14 @@
 package impl
 
 import (
-	"github.com/google/uuid"
-	"github.com/yosssi/gohtml"
 	"go.uber.org/zap"
+	"mime"
 	"museum/config"
 	"museum/domain"
 	"museum/http"
 	"museum/util"
+	"museum/util/rewrite"
 	gohttp "net/http"
-	"net/url"
-	"regexp"
-	"strings"
 )
 
 type RewriteServiceImpl struct {
@@ -19,66 +16,54 @@ type RewriteServiceImpl struct {
 	Log    *zap.SugaredLogger
 }
 
-var placeHolderHost = strings.ReplaceAll(uuid.New().String(), "-", "")
-var hrefSrcReg = regexp.MustCompile("(href|src|action) *= *([\"'])(\\w+[\\w=?/&.]*)([\"'])")
-var hrefSrcBaseReg = regexp.MustCompile("(href|src|action) *= *([\"'])/")
-
 // gets the FQHN (Fully Qualified Host Name) of the museum
 func (r *RewriteServiceImpl) getFqhn() string {
 	return r.Config.GetHostname() + ":" + r.Config.GetPort()
 }
 
-/*
- FIXME: currently http://localhost:8080/exhibit/fd8006cd-39b9-4c8b-816b-50152bbef02b/wp-login.php?redirect_to=http%3A%2F%2Flocalhost%3A8080%2Fwp-admin%2F&reauth=1
- does not work, because the redirect_to parameter is not rewritten because we are not idiots that rewrite query params
- but apparently WP devs are idiots that use query params for redirects :/
-*/
+// gets the rewriter that maps the urls of the application (reachable at hostname) to the ones of the exhibit
+func (r *RewriteServiceImpl) getRewriter(exhibit domain.Exhibit, hostname string) rewrite.Rewriter {
+	mode := r.Config.GetRoutingMode()
+	rewriter := rewrite.Rewriter{
+		BasePath:     mode.BasePath(exhibit.Id),
+		PublicHost:   mode.Host(exhibit.Name, r.getFqhn()),
+		UpstreamHost: hostname,
+	}
 
-func (r *RewriteServiceImpl) RewriteServerResponse(exhibit domain.Exhibit, hostname string, res *gohttp.Response, body *[]byte) (*[]byte, error) {
-	// alright, so we have to rewrite the response
-	// 1: "http://172.168.0.3:9090/foo/bar" changes to "http://localhost:8080/exhibit/123/foo/bar"
-	// 2: "http://localhost:8080/foo/bar" changes to "http://localhost:8080/exhibit/123/foo/bar"
-	// 3: "http://localhost:8080/exhibit/123/foo/bar" changes to "http://localhost:8080/exhibit/123/foo/bar" (not "http://localhost:8080/exhibit/123/exhibit/123/foo/bar")
-	// 4: change hrefs from "/foo/bar" to "/exhibit/123/foo/bar"
-	// 5: change srcs from "/foo/bar" to "/exhibit/123/foo/bar"
-
-	util.Nop(hostname)
-
-	// check if res is a redirect
-	if res.StatusCode >= 300 && res.StatusCode < 400 {
-		// get the redirect url
-		redirectUrl, err := res.Location()
-		if err != nil {
-			return nil, err
-		}
+	if exhibit.Proxy.ShouldNamespaceCookies() {
+		rewriter.CookiePrefix = exhibit.Id + "_"
+	}
 
-		// rewrite the redirect url
-		redirectUrlStr := redirectUrl.String()
-		redirectUrlStr = strings.ReplaceAll(redirectUrlStr, r.getFqhn(), r.getFqhn()+"/exhibit/"+exhibit.Id)
+	return rewriter
+}
 
-		res.Header.Set("Location", redirectUrlStr)
-	}
+func (r *RewriteServiceImpl) RewriteServerResponseHeaders(exhibit domain.Exhibit, hostname string, res *gohttp.Response) {
+	r.getRewriter(exhibit, hostname).Headers(res.Header)
+}
 
-	// check content type
-	if !strings.Contains(res.Header.Get("Content-Type"), "text/html") {
+func (r *RewriteServiceImpl) RewriteServerResponse(exhibit domain.Exhibit, hostname string, res *gohttp.Response, body *[]byte) (*[]byte, error) {
+	contentType := res.Header.Get("Content-Type")
+	if !rewrite.CanRewrite(contentType) {
 		return body, nil
 	}
 
 	// get encoding from header
+	// the upstream should only answer with encodings we asked for, but if it doesn't, the body is passed on as is
 	encoding := res.Header.Get("Content-Encoding")
+	if !util.IsSupportedEncoding(encoding) {
+		r.Log.Warnw("unsupported content encoding, not rewriting body", "encoding", encoding, "exhibitId", exhibit.Id)
+		return body, nil
+	}
+
 	bodyDecoded, err := util.DecodeBody(*body, encoding)
 	if err != nil {
-		r.Log.Warnw("error decoding body", "error", err, "requestId", exhibit.Id)
+		r.Log.Warnw("error decoding body", "error", err, "exhibitId", exhibit.Id)
 		return nil, err
 	}
 
-	bodyStr := string(bodyDecoded)
-	bodyStr = gohtml.Format(bodyStr)
-
-	bodyStr = hrefSrcBaseReg.ReplaceAllString(bodyStr, "$1=$2/exhibit/"+exhibit.Id+"/")
-	bodyStr = hrefSrcReg.ReplaceAllString(bodyStr, "$1=$2/exhibit/"+exhibit.Id+"/$3$4")
+	rewritten := r.getRewriter(exhibit, hostname).Body(contentType, bodyDecoded)
 
-	b, err := util.EncodeBody([]byte(bodyStr), encoding)
+	b, err := util.EncodeBody(rewritten, encoding)
 	if err != nil {
 		return nil, err
 	}
@@ -86,44 +71,64 @@ func (r *RewriteServiceImpl) RewriteServerResponse(exhibit domain.Exhibit, hostn
 	return &b, nil
 }
 
+// RewriteClientCookies removes the prefix of namespaced cookies and drops the ones of other exhibits,
+// unlike the rest of the request this happens whether the exhibit is rewritten or not
+func (r *RewriteServiceImpl) RewriteClientCookies(exhibit domain.Exhibit, hostname string, req *http.Request) {
+	rewriter := r.getRewriter(exhibit, hostname)
+	for i, value := range req.Header.Values("Cookie") {
+		req.Header["Cookie"][i] = rewriter.ReverseCookies(value)
+	}
+}
+
 func (r *RewriteServiceImpl) RewriteClientRequest(exhibit domain.Exhibit, hostname string, req *http.Request, body *[]byte) error {
 	// alright, so we have to rewrite the request
-	// 1: "http://localhost:8080/exhibit/123/foo/bar" changes to "http://ip:port/foo/bar"
-	// 2: "http://localhost:8080/foo/bar" changes to "http://ip:port/foo/bar"
+	// "http://localhost:8080/exhibit/123/foo/bar" changes to "http://ip:port/foo/bar"
+	rewriter := r.getRewriter(exhibit, hostname)
 
-	// get encoding from header
-	encoding := req.Header.Get("Content-Encoding")
-	bodyDecoded, err := util.DecodeBody(*body, encoding)
-	if err != nil {
-		r.Log.Warnw("error decoding body", "error", err, "requestId", exhibit.Id)
-		return err
+	for _, values := range req.Header {
+		for i, value := range values {
+			values[i] = rewriter.Reverse(value)
+		}
 	}
 
-	bodyStr, err := url.QueryUnescape(string(bodyDecoded))
-	if err != nil {
-		r.Log.Warnw("error unescaping body", "error", err, "requestId", exhibit.Id)
-		return err
+	req.RawQueryParams = rewriter.ReverseQuery(req.RawQueryParams)
+
+	// only ask the application for encodings we can rewrite
+	if req.Header.Get("Accept-Encoding") != "" {
+		req.Header.Set("Accept-Encoding", util.FilterAcceptEncoding(req.Header.Get("Accept-Encoding")))
 	}
 
-	// let's rewrite case 1
-	for k := range req.Header {
-		h := strings.ReplaceAll(req.Header.Get(k), r.getFqhn()+"/exhibit/"+exhibit.Id, hostname)
-		req.Header.Set(k, h)
+	if len(*body) == 0 {
+		return nil
 	}
 
-	*body, err = util.EncodeBody([]byte(bodyStr), encoding)
+	// get encoding from header
+	encoding := req.Header.Get("Content-Encoding")
+	if !util.IsSupportedEncoding(encoding) {
+		r.Log.Warnw("unsupported content encoding, not rewriting body", "encoding", encoding, "exhibitId", exhibit.Id)
+		return nil
+	}
+
+	bodyDecoded, err := util.DecodeBody(*body, encoding)
 	if err != nil {
-		r.Log.Warnw("error encoding body", "error", err, "requestId", exhibit.Id)
+		r.Log.Warnw("error decoding body", "error", err, "exhibitId", exhibit.Id)
 		return err
 	}
-	bodyStr = strings.ReplaceAll(bodyStr, r.getFqhn(), hostname)
 
-	b, err := util.EncodeBody([]byte(bodyStr), encoding)
+	// form bodies are encoded like query params (e.g. redirect_to of the WordPress login)
+	var rewritten string
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if contentType == "application/x-www-form-urlencoded" {
+		rewritten = rewriter.ReverseQuery(string(bodyDecoded))
+	} else {
+		rewritten = rewriter.Reverse(string(bodyDecoded))
+	}
+
+	*body, err = util.EncodeBody([]byte(rewritten), encoding)
 	if err != nil {
+		r.Log.Warnw("error encoding body", "error", err, "exhibitId", exhibit.Id)
 		return err
 	}
 
-	copy(*body, b)
-
 	return nil
 }
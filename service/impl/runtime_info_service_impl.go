@@ -35,3 +35,7 @@ func (r RuntimeInfoServiceImpl) GetRuntimeInfo(ctx context.Context, id string) (
 
 	return r.State.GetRuntimeInfo(ctx, id)
 }
+
+func (r RuntimeInfoServiceImpl) GetRuntimeInfoInsideLock(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error) {
+	return r.State.GetRuntimeInfo(ctx, id)
+}
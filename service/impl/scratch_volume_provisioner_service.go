@@ -0,0 +1,116 @@
+package impl
+
+import (
+	"context"
+	"io/fs"
+	"museum/domain"
+	service "museum/service/interface"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ScratchVolumeProvisionerService provisions a writable copy of a volume, the volume itself is never written to
+type ScratchVolumeProvisionerService struct {
+	Inner service.VolumeProvisionerService
+	volumeCopy
+}
+
+func (s ScratchVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
+	return s.Inner.CheckValidity(config)
+}
+
+func (s ScratchVolumeProvisionerService) ProvisionStorage(ctx context.Context, config domain.StringMap) (string, error) {
+	err := checkUnder(s.Root, s.Path)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := s.Inner.ProvisionStorage(ctx, config)
+	if err != nil {
+		return "", err
+	}
+
+	return s.provision(func(path string) error {
+		return copyTree(source, path)
+	})
+}
+
+func (s ScratchVolumeProvisionerService) DeprovisionStorage(ctx context.Context, config domain.StringMap) error {
+	err := s.remove()
+	if err != nil {
+		return err
+	}
+
+	return s.Inner.DeprovisionStorage(ctx, config)
+}
+
+// copyTree copies a directory with its permissions, owners and symlinks, anything else than files, directories and symlinks is skipped
+func copyTree(source string, target string) error {
+	dirs := make(map[string]fs.FileMode)
+
+	err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			// the permissions are set once the directory is filled, it may not be writable
+			err = os.Mkdir(dst, 0700)
+			dirs[dst] = info.Mode().Perm()
+		case entry.Type()&fs.ModeSymlink != 0:
+			var link string
+			link, err = os.Readlink(path)
+			if err == nil {
+				err = os.Symlink(link, dst)
+			}
+		case entry.Type().IsRegular():
+			err = copyFile(path, dst, info.Mode().Perm())
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			keepOwner(dst, int(stat.Uid), int(stat.Gid))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for dir, mode := range dirs {
+		err = os.Chmod(dir, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(source string, target string, mode fs.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return writeFile(in, target, mode)
+}
@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"context"
+	configimpl "museum/config/impl"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScratchVolumeProvisioner(t *testing.T) {
+	source := t.TempDir()
+	err := os.MkdirAll(filepath.Join(source, "data"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(source, "data", "db"), []byte("archived"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("data/db", filepath.Join(source, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	config := domain.StringMap{"path": source}
+	root := t.TempDir()
+	scratch := ScratchVolumeProvisionerService{
+		Inner:      LocalVolumeProvisionerService{},
+		volumeCopy: volumeCopy{Root: root, Path: filepath.Join(root, "1234", "data")},
+	}
+
+	path, err := scratch.ProvisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != scratch.Path {
+		t.Errorf("Expected the copy to be provisioned, got %s", path)
+	}
+
+	content, err := os.ReadFile(filepath.Join(path, "link"))
+	if err != nil || string(content) != "archived" {
+		t.Errorf("Expected the copy to keep files and symlinks, got %s (%v)", content, err)
+	}
+
+	if info, err := os.Stat(filepath.Join(path, "data", "db")); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("Expected the copy to keep permissions, got %v (%v)", info, err)
+	}
+
+	err = os.WriteFile(filepath.Join(path, "data", "db"), []byte("changed"), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = os.ReadFile(filepath.Join(source, "data", "db"))
+	if string(content) != "archived" {
+		t.Errorf("Expected the volume to be untouched, got %s", content)
+	}
+
+	// provisioning again starts from the volume, e.g. after a crash
+	_, err = scratch.ProvisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = os.ReadFile(filepath.Join(path, "data", "db"))
+	if string(content) != "archived" {
+		t.Errorf("Expected the copy to be reset, got %s", content)
+	}
+
+	err = scratch.DeprovisionStorage(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the copy and the directory of the exhibit to be removed, got %v", err)
+	}
+}
+
+func TestScratchVolumeOutsideOfRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	factory := VolumeProvisionerFactoryServiceImpl{Config: configimpl.EnvConfig{ProxyMode: "swarm", ScratchPath: root}}
+	if _, err := factory.GetForVolume("1234", domain.Volume{Name: "../../..", Driver: domain.Driver{Type: "local"}, Scratch: true}); err == nil {
+		t.Error("Expected a volume name leaving the scratch path to be rejected")
+	}
+
+	// even a provisioner made up by hand never removes anything outside of its root
+	scratch := ScratchVolumeProvisionerService{Inner: LocalVolumeProvisionerService{}, volumeCopy: volumeCopy{Root: root, Path: outside}}
+	if _, err := scratch.ProvisionStorage(context.Background(), domain.StringMap{"path": t.TempDir()}); err == nil {
+		t.Error("Expected a copy outside of the root to be rejected")
+	}
+
+	if err := scratch.DeprovisionStorage(context.Background(), domain.StringMap{}); err == nil {
+		t.Error("Expected a copy outside of the root not to be removed")
+	}
+
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("Expected the directory outside of the root to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	docker "github.com/docker/docker/client"
+	"museum/domain"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// smbVersionRegex matches smb protocol versions, e.g. 3.1.1
+var smbVersionRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// SmbVolumeProvisionerService mounts a share of an smb (cifs) server read only, through a docker volume
+type SmbVolumeProvisionerService struct {
+	// Client is the docker daemon running the objects, which mounts the share
+	Client *docker.Client
+	// Name is the name of the docker volume, it is empty if the provisioner only checks configs
+	Name   string
+	Labels map[string]string
+}
+
+func (s SmbVolumeProvisionerService) CheckValidity(config domain.StringMap) error {
+	if config["server"] == "" {
+		return errors.New("server is required")
+	}
+
+	share := strings.Trim(config["share"], "/")
+	if share == "" {
+		return errors.New("share is required")
+	}
+
+	for _, key := range []string{"server", "share", "username", "password", "domain", "credentials"} {
+		if err := checkMountValue(key, config[key]); err != nil {
+			return err
+		}
+	}
+
+	if config["password"] != "" && config["username"] == "" {
+		return errors.New("password needs a username")
+	}
+
+	// a credentials file keeps the password out of the exhibit, its revisions and the api
+	if c := config["credentials"]; c != "" {
+		if !filepath.IsAbs(c) {
+			return errors.New("credentials must be an absolute path on the docker host")
+		}
+
+		if config["username"] != "" || config["password"] != "" {
+			return errors.New("credentials replace username and password, use one or the other")
+		}
+	}
+
+	if v, ok := config["version"]; ok && !smbVersionRegex.MatchString(v) {
+		return errors.New("version must be an smb protocol version, e.g. 3.0")
+	}
+
+	return checkMountOptions(config["options"])
+}
+
+func (s SmbVolumeProvisionerService) ProvisionStorage(ctx context.Context, config domain.StringMap) (string, error) {
+	return provisionDockerVolume(ctx, s.Client, s.Name, s.Labels, smbDriverOptions(config))
+}
+
+func (s SmbVolumeProvisionerService) DeprovisionStorage(ctx context.Context, _ domain.StringMap) error {
+	return deprovisionDockerVolume(ctx, s.Client, s.Name)
+}
+
+// smbDriverOptions are the options of the local docker volume driver mounting a share, without a username
+// or a credentials file the share is mounted as guest
+func smbDriverOptions(config domain.StringMap) map[string]string {
+	credentials := "guest"
+	if config["credentials"] != "" {
+		credentials = "credentials=" + config["credentials"]
+	} else if config["username"] != "" {
+		credentials = mountOptions("username="+config["username"], "password="+config["password"])
+		if config["domain"] != "" {
+			credentials = mountOptions(credentials, "domain="+config["domain"])
+		}
+	}
+
+	version := ""
+	if v, ok := config["version"]; ok {
+		version = "vers=" + v
+	}
+
+	return map[string]string{
+		"type":   "cifs",
+		"o":      mountOptions("addr="+config["server"], "ro", credentials, version, config["options"]),
+		"device": "//" + config["server"] + "/" + strings.Trim(config["share"], "/"),
+	}
+}
@@ -0,0 +1,186 @@
+package impl
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeArchive writes a directory as gzipped tar with its permissions, owners and symlinks,
+// anything else than files, directories and symlinks is skipped
+func writeArchive(source string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && !entry.Type().IsRegular() && entry.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if entry.Type()&fs.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the owners are taken from the file as well
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// extractArchive writes the files of an archive written by writeArchive to a directory that doesn't exist yet
+func extractArchive(r io.Reader, target string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	err = os.Mkdir(target, 0700)
+	if err != nil {
+		return err
+	}
+
+	// the permissions of directories are set once they are filled, they may not be writable
+	dirs := map[string]fs.FileMode{target: 0755}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(target, filepath.FromSlash(header.Name))
+		if dst != target && !strings.HasPrefix(dst, target+string(filepath.Separator)) {
+			return errors.New("snapshot contains a path outside of it: " + header.Name)
+		}
+
+		// a symlink of the archive must not take the entries after it outside of the target
+		err = checkNoSymlinks(target, dst, header.Typeflag == tar.TypeDir)
+		if err != nil {
+			return errors.New("snapshot contains a path through a symlink: " + header.Name)
+		}
+
+		mode := fs.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dst, 0700)
+			dirs[dst] = mode
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, dst)
+		case tar.TypeReg:
+			err = writeFile(tr, dst, mode)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		keepOwner(dst, header.Uid, header.Gid)
+	}
+
+	for dir, mode := range dirs {
+		err = os.Chmod(dir, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNoSymlinks checks that none of the directories between target and path are symlinks, nor path itself if self is set
+func checkNoSymlinks(target string, path string, self bool) error {
+	rel, err := filepath.Rel(target, path)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !self {
+		parts = parts[:len(parts)-1]
+	}
+
+	dir := target
+	for _, part := range parts {
+		dir = filepath.Join(dir, part)
+
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return errors.New(dir + " is a symlink")
+		}
+	}
+
+	return nil
+}
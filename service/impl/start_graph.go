@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"context"
+	"museum/domain"
+	"museum/persistence"
+	"sync"
+)
+
+// startProgress is shared by the objects of an exhibit that start concurrently, it counts their steps
+// and guards the runtime info they fill in
+type startProgress struct {
+	mu       sync.Mutex
+	eventing persistence.Eventing
+	count    int
+}
+
+func newStartProgress(eventing persistence.Eventing) *startProgress {
+	return &startProgress{eventing: eventing, count: 1}
+}
+
+func (p *startProgress) dispatch(ctx context.Context, exhibit domain.Exhibit, step domain.ExhibitStartingStep) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.eventing.DispatchExhibitStartingEvent(ctx, exhibit, &p.count, step)
+}
+
+// update changes the runtime info of the exhibit (or anything else shared by the objects)
+func (p *startProgress) update(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f()
+}
+
+// snapshot returns the exhibit with a copy of its runtime info, which the other objects don't change
+func (p *startProgress) snapshot(exhibit domain.Exhibit) domain.Exhibit {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	runtimeInfo := *exhibit.RuntimeInfo
+	runtimeInfo.RelatedContainers = append([]string{}, runtimeInfo.RelatedContainers...)
+	exhibit.RuntimeInfo = &runtimeInfo
+	return exhibit
+}
+
+// startGraph starts every object of an exhibit as soon as the objects it depends on are started,
+// objects that don't wait for each other start concurrently. start gets the index of the object in exhibit.Objects,
+// the first error cancels the objects that are still starting and is returned once all of them are done
+func startGraph(ctx context.Context, exhibit domain.Exhibit, start func(ctx context.Context, idx int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indices := exhibit.StartedObjects()
+	dependencies := exhibit.Dependencies()
+
+	started := make(map[string]chan struct{})
+	for _, idx := range indices {
+		started[exhibit.Objects[idx].Name] = make(chan struct{})
+	}
+
+	errs := make(chan error, len(indices))
+	wg := sync.WaitGroup{}
+
+	for _, idx := range indices {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			name := exhibit.Objects[idx].Name
+			for _, dependency := range dependencies[name] {
+				// objects that aren't started can't be waited for, the validation doesn't let them through
+				c, ok := started[dependency]
+				if !ok {
+					continue
+				}
+
+				select {
+				case <-c:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			err := start(ctx, idx)
+			if err != nil {
+				errs <- err
+				cancel()
+				return
+			}
+
+			close(started[name])
+		}(idx)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
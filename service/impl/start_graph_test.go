@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"museum/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartGraphStartsIndependentObjectsConcurrently(t *testing.T) {
+	exhibit := domain.Exhibit{Objects: []domain.Object{
+		{Name: "web", DependsOn: []string{"db", "cache"}},
+		{Name: "db"},
+		{Name: "cache"},
+	}}
+
+	mu := sync.Mutex{}
+	running, maxRunning := 0, 0
+	started := make([]string, 0)
+
+	err := startGraph(context.Background(), exhibit, func(ctx context.Context, idx int) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		started = append(started, exhibit.Objects[idx].Name)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if maxRunning != 2 {
+		t.Errorf("Expected db and cache to start at the same time, at most %d objects started at once", maxRunning)
+	}
+
+	if len(started) != 3 || started[2] != "web" {
+		t.Errorf("Expected web to start last, got %v", started)
+	}
+}
+
+func TestStartGraphKeepsOrder(t *testing.T) {
+	exhibit := domain.Exhibit{
+		Objects: []domain.Object{{Name: "web"}, {Name: "db"}, {Name: "unused"}},
+		Order:   []string{"db", "web"},
+	}
+
+	started := make([]string, 0)
+	err := startGraph(context.Background(), exhibit, func(ctx context.Context, idx int) error {
+		started = append(started, exhibit.Objects[idx].Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(started) != 2 || started[0] != "db" || started[1] != "web" {
+		t.Errorf("Expected db and web to start in order, got %v", started)
+	}
+
+	if steps := exhibit.GetTotalSteps(); steps != 8 {
+		t.Errorf("Expected steps of the ordered objects only, got %d", steps)
+	}
+}
+
+func TestStartGraphStopsDependentsOnError(t *testing.T) {
+	exhibit := domain.Exhibit{Objects: []domain.Object{
+		{Name: "web", DependsOn: []string{"db"}},
+		{Name: "db"},
+	}}
+
+	failed := errors.New("db failed")
+	err := startGraph(context.Background(), exhibit, func(ctx context.Context, idx int) error {
+		if exhibit.Objects[idx].Name == "web" {
+			t.Error("Expected web not to start")
+		}
+		return failed
+	})
+
+	if !errors.Is(err, failed) {
+		t.Errorf("Expected the error of db, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// volumeCopy is a copy of a volume that is made fresh whenever the storage is provisioned and thrown away when it is deprovisioned
+type volumeCopy struct {
+	// Root is the directory keeping all copies, the copy is never made (or removed) outside of it
+	Root string
+	// Path is the directory of the copy
+	Path string
+}
+
+// provision replaces the copy with a new one, fill writes it to a path that doesn't exist yet
+func (c volumeCopy) provision(fill func(path string) error) (string, error) {
+	err := checkUnder(c.Root, c.Path)
+	if err != nil {
+		return "", err
+	}
+
+	// whatever was written during the last run is gone, even if the exhibit wasn't stopped cleanly
+	err = os.RemoveAll(c.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.Path), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = fill(c.Path)
+	if err != nil {
+		_ = os.RemoveAll(c.Path)
+		return "", err
+	}
+
+	return c.Path, nil
+}
+
+// remove removes the copy, the directory of the exhibit is only removed once none of its volumes are left
+func (c volumeCopy) remove() error {
+	err := checkUnder(c.Root, c.Path)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(c.Path)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(c.Path); checkUnder(c.Root, dir) == nil {
+		_ = os.Remove(dir)
+	}
+
+	return nil
+}
+
+// writeFile writes a new file with the given permissions
+func writeFile(r io.Reader, target string, mode fs.FileMode) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, r)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+
+	// the umask may have taken some of the permissions
+	return os.Chmod(target, mode)
+}
+
+// keepOwner sets the owners of a copied file if museum is allowed to, the objects may run as another user than museum
+func keepOwner(path string, uid int, gid int) {
+	_ = os.Lchown(path, uid, gid)
+}
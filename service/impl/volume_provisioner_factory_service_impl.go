@@ -2,17 +2,107 @@ package impl
 
 import (
 	"errors"
+	docker "github.com/docker/docker/client"
+	"museum/config"
+	proxymode "museum/config/proxy-mode"
+	"museum/domain"
 	service "museum/service/interface"
 )
 
 type VolumeProvisionerFactoryServiceImpl struct {
+	Config config.Config
+	// Client is the docker daemon the nfs and smb volumes are created on, it is nil on kubernetes
+	Client *docker.Client
 }
 
 func (v VolumeProvisionerFactoryServiceImpl) GetForDriverType(driver string) (service.VolumeProvisionerService, error) {
+	return v.get(driver, "", "")
+}
+
+// GetForVolume returns the provisioner of a volume, scratch and pinned volumes are copied to a directory of the exhibit
+func (v VolumeProvisionerFactoryServiceImpl) GetForVolume(exhibitId string, volume domain.Volume) (service.VolumeProvisionerService, error) {
+	provisioner, err := v.get(volume.Driver.Type, exhibitId, volume.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !volume.IsCopy() {
+		return provisioner, nil
+	}
+
+	if v.Config.GetProxyMode() == proxymode.ModeK8s {
+		return nil, errors.New("scratch and pinned volumes are not supported on kubernetes")
+	}
+
+	// the copy is made by museum, it needs the files of the volume
+	if volume.Driver.Type != "local" {
+		return nil, errors.New("scratch and pinned volumes need a local volume")
+	}
+
+	root := v.Config.GetScratchPath()
+	path, err := pathUnder(root, exhibitId, volume.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if volume.Snapshot != "" {
+		snapshots, ok := provisioner.(service.VolumeSnapshotter)
+		if !ok {
+			return nil, domain.ErrSnapshotsNotSupported
+		}
+
+		// the copy of the snapshot is thrown away anyway, a pinned scratch volume only needs to be writable
+		return &PinnedVolumeProvisionerService{Inner: provisioner, Snapshots: snapshots, Snapshot: volume.Snapshot, volumeCopy: volumeCopy{Root: root, Path: path}}, nil
+	}
+
+	return &ScratchVolumeProvisionerService{Inner: provisioner, volumeCopy: volumeCopy{Root: root, Path: path}}, nil
+}
+
+// get returns the provisioner of a driver, the exhibit and volume are empty if the provisioner only checks configs
+func (v VolumeProvisionerFactoryServiceImpl) get(driver string, exhibitId string, volume string) (service.VolumeProvisionerService, error) {
+	name := ""
+	labels := map[string]string{}
+	snapshots := ""
+	if exhibitId != "" {
+		name = dockerVolumeName(exhibitId, volume)
+		labels = map[string]string{"museum.exhibit-id": exhibitId, "museum.volume": volume}
+
+		var err error
+		snapshots, err = snapshotDir(v.Config.GetSnapshotPath(), exhibitId, volume)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	k8s := v.Config.GetProxyMode() == proxymode.ModeK8s
+
 	switch driver {
 	case "local":
-		return &LocalVolumeProvisionerService{}, nil
+		if k8s {
+			return nil, errors.New("local volumes are not supported on kubernetes, use a pvc volume")
+		}
+		return &LocalVolumeProvisionerService{SnapshotPath: snapshots}, nil
+	case "pvc":
+		if !k8s {
+			return nil, errors.New("pvc volumes are only supported on kubernetes")
+		}
+		return &PvcVolumeProvisionerService{}, nil
+	case "nfs":
+		if k8s {
+			return nil, errors.New("nfs volumes are not supported on kubernetes, use a pvc volume backed by nfs")
+		}
+		return &NfsVolumeProvisionerService{Client: v.Client, Name: name, Labels: labels}, nil
+	case "smb":
+		if k8s {
+			return nil, errors.New("smb volumes are not supported on kubernetes, use a pvc volume backed by smb")
+		}
+		return &SmbVolumeProvisionerService{Client: v.Client, Name: name, Labels: labels}, nil
 	default:
 		return nil, errors.New("unsupported driver type")
 	}
 }
+
+// scratchDir is the directory keeping the scratch copies of the volumes of an exhibit
+func scratchDir(config config.Config, exhibitId string) (string, error) {
+	return pathUnder(config.GetScratchPath(), exhibitId)
+}
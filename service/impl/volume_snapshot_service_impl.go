@@ -0,0 +1,137 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	service "museum/service/interface"
+	"museum/util"
+)
+
+type VolumeSnapshotServiceImpl struct {
+	ExhibitService           service.ExhibitService
+	LockService              service.LockService
+	RuntimeInfoService       service.RuntimeInfoService
+	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	Provider                 trace.TracerProvider
+	Log                      *zap.SugaredLogger
+}
+
+// snapshotter returns the volume of an exhibit and the provisioner taking its snapshots
+func (v VolumeSnapshotServiceImpl) snapshotter(ctx context.Context, exhibitId string, name string) (domain.Volume, service.VolumeSnapshotter, error) {
+	exhibit, err := v.ExhibitService.GetExhibitById(ctx, exhibitId)
+	if err != nil {
+		return domain.Volume{}, nil, err
+	}
+
+	volume := findVolume(exhibit, name)
+	if volume.Name == "" {
+		return domain.Volume{}, nil, fmt.Errorf("%w: %s", domain.ErrVolumeNotFound, name)
+	}
+
+	// snapshots are taken of the volume itself, not of its copy
+	provisioner, err := v.VolumeProvisionerFactory.GetForVolume(exhibitId, domain.Volume{Name: volume.Name, Driver: volume.Driver})
+	if err != nil {
+		return domain.Volume{}, nil, err
+	}
+
+	snapshotter, ok := provisioner.(service.VolumeSnapshotter)
+	if !ok {
+		return domain.Volume{}, nil, fmt.Errorf("%w: %s", domain.ErrSnapshotsNotSupported, volume.Driver.Type)
+	}
+
+	return volume, snapshotter, nil
+}
+
+func (v VolumeSnapshotServiceImpl) GetSnapshots(ctx context.Context, exhibitId string, volume string) ([]domain.Snapshot, error) {
+	subCtx, span := v.Provider.
+		Tracer("snapshot-service").
+		Start(ctx, "GetSnapshots("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.String("volume", volume)))
+	defer span.End()
+
+	vol, snapshotter, err := v.snapshotter(subCtx, exhibitId, volume)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshotter.GetSnapshots(subCtx, vol.Driver.Config)
+}
+
+// CreateSnapshot takes a snapshot of a volume, the objects can't change it while the exhibit runs as it is mounted read only
+func (v VolumeSnapshotServiceImpl) CreateSnapshot(ctx context.Context, exhibitId string, volume string, name string) (domain.Snapshot, error) {
+	subCtx, span := v.Provider.
+		Tracer("snapshot-service").
+		Start(ctx, "CreateSnapshot("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.String("volume", volume), attribute.String("snapshot", name)))
+	defer span.End()
+
+	vol, snapshotter, err := v.snapshotter(subCtx, exhibitId, volume)
+	if err != nil {
+		return domain.Snapshot{}, err
+	}
+
+	v.Log.Infow("creating snapshot", "exhibitId", exhibitId, "volume", volume, "snapshot", name)
+	return snapshotter.CreateSnapshot(subCtx, vol.Driver.Config, name)
+}
+
+// RestoreSnapshot replaces the files of a volume with a snapshot, the runtime_info lock keeps the exhibit from starting meanwhile
+func (v VolumeSnapshotServiceImpl) RestoreSnapshot(ctx context.Context, exhibitId string, volume string, name string) (err error) {
+	subCtx, span := v.Provider.
+		Tracer("snapshot-service").
+		Start(ctx, "RestoreSnapshot("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.String("volume", volume), attribute.String("snapshot", name)))
+	defer span.End()
+
+	vol, snapshotter, err := v.snapshotter(subCtx, exhibitId, volume)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("acquiring runtime_info lock")
+	lock := v.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock()
+	if err != nil {
+		return err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			err = e
+		}
+	}(lock)
+
+	runtimeInfo, err := v.RuntimeInfoService.GetRuntimeInfoInsideLock(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	err = checkTransition(runtimeInfo.Status, false)
+	if err != nil {
+		return err
+	}
+
+	v.Log.Infow("restoring snapshot", "exhibitId", exhibitId, "volume", volume, "snapshot", name)
+	return snapshotter.RestoreSnapshot(subCtx, vol.Driver.Config, name)
+}
+
+// DeleteSnapshot deletes a snapshot, unless its volume is pinned to it
+func (v VolumeSnapshotServiceImpl) DeleteSnapshot(ctx context.Context, exhibitId string, volume string, name string) error {
+	subCtx, span := v.Provider.
+		Tracer("snapshot-service").
+		Start(ctx, "DeleteSnapshot("+exhibitId+")", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.String("volume", volume), attribute.String("snapshot", name)))
+	defer span.End()
+
+	vol, snapshotter, err := v.snapshotter(subCtx, exhibitId, volume)
+	if err != nil {
+		return err
+	}
+
+	if vol.Snapshot == name {
+		return fmt.Errorf("%w: %s", domain.ErrSnapshotPinned, name)
+	}
+
+	v.Log.Infow("deleting snapshot", "exhibitId", exhibitId, "volume", volume, "snapshot", name)
+	return snapshotter.DeleteSnapshot(subCtx, vol.Driver.Config, name)
+}
@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"museum/domain"
+	service "museum/service/interface"
+	"path/filepath"
+	"strings"
+)
+
+// provisionVolumes provisions every volume of an exhibit once before its objects start, objects sharing a volume share its copy.
+// It returns the provisioned path of each volume by its name
+func provisionVolumes(ctx context.Context, factory service.VolumeProvisionerFactoryService, exhibit domain.Exhibit) (map[string]string, error) {
+	paths := make(map[string]string)
+
+	for _, volume := range exhibit.Volumes {
+		provisioner, err := factory.GetForVolume(exhibit.Id, volume)
+		if err != nil {
+			return nil, err
+		}
+
+		path, err := provisioner.ProvisionStorage(ctx, volume.Driver.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[volume.Name] = path
+	}
+
+	return paths, nil
+}
+
+// deprovisionVolumes deprovisions every volume of an exhibit, which throws away the copies of its scratch and pinned volumes
+func deprovisionVolumes(ctx context.Context, factory service.VolumeProvisionerFactoryService, exhibit domain.Exhibit) error {
+	for _, volume := range exhibit.Volumes {
+		provisioner, err := factory.GetForVolume(exhibit.Id, volume)
+		if err != nil {
+			return err
+		}
+
+		err = provisioner.DeprovisionStorage(ctx, volume.Driver.Config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// volumeBind returns the docker bind of a volume, only scratch volumes are writable
+func volumeBind(volume domain.Volume, path string, mount string) string {
+	if volume.Scratch {
+		return path + ":" + mount
+	}
+
+	return path + ":" + mount + ":ro"
+}
+
+// findVolume returns the volume of an exhibit with the given name
+func findVolume(exhibit domain.Exhibit, name string) domain.Volume {
+	for _, v := range exhibit.Volumes {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return domain.Volume{}
+}
+
+// pathUnder joins elements, e.g. the id of an exhibit and the name of a volume, to a root directory of museum.
+// The result has to be inside of root, museum removes these paths recursively
+func pathUnder(root string, elem ...string) (string, error) {
+	path := filepath.Join(append([]string{root}, elem...)...)
+	return path, checkUnder(root, path)
+}
+
+// checkUnder makes sure a path is inside of root and isn't root itself
+func checkUnder(root string, path string) error {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.New("path " + path + " is not inside of " + root)
+	}
+
+	return nil
+}
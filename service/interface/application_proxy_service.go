@@ -7,4 +7,8 @@ import (
 
 type ApplicationProxyService interface {
 	ForwardRequest(exhibit domain.Exhibit, path string, res *http.Response, req *http.Request) error
+	// EvictClient drops the pooled client of an exhibit, e.g. once it is deleted
+	EvictClient(exhibitId string)
+	// PruneClients drops the pooled clients of all exhibits not in the list, e.g. deleted by another instance
+	PruneClients(exhibits []domain.Exhibit)
 }
@@ -1,5 +1,8 @@
 package service
 
+import "context"
+
 type ExhibitCleanupService interface {
 	Cleanup() error
+	DeleteExhibit(ctx context.Context, exhibitId string, force bool) error
 }
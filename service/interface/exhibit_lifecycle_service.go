@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+type ExhibitLifecycleService interface {
+	StartExhibit(ctx context.Context, exhibitId string) error
+	StopExhibit(ctx context.Context, exhibitId string) error
+	RestartExhibit(ctx context.Context, exhibitId string) error
+	RenewExhibit(ctx context.Context, exhibitId string) (domain.Exhibit, error)
+}
@@ -3,12 +3,20 @@ package service
 import (
 	"context"
 	"museum/domain"
+	"museum/validation"
 )
 
 type ExhibitService interface {
 	GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error)
+	GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error)
 	GetAllExhibits(ctx context.Context) []domain.Exhibit
 	CreateExhibit(ctx context.Context, createExhibit domain.CreateExhibit) (string, error)
+	UpdateExhibit(ctx context.Context, updateExhibit domain.UpdateExhibit) (domain.Exhibit, error)
+	ValidateExhibit(ctx context.Context, exhibit *domain.Exhibit) error
+	PrepareExhibit(ctx context.Context, exhibit *domain.Exhibit) error
+	LintExhibit(ctx context.Context, content []byte) (domain.Exhibit, validation.Problems)
+	GetExhibitRevisions(ctx context.Context, id string) ([]domain.ExhibitRevision, error)
+	GetExhibitRevision(ctx context.Context, id string, revision int) (domain.ExhibitRevision, error)
 	DeleteExhibitById(ctx context.Context, id string) error
 	Count() int
 }
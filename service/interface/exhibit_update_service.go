@@ -0,0 +1,11 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+type ExhibitUpdateService interface {
+	UpdateExhibit(ctx context.Context, updateExhibit domain.UpdateExhibit) (domain.Exhibit, error)
+	RollbackExhibit(ctx context.Context, rollbackExhibit domain.RollbackExhibit) (domain.Exhibit, error)
+}
@@ -7,6 +7,8 @@ import (
 )
 
 type RewriteService interface {
+	RewriteServerResponseHeaders(exhibit domain.Exhibit, hostname string, res *gohttp.Response)
 	RewriteServerResponse(exhibit domain.Exhibit, hostname string, res *gohttp.Response, body *[]byte) (*[]byte, error)
 	RewriteClientRequest(exhibit domain.Exhibit, hostname string, req *http.Request, body *[]byte) error
+	RewriteClientCookies(exhibit domain.Exhibit, hostname string, req *http.Request)
 }
@@ -8,4 +8,6 @@ import (
 type RuntimeInfoService interface {
 	SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error
 	GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error)
+	// GetRuntimeInfoInsideLock reads the runtime info while the caller holds the runtime_info lock, which isn't reentrant
+	GetRuntimeInfoInsideLock(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error)
 }
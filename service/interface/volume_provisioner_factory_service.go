@@ -1,5 +1,9 @@
 package service
 
+import "museum/domain"
+
 type VolumeProvisionerFactoryService interface {
 	GetForDriverType(driver string) (VolumeProvisionerService, error)
+	// GetForVolume returns the provisioner of a volume of an exhibit, which takes care of its scratch copy as well
+	GetForVolume(exhibitId string, volume domain.Volume) (VolumeProvisionerService, error)
 }
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+type VolumeSnapshotService interface {
+	GetSnapshots(ctx context.Context, exhibitId string, volume string) ([]domain.Snapshot, error)
+	CreateSnapshot(ctx context.Context, exhibitId string, volume string, name string) (domain.Snapshot, error)
+	// RestoreSnapshot replaces the files of a volume with the ones of a snapshot, the exhibit has to be stopped
+	RestoreSnapshot(ctx context.Context, exhibitId string, volume string, name string) error
+	DeleteSnapshot(ctx context.Context, exhibitId string, volume string, name string) error
+}
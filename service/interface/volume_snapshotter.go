@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// VolumeSnapshotter is implemented by the volume provisioners that can take snapshots of their volumes
+type VolumeSnapshotter interface {
+	GetSnapshots(ctx context.Context, config domain.StringMap) ([]domain.Snapshot, error)
+	CreateSnapshot(ctx context.Context, config domain.StringMap, name string) (domain.Snapshot, error)
+	// RestoreSnapshot replaces the files of the volume with the ones of a snapshot
+	RestoreSnapshot(ctx context.Context, config domain.StringMap, name string) error
+	DeleteSnapshot(ctx context.Context, config domain.StringMap, name string) error
+	// ExtractSnapshot writes the files of a snapshot to a directory that doesn't exist yet
+	ExtractSnapshot(ctx context.Context, config domain.StringMap, name string, target string) error
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"museum/config"
+)
+
+func NewKubernetesClient(config config.Config, log *zap.SugaredLogger) kubernetes.Interface {
+	// without a kubeconfig the service account of the pod museum runs in is used
+	restConfig, err := clientcmd.BuildConfigFromFlags("", config.GetKubeconfig())
+	if err != nil {
+		log.Panicw("failed to load kubernetes config", "error", err)
+	}
+
+	c, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		log.Panicw("failed to create kubernetes client", "error", err)
+	}
+
+	version, err := c.Discovery().ServerVersion()
+	if err != nil {
+		log.Panicw("failed to get kubernetes version", "error", err)
+	}
+
+	log.Debugw("connected to kubernetes", "host", restConfig.Host, "version", version.GitVersion, "namespace", config.GetK8sNamespace())
+
+	return c
+}
@@ -1,12 +1,17 @@
 package service
 
 import (
+	docker "github.com/docker/docker/client"
+	"museum/config"
 	"museum/service/impl"
 	service "museum/service/interface"
 )
 
 type VolumeProvisionerFactoryService service.VolumeProvisionerFactoryService
 
-func NewVolumeProvisionerFactoryService() VolumeProvisionerFactoryService {
-	return &impl.VolumeProvisionerFactoryServiceImpl{}
+func NewVolumeProvisionerFactoryService(config config.Config, client *docker.Client) VolumeProvisionerFactoryService {
+	return &impl.VolumeProvisionerFactoryServiceImpl{
+		Config: config,
+		Client: client,
+	}
 }
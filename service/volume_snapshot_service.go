@@ -0,0 +1,21 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type VolumeSnapshotService service.VolumeSnapshotService
+
+func NewVolumeSnapshotService(exhibitService service.ExhibitService, lockService service.LockService, runtimeInfoService service.RuntimeInfoService, volumeProvisionerFactory service.VolumeProvisionerFactoryService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) VolumeSnapshotService {
+	return &impl.VolumeSnapshotServiceImpl{
+		ExhibitService:           exhibitService,
+		LockService:              lockService,
+		RuntimeInfoService:       runtimeInfoService,
+		VolumeProvisionerFactory: volumeProvisionerFactory,
+		Provider:                 factory.Build("snapshot-service"),
+		Log:                      log,
+	}
+}
@@ -0,0 +1,106 @@
+package spec
+
+import (
+	"museum/domain"
+	"reflect"
+	"strings"
+)
+
+// required are the keys a definition must have, the schema can't tell them from the go types
+var required = map[reflect.Type][]string{
+	reflect.TypeOf(domain.Exhibit{}):   {"spec", "name", "expose", "lease", "objects"},
+	reflect.TypeOf(domain.Object{}):    {"name", "image"},
+	reflect.TypeOf(domain.Livecheck{}): {"type"},
+	reflect.TypeOf(domain.Volume{}):    {"name", "driver"},
+	reflect.TypeOf(domain.Driver{}):    {"type"},
+}
+
+// overrides replace the generated schema of single keys, e.g. to restrict them to known values
+var overrides = map[reflect.Type]map[string]map[string]any{
+	reflect.TypeOf(domain.Livecheck{}): {
+		"type": {"enum": []string{domain.LivecheckTypeHttp, domain.LivecheckTypeExec}},
+	},
+	reflect.TypeOf(domain.Object{}): {
+		"port": {"type": []string{"string", "integer"}},
+	},
+	reflect.TypeOf(domain.Resources{}): {
+		"cpus":   {"type": []string{"string", "number"}},
+		"memory": {"type": []string{"string", "integer"}},
+	},
+}
+
+// Schema returns the JSON Schema of a spec version, it is generated from the type definitions of the version are decoded into
+func Schema(name string) (map[string]any, error) {
+	err := Check(name)
+	if err != nil {
+		return nil, err
+	}
+
+	i, _ := find(name)
+	schema := typeSchema(versions[i].exhibit)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "mūsēum exhibit (spec " + name + ")"
+	schema["properties"].(map[string]any)["spec"] = map[string]any{"const": name}
+
+	return schema, nil
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		// yaml reads any scalar into a string, e.g. environment values don't have to be quoted
+		if t.Elem().Kind() == reflect.String {
+			return map[string]any{"type": "object", "additionalProperties": map[string]any{"type": []string{"string", "number", "boolean"}}}
+		}
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	default:
+		// interfaces (e.g. meta) take anything
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// fields without a yaml tag (e.g. the id) are set by the server, they don't belong in a file
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		if override, ok := overrides[t][name]; ok {
+			properties[name] = override
+			continue
+		}
+
+		properties[name] = typeSchema(field.Type)
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if keys, ok := required[t]; ok {
+		schema["required"] = keys
+	}
+
+	return schema
+}
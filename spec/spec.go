@@ -0,0 +1,136 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"museum/domain"
+	"reflect"
+	"strings"
+)
+
+const V1 = "v1"
+
+// Current is the spec version exhibits are stored and validated in, files of older versions are upgraded on read
+const Current = V1
+
+// ErrMissingSpec is returned for exhibit definitions that don't declare their spec version
+var ErrMissingSpec = errors.New("exhibit must declare its spec version (e.g. spec: " + Current + ")")
+
+// ErrUnsupportedSpec is returned for spec versions this version of museum doesn't know
+var ErrUnsupportedSpec = errors.New("unsupported spec version")
+
+type version struct {
+	name string
+	// exhibit is the type a definition of this version is decoded into, the schema is generated from it
+	exhibit reflect.Type
+	// upgrade converts a definition of this version to the next one, the current version has none
+	upgrade func(document *yaml.Node) error
+}
+
+// versions are all supported spec versions, oldest first, an upgrade goes through every version in between
+var versions = []version{
+	{name: V1, exhibit: reflect.TypeOf(domain.Exhibit{})},
+}
+
+// Versions returns the names of all supported spec versions, oldest first
+func Versions() []string {
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.name
+	}
+
+	return names
+}
+
+func find(name string) (int, bool) {
+	for i, v := range versions {
+		if v.name == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Check returns an error if a spec version is missing or unsupported
+func Check(name string) error {
+	if name == "" {
+		return ErrMissingSpec
+	}
+
+	if _, ok := find(name); !ok {
+		return fmt.Errorf("%w %s, must be one of: %s", ErrUnsupportedSpec, name, strings.Join(Versions(), ", "))
+	}
+
+	return nil
+}
+
+// VersionNode returns the value of the spec key of a document, nil if there is none
+func VersionNode(document *yaml.Node) *yaml.Node {
+	if document.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		if document.Content[i].Value == "spec" {
+			return document.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// Upgrade converts a definition to the current spec version in place, the nodes keep their positions in the file
+func Upgrade(document *yaml.Node) error {
+	node := VersionNode(document)
+	if node == nil {
+		return ErrMissingSpec
+	}
+
+	err := Check(node.Value)
+	if err != nil {
+		return err
+	}
+
+	i, _ := find(node.Value)
+	for ; i < len(versions)-1; i++ {
+		err = versions[i].upgrade(document)
+		if err != nil {
+			return fmt.Errorf("error upgrading from spec %s to %s: %w", versions[i].name, versions[i+1].name, err)
+		}
+
+		// the upgrade may have rebuilt the document, the spec node is looked up again
+		node = VersionNode(document)
+		if node == nil {
+			return errors.New("upgrade to spec " + versions[i+1].name + " dropped the spec key")
+		}
+		node.Value = versions[i+1].name
+	}
+
+	return nil
+}
+
+// Decode reads an exhibit definition of any supported spec version from yaml (or json), in the current spec version
+func Decode(content []byte) (domain.Exhibit, error) {
+	exhibit := domain.Exhibit{}
+
+	root := &yaml.Node{}
+	err := yaml.Unmarshal(content, root)
+	if err != nil {
+		return exhibit, err
+	}
+
+	if len(root.Content) == 0 {
+		return exhibit, errors.New("exhibit definition is empty")
+	}
+
+	document := root.Content[0]
+	err = Upgrade(document)
+	if err != nil {
+		return exhibit, err
+	}
+
+	err = document.Decode(&exhibit)
+	return exhibit, err
+}
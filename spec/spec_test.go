@@ -0,0 +1,92 @@
+package spec
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeGoesThroughEveryVersion(t *testing.T) {
+	previous := versions
+	defer func() { versions = previous }()
+
+	// a made up v2 renames lease to ttl
+	versions = []version{
+		{name: V1, upgrade: func(document *yaml.Node) error {
+			for i := 0; i+1 < len(document.Content); i += 2 {
+				if document.Content[i].Value == "lease" {
+					document.Content[i].Value = "ttl"
+				}
+			}
+			return nil
+		}},
+		{name: "v2"},
+	}
+
+	root := &yaml.Node{}
+	err := yaml.Unmarshal([]byte("spec: v1\nname: test\nlease: 10m\n"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Upgrade(root.Content[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgraded := map[string]string{}
+	err = root.Content[0].Decode(&upgraded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"spec": "v2", "name": "test", "ttl": "10m"}
+	if !reflect.DeepEqual(upgraded, expected) {
+		t.Errorf("Expected %v, got %v", expected, upgraded)
+	}
+}
+
+func TestDecodeChecksSpec(t *testing.T) {
+	_, err := Decode([]byte("name: test\n"))
+	if !errors.Is(err, ErrMissingSpec) {
+		t.Errorf("Expected ErrMissingSpec, got %v", err)
+	}
+
+	_, err = Decode([]byte("spec: v0\nname: test\n"))
+	if !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("Expected ErrUnsupportedSpec, got %v", err)
+	}
+
+	exhibit, err := Decode([]byte(`{"spec": "v1", "name": "test"}`))
+	if err != nil || exhibit.Spec != V1 || exhibit.Name != "test" {
+		t.Errorf("Expected json to be decoded, got %v, %v", exhibit, err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := Schema(V1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	properties := schema["properties"].(map[string]any)
+	if !reflect.DeepEqual(properties["spec"], map[string]any{"const": V1}) {
+		t.Errorf("Expected spec to be fixed to %s, got %v", V1, properties["spec"])
+	}
+
+	if _, ok := properties["id"]; ok {
+		t.Errorf("Expected the id to be left out")
+	}
+
+	object := properties["objects"].(map[string]any)["items"].(map[string]any)
+	livecheck := object["properties"].(map[string]any)["livecheck"].(map[string]any)
+	if livecheck["properties"].(map[string]any)["type"] == nil || !reflect.DeepEqual(livecheck["required"], []string{"type"}) {
+		t.Errorf("Expected the livecheck type to be required, got %v", livecheck)
+	}
+
+	_, err = Schema("v0")
+	if !errors.Is(err, ErrUnsupportedSpec) {
+		t.Errorf("Expected ErrUnsupportedSpec, got %v", err)
+	}
+}
@@ -2,22 +2,158 @@ package util
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"io"
+	"strings"
+	"sync"
 )
 
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// SupportedEncodings are the content encodings that can be decoded and encoded again
+var SupportedEncodings = []string{"gzip", "deflate", "br", "zstd"}
+
+// zstd encoders and decoders are expensive to create, but safe for concurrent use with EncodeAll and DecodeAll
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil)
+})
+
+// DecodeBody decodes a body with the given Content-Encoding
+// multiple encodings (e.g. "gzip, br") are removed in the reverse order they were applied in
 func DecodeBody(body []byte, encoding string) (b []byte, err error) {
+	encodings := splitEncodings(encoding)
+
+	b = body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		b, err = decode(b, encodings[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// EncodeBody encodes a body with the given Content-Encoding
+func EncodeBody(body []byte, encoding string) (b []byte, err error) {
+	b = body
+	for _, e := range splitEncodings(encoding) {
+		b, err = encode(b, e)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// IsSupportedEncoding checks if a body with the given Content-Encoding can be decoded and encoded again
+func IsSupportedEncoding(encoding string) bool {
+	for _, e := range splitEncodings(encoding) {
+		if !isSupported(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FilterAcceptEncoding removes all encodings from an Accept-Encoding header that can't be decoded
+// so an upstream server only answers with encodings that can be rewritten, e.g. "gzip, compress, br;q=0.8" -> "gzip, br;q=0.8"
+func FilterAcceptEncoding(acceptEncoding string) string {
+	accepted := make([]string, 0)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, _, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		if coding == "identity" || isSupported(coding) {
+			accepted = append(accepted, strings.TrimSpace(part))
+		}
+	}
+
+	if len(accepted) == 0 {
+		return "identity"
+	}
+
+	return strings.Join(accepted, ", ")
+}
+
+func splitEncodings(encoding string) []string {
+	encodings := make([]string, 0)
+	for _, e := range strings.Split(encoding, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || e == "identity" {
+			continue
+		}
+
+		encodings = append(encodings, e)
+	}
+
+	return encodings
+}
+
+func isSupported(encoding string) bool {
+	if encoding == "x-gzip" {
+		return true
+	}
+
+	for _, e := range SupportedEncodings {
+		if e == encoding {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decode(body []byte, encoding string) ([]byte, error) {
 	switch encoding {
-	case "gzip":
+	case "gzip", "x-gzip":
 		return decodeGzip(body)
 	case "deflate":
-		panic("deflate not implemented")
-	case "compress":
-		panic("compress not implemented")
+		return decodeDeflate(body)
+	case "br":
+		return io.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+	case "zstd":
+		decoder, err := zstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(body, nil)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+func encode(body []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return encodeGzip(body)
+	case "deflate":
+		return encodeWith(body, func(w io.Writer) io.WriteCloser {
+			return zlib.NewWriter(w)
+		})
 	case "br":
-		panic("br not implemented")
+		return encodeWith(body, func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriter(w)
+		})
+	case "zstd":
+		encoder, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(body, nil), nil
 	default:
-		return body, nil
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
 	}
 }
 
@@ -39,25 +175,42 @@ func decodeGzip(body []byte) (b []byte, err error) {
 	return io.ReadAll(gzipReader)
 }
 
-func EncodeBody(body []byte, encoding string) (b []byte, err error) {
-	switch encoding {
-	case "gzip":
-		return encodeGzip(body)
-	default:
-		return body, nil
+// decodeDeflate decodes a zlib wrapped deflate stream (as the spec says),
+// falling back to a raw deflate stream (as some servers send it anyway)
+func decodeDeflate(body []byte) ([]byte, error) {
+	zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+	if err == nil {
+		b, err := io.ReadAll(zlibReader)
+		_ = zlibReader.Close()
+		if err == nil {
+			return b, nil
+		}
 	}
+
+	flateReader := flate.NewReader(bytes.NewReader(body))
+	defer func(flateReader io.ReadCloser) {
+		_ = flateReader.Close()
+	}(flateReader)
+
+	return io.ReadAll(flateReader)
+}
+
+func encodeGzip(body []byte) ([]byte, error) {
+	return encodeWith(body, func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	})
 }
 
-func encodeGzip(body []byte) (b []byte, err error) {
+func encodeWith(body []byte, newWriter func(w io.Writer) io.WriteCloser) ([]byte, error) {
 	buffer := bytes.Buffer{}
-	gzipWriter := gzip.NewWriter(&buffer)
+	writer := newWriter(&buffer)
 
-	_, err = gzipWriter.Write(body)
+	_, err := writer.Write(body)
 	if err != nil {
 		return nil, err
 	}
 
-	err = gzipWriter.Close()
+	err = writer.Close()
 	if err != nil {
 		return nil, err
 	}
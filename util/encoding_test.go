@@ -0,0 +1,52 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncodeDecodeBody(t *testing.T) {
+	body := []byte("<html><body><a href=\"/foo\">foo</a></body></html>")
+
+	for _, encoding := range []string{"", "identity", "gzip", "x-gzip", "deflate", "br", "zstd", "gzip, br"} {
+		encoded, err := EncodeBody(body, encoding)
+		if err != nil {
+			t.Fatalf("Expected %q to be encoded, got %v", encoding, err)
+		}
+
+		decoded, err := DecodeBody(encoded, encoding)
+		if err != nil {
+			t.Fatalf("Expected %q to be decoded, got %v", encoding, err)
+		}
+
+		if string(decoded) != string(body) {
+			t.Errorf("Expected %q to round trip, got %q", encoding, decoded)
+		}
+	}
+}
+
+func TestDecodeUnsupportedBody(t *testing.T) {
+	_, err := DecodeBody([]byte("foo"), "compress")
+	if !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected unsupported encoding error, got %v", err)
+	}
+
+	if IsSupportedEncoding("gzip, compress") {
+		t.Errorf("Expected gzip, compress to be unsupported")
+	}
+}
+
+func TestFilterAcceptEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gzip, deflate, br, zstd":     "gzip, deflate, br, zstd",
+		"gzip, compress, br;q=0.8":    "gzip, br;q=0.8",
+		"compress, *":                 "identity",
+		"GZIP;q=1.0, identity; q=0.5": "GZIP;q=1.0, identity; q=0.5",
+	}
+
+	for input, expected := range tests {
+		if actual := FilterAcceptEncoding(input); actual != expected {
+			t.Errorf("Expected %q to be filtered to %q, got %q", input, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package rewrite
+
+import (
+	"regexp"
+)
+
+// url(...) with or without quotes
+var cssUrlReg = regexp.MustCompile(`(?i)(url\(\s*)(['"]?)([^'")]*)(['"]?)(\s*\))`)
+
+// @import "..." without url(...)
+var cssImportReg = regexp.MustCompile(`(?i)(@import\s+)(['"])([^'"]*)(['"])`)
+
+// CSS rewrites the urls inside a stylesheet or an inline style attribute
+func (r Rewriter) CSS(body []byte) []byte {
+	body = r.replaceSubmatch(cssUrlReg, body)
+	return r.replaceSubmatch(cssImportReg, body)
+}
+
+// replaceSubmatch rewrites the third group of every match as a url, keeping the rest of the match as is
+func (r Rewriter) replaceSubmatch(reg *regexp.Regexp, body []byte) []byte {
+	return reg.ReplaceAllFunc(body, func(match []byte) []byte {
+		groups := reg.FindSubmatch(match)
+		u := string(groups[3])
+		rewritten := r.URL(u)
+		if rewritten == u {
+			return match
+		}
+
+		out := make([]byte, 0, len(match)+len(rewritten)-len(u))
+		out = append(out, groups[1]...)
+		out = append(out, groups[2]...)
+		out = append(out, rewritten...)
+		out = append(out, groups[4]...)
+		if len(groups) > 5 {
+			out = append(out, groups[5]...)
+		}
+
+		return out
+	})
+}
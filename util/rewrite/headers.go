@@ -0,0 +1,136 @@
+package rewrite
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// cookies namespaced for an exhibit, see CookiePrefix
+var namespacedCookieReg = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}_`)
+
+// headers that contain a single url
+var urlHeaders = []string{"Location", "Content-Location"}
+
+// Headers rewrites the urls inside the headers of a response
+func (r Rewriter) Headers(header http.Header) {
+	for _, name := range urlHeaders {
+		values := header.Values(name)
+		for i, value := range values {
+			values[i] = r.URL(value)
+		}
+	}
+
+	refresh := header.Values("Refresh")
+	for i, value := range refresh {
+		refresh[i] = r.refresh(value)
+	}
+
+	links := header.Values("Link")
+	for i, value := range links {
+		links[i] = r.link(value)
+	}
+
+	cookies := header.Values("Set-Cookie")
+	for i, value := range cookies {
+		cookies[i] = r.cookie(value)
+	}
+}
+
+// link rewrites the urls of a Link header, e.g. </style.css>; rel=preload, </app.js>; rel=preload
+func (r Rewriter) link(value string) string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		start := strings.Index(part, "<")
+		end := strings.Index(part, ">")
+		if start < 0 || end < start {
+			continue
+		}
+
+		parts[i] = part[:start+1] + r.URL(part[start+1:end]) + part[end:]
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// cookie rewrites a Set-Cookie header, so the cookie is only sent along with requests to the exhibit
+// the Path is scoped under the base path, the Domain is set to the public host and the name gets the cookie prefix
+func (r Rewriter) cookie(value string) string {
+	attributes := strings.Split(value, ";")
+	rewritten := make([]string, 0, len(attributes))
+
+	for i, attribute := range attributes {
+		key, val, found := strings.Cut(attribute, "=")
+
+		// the first pair is the cookie itself
+		if i == 0 {
+			if found && r.CookiePrefix != "" {
+				attribute = r.CookiePrefix + strings.TrimSpace(key) + "=" + val
+			}
+			rewritten = append(rewritten, attribute)
+			continue
+		}
+
+		switch {
+		case found && strings.EqualFold(strings.TrimSpace(key), "path"):
+			val = strings.TrimSpace(val)
+			if strings.HasPrefix(val, "/") {
+				attribute = key + "=" + r.prefix(val)
+			}
+		case found && strings.EqualFold(strings.TrimSpace(key), "domain"):
+			domain := r.cookieDomain()
+			if domain == "" {
+				// a host-only cookie is sent to the public host only
+				continue
+			}
+			attribute = key + "=" + domain
+		}
+
+		rewritten = append(rewritten, attribute)
+	}
+
+	return strings.Join(rewritten, ";")
+}
+
+// cookieDomain returns the Domain cookies are set for, empty if browsers don't accept a Domain for the public host (e.g. localhost or an ip)
+func (r Rewriter) cookieDomain() string {
+	host := r.PublicHost
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return ""
+	}
+
+	return host
+}
+
+// ReverseCookies rewrites a Cookie header sent by the client
+// if cookies are namespaced, the prefix is removed and cookies of other exhibits are dropped
+func (r Rewriter) ReverseCookies(value string) string {
+	if r.CookiePrefix == "" {
+		return value
+	}
+
+	cookies := make([]string, 0)
+	for _, cookie := range strings.Split(value, ";") {
+		cookie = strings.TrimSpace(cookie)
+		if cookie == "" {
+			continue
+		}
+
+		if strings.HasPrefix(cookie, r.CookiePrefix) {
+			cookies = append(cookies, strings.TrimPrefix(cookie, r.CookiePrefix))
+			continue
+		}
+
+		// cookies without a prefix were set by scripts
+		if !namespacedCookieReg.MatchString(cookie) {
+			cookies = append(cookies, cookie)
+		}
+	}
+
+	return strings.Join(cookies, "; ")
+}
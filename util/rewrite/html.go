@@ -0,0 +1,180 @@
+package rewrite
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/net/html"
+	"io"
+	"strings"
+)
+
+// attributes that contain a single url
+var urlAttributes = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"data":       true,
+	"background": true,
+	"cite":       true,
+	"longdesc":   true,
+	"manifest":   true,
+	"icon":       true,
+	"xlink:href": true,
+}
+
+// HTML rewrites the urls inside an html document
+// tokens that don't contain a url are written unmodified, so the document keeps its original formatting
+func (r Rewriter) HTML(body []byte) []byte {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	out := bytes.Buffer{}
+	out.Grow(len(body))
+
+	// the shim is only injected into whole documents, not into fragments loaded by scripts
+	injected := r.BasePath == ""
+	document := false
+	rawTag := ""
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if !errors.Is(z.Err(), io.EOF) {
+				// the tokenizer gave up, better send the rest as is than nothing at all
+				out.Write(z.Raw())
+			}
+			return out.Bytes()
+		}
+
+		// the raw bytes have to be copied, they are invalidated by the call to Token
+		raw := append([]byte(nil), z.Raw()...)
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if token.Data == "html" || token.Data == "head" {
+				document = true
+			}
+
+			if !injected && document && token.Data != "html" && token.Data != "head" {
+				out.WriteString(r.shimTag())
+				injected = true
+			}
+
+			if r.rewriteTag(&token) {
+				out.WriteString(token.String())
+			} else {
+				out.Write(raw)
+			}
+
+			if !injected && token.Data == "head" {
+				out.WriteString(r.shimTag())
+				injected = true
+			}
+
+			if tt == html.StartTagToken && (token.Data == "style" || token.Data == "script") {
+				rawTag = token.Data
+			}
+		case html.EndTagToken:
+			rawTag = ""
+			out.Write(raw)
+		case html.TextToken:
+			switch rawTag {
+			case "style":
+				out.Write(r.CSS(raw))
+			case "script":
+				out.Write(r.Script(raw))
+			default:
+				out.Write(raw)
+			}
+		case html.DoctypeToken:
+			document = true
+			out.Write(raw)
+		default:
+			out.Write(raw)
+		}
+	}
+}
+
+// rewriteTag rewrites the url attributes of a tag and reports if anything changed
+func (r Rewriter) rewriteTag(token *html.Token) bool {
+	changed := false
+	refresh := isMetaRefresh(token)
+
+	for i, attr := range token.Attr {
+		key := strings.ToLower(attr.Key)
+		value := attr.Val
+
+		switch {
+		case urlAttributes[key]:
+			value = r.URL(value)
+		case key == "srcset" || key == "imagesrcset":
+			value = r.srcset(value)
+		case key == "style":
+			value = string(r.CSS([]byte(value)))
+		case key == "content" && refresh:
+			value = r.refresh(value)
+		default:
+			continue
+		}
+
+		if value != attr.Val {
+			token.Attr[i].Val = value
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func isMetaRefresh(token *html.Token) bool {
+	if token.Data != "meta" {
+		return false
+	}
+
+	for _, attr := range token.Attr {
+		if strings.EqualFold(attr.Key, "http-equiv") && strings.EqualFold(strings.TrimSpace(attr.Val), "refresh") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// srcset rewrites a list of image candidates, e.g. "/a.png 1x, /b.png 2x"
+func (r Rewriter) srcset(value string) string {
+	candidates := strings.Split(value, ",")
+	for i, candidate := range candidates {
+		trimmed := strings.TrimSpace(candidate)
+		if trimmed == "" {
+			continue
+		}
+
+		u, _, _ := strings.Cut(trimmed, " ")
+		rewritten := r.URL(u)
+		if rewritten == u {
+			continue
+		}
+
+		candidates[i] = strings.Replace(candidate, u, rewritten, 1)
+	}
+
+	return strings.Join(candidates, ",")
+}
+
+// refresh rewrites the url of a refresh instruction, e.g. "5; url=/foo"
+func (r Rewriter) refresh(value string) string {
+	i := strings.Index(strings.ToLower(value), "url=")
+	if i < 0 {
+		return value
+	}
+
+	u := strings.TrimSpace(value[i+len("url="):])
+	quote := ""
+	if len(u) > 1 && (u[0] == '\'' || u[0] == '"') && u[len(u)-1] == u[0] {
+		quote = u[:1]
+		u = u[1 : len(u)-1]
+	}
+
+	return value[:i+len("url=")] + quote + r.URL(u) + quote
+}
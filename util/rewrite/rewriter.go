@@ -0,0 +1,221 @@
+package rewrite
+
+import (
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Kind describes how the body of a response can be rewritten
+type Kind int
+
+const (
+	KindNone Kind = iota
+	KindHTML
+	KindCSS
+	KindScript
+)
+
+// KindOf returns the kind of body for a Content-Type header
+func KindOf(contentType string) Kind {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return KindNone
+	}
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return KindHTML
+	case "text/css":
+		return KindCSS
+	case "text/javascript", "application/javascript", "application/x-javascript", "application/ecmascript", "application/json":
+		return KindScript
+	default:
+		return KindNone
+	}
+}
+
+// CanRewrite checks if a body with the given Content-Type can be rewritten
+func CanRewrite(contentType string) bool {
+	return KindOf(contentType) != KindNone
+}
+
+// Rewriter rewrites the URLs of an application running behind the proxy,
+// so they point to the exhibit as it is seen by the client
+//
+//	"http://172.17.0.3:80/foo" -> "http://localhost:8080/exhibit/123/foo"
+//	"http://localhost:8080/foo" -> "http://localhost:8080/exhibit/123/foo"
+//	"/foo" -> "/exhibit/123/foo"
+//	"/exhibit/123/foo" -> "/exhibit/123/foo"
+//	"foo" -> "foo" (relative urls are resolved by the browser)
+type Rewriter struct {
+	// BasePath is the path the exhibit is served at (e.g. /exhibit/123), empty if the exhibit has its own host
+	BasePath string
+	// PublicHost is the host the exhibit is served at (e.g. localhost:8080 or my-exhibit.localhost:8080)
+	PublicHost string
+	// UpstreamHost is the address of the application (e.g. 172.17.0.3:80)
+	UpstreamHost string
+	// CookiePrefix is prepended to the names of cookies set by the application (the exhibit id followed by an underscore),
+	// empty if cookies are not namespaced
+	CookiePrefix string
+}
+
+// Body rewrites a decoded body according to its Content-Type
+func (r Rewriter) Body(contentType string, body []byte) []byte {
+	switch KindOf(contentType) {
+	case KindHTML:
+		return r.HTML(body)
+	case KindCSS:
+		return r.CSS(body)
+	case KindScript:
+		return r.Script(body)
+	default:
+		return body
+	}
+}
+
+// URL rewrites a single url, urls that don't belong to the application are returned as is
+func (r Rewriter) URL(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return raw
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return raw
+	}
+
+	// data:, javascript:, mailto: and friends
+	switch u.Scheme {
+	case "", "http", "https", "ws", "wss":
+	default:
+		return raw
+	}
+
+	changed := false
+	if u.Host != "" {
+		if !r.isInternal(u.Scheme, u.Host) {
+			return raw
+		}
+
+		changed = u.Host != r.PublicHost
+		u.Host = r.PublicHost
+	} else if u.Scheme != "" || u.Opaque != "" {
+		return raw
+	}
+
+	// relative paths are resolved against the (already prefixed) document url by the browser
+	if u.Host != "" || strings.HasPrefix(u.Path, "/") {
+		prefixed := r.prefix(u.Path)
+		if prefixed != u.Path {
+			changed = true
+			u.Path = prefixed
+			if u.RawPath != "" {
+				u.RawPath = r.prefix(u.RawPath)
+			}
+		}
+	}
+
+	query := r.Query(u.RawQuery)
+	if query != u.RawQuery {
+		changed = true
+		u.RawQuery = query
+	}
+
+	// don't re-encode urls that didn't change
+	if !changed {
+		return raw
+	}
+
+	return u.String()
+}
+
+// Query rewrites absolute urls passed as query parameters, e.g. ?redirect_to=http%3A%2F%2Flocalhost%3A8080%2Fwp-admin%2F
+func (r Rewriter) Query(raw string) string {
+	return mapQuery(raw, func(value string) string {
+		if !r.isAbsolute(value) {
+			return value
+		}
+
+		return r.URL(value)
+	})
+}
+
+// Reverse rewrites the urls the client knows to the ones the application knows
+func (r Rewriter) Reverse(s string) string {
+	return strings.ReplaceAll(s, r.PublicHost+r.BasePath, r.UpstreamHost)
+}
+
+// ReverseQuery rewrites the urls the client knows to the ones the application knows inside a query string or form body
+func (r Rewriter) ReverseQuery(raw string) string {
+	return mapQuery(r.Reverse(raw), r.Reverse)
+}
+
+// prefix adds the base path to a path, unless it already has it
+func (r Rewriter) prefix(path string) string {
+	if r.BasePath == "" || path == r.BasePath || strings.HasPrefix(path, r.BasePath+"/") {
+		return path
+	}
+
+	if path == "" {
+		return r.BasePath + "/"
+	}
+
+	return r.BasePath + path
+}
+
+// isAbsolute checks if a string is an absolute url pointing to the application
+func (r Rewriter) isAbsolute(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	return r.isInternal(u.Scheme, u.Host)
+}
+
+// isInternal checks if a host belongs to the application
+func (r Rewriter) isInternal(scheme string, host string) bool {
+	host = normalizeHost(scheme, host)
+	return host == normalizeHost(scheme, r.UpstreamHost) || host == normalizeHost(scheme, r.PublicHost)
+}
+
+// normalizeHost lowercases a host and strips the default port of the scheme
+func normalizeHost(scheme string, host string) string {
+	host = strings.ToLower(host)
+
+	switch scheme {
+	case "https", "wss":
+		return strings.TrimSuffix(host, ":443")
+	default:
+		return strings.TrimSuffix(host, ":80")
+	}
+}
+
+// mapQuery applies f to every decoded value of a query string, values that don't change keep their original encoding
+func mapQuery(raw string, f func(string) string) string {
+	if raw == "" {
+		return raw
+	}
+
+	pairs := strings.Split(raw, "&")
+	for i, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+
+		mapped := f(decoded)
+		if mapped != decoded {
+			pairs[i] = key + "=" + url.QueryEscape(mapped)
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
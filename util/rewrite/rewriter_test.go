@@ -0,0 +1,188 @@
+package rewrite
+
+import (
+	"flag"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var rewriter = Rewriter{
+	BasePath:     "/exhibit/123",
+	PublicHost:   "localhost:8080",
+	UpstreamHost: "172.17.0.3:80",
+}
+
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, ".golden") {
+			continue
+		}
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			input, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := rewriter.Body(mime.TypeByExtension(filepath.Ext(file)), input)
+
+			if *update {
+				err = os.WriteFile(file+".golden", actual, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(file + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(actual) != string(expected) {
+				t.Errorf("Expected\n%s\ngot\n%s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := map[string]string{
+		"/foo":                            "/exhibit/123/foo",
+		"/exhibit/123/foo":                "/exhibit/123/foo",
+		"/exhibit/123":                    "/exhibit/123",
+		"/exhibit/1234/foo":               "/exhibit/123/exhibit/1234/foo",
+		"foo/bar":                         "foo/bar",
+		"http://172.17.0.3:80/foo":        "http://localhost:8080/exhibit/123/foo",
+		"http://172.17.0.3/foo":           "http://localhost:8080/exhibit/123/foo",
+		"http://172.17.0.3":               "http://localhost:8080/exhibit/123/",
+		"http://LOCALHOST:8080/foo":       "http://localhost:8080/exhibit/123/foo",
+		"https://example.com/foo":         "https://example.com/foo",
+		"//example.com/foo":               "//example.com/foo",
+		"ws://172.17.0.3/socket":          "ws://localhost:8080/exhibit/123/socket",
+		"data:text/plain,/foo":            "data:text/plain,/foo",
+		"#/foo":                           "#/foo",
+		"":                                "",
+		"/foo?next=/bar":                  "/exhibit/123/foo?next=/bar",
+		"/a%20b":                          "/exhibit/123/a%20b",
+		"?redirect=http://localhost:8080": "?redirect=http%3A%2F%2Flocalhost%3A8080%2Fexhibit%2F123%2F",
+	}
+
+	for input, expected := range tests {
+		actual := rewriter.URL(input)
+		if actual != expected {
+			t.Errorf("Expected %q to be rewritten to %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestURLWithoutBasePath(t *testing.T) {
+	r := Rewriter{PublicHost: "wordpress.localhost:8080", UpstreamHost: "172.17.0.3:80"}
+
+	tests := map[string]string{
+		"/foo":                             "/foo",
+		"http://172.17.0.3/foo":            "http://wordpress.localhost:8080/foo",
+		"http://wordpress.localhost:8080/": "http://wordpress.localhost:8080/",
+		"http://localhost:8080/api":        "http://localhost:8080/api",
+	}
+
+	for input, expected := range tests {
+		actual := r.URL(input)
+		if actual != expected {
+			t.Errorf("Expected %q to be rewritten to %q, got %q", input, expected, actual)
+		}
+	}
+
+	if strings.Contains(string(r.HTML([]byte("<html><head></head></html>"))), "<script>") {
+		t.Errorf("Expected no shim to be injected without a base path")
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Location", "http://localhost:8080/wp-admin/")
+	header.Set("Refresh", "0; url=/wp-login.php")
+	header.Set("Link", "</style.css>; rel=preload; as=style, <https://example.com/font.woff2>; rel=preload")
+	header.Add("Set-Cookie", "session=abc; Path=/; HttpOnly")
+	header.Add("Set-Cookie", "wp-settings=1; path=/wp-admin")
+	header.Add("Set-Cookie", "theme=dark")
+	header.Add("Set-Cookie", "lang=de; Domain=wordpress.local; Path=/")
+
+	rewriter.Headers(header)
+
+	expected := map[string][]string{
+		"Location":   {"http://localhost:8080/exhibit/123/wp-admin/"},
+		"Refresh":    {"0; url=/exhibit/123/wp-login.php"},
+		"Link":       {"</exhibit/123/style.css>; rel=preload; as=style, <https://example.com/font.woff2>; rel=preload"},
+		"Set-Cookie": {"session=abc; Path=/exhibit/123/; HttpOnly", "wp-settings=1; path=/exhibit/123/wp-admin", "theme=dark", "lang=de; Path=/exhibit/123/"},
+	}
+
+	for name, values := range expected {
+		actual := header.Values(name)
+		if strings.Join(actual, "\n") != strings.Join(values, "\n") {
+			t.Errorf("Expected %s to be %q, got %q", name, values, actual)
+		}
+	}
+}
+
+func TestCookies(t *testing.T) {
+	r := Rewriter{
+		BasePath:     "/exhibit/123",
+		PublicHost:   "museum.example.com:8080",
+		UpstreamHost: "172.17.0.3:80",
+		CookiePrefix: "0b5e7a4c-1a1b-4c1d-9e1f-123456789abc_",
+	}
+
+	cookie := r.cookie("sessionid=abc; Domain=.wordpress.local; Path=/; Secure")
+	if cookie != "0b5e7a4c-1a1b-4c1d-9e1f-123456789abc_sessionid=abc; Domain=museum.example.com; Path=/exhibit/123/; Secure" {
+		t.Errorf("Expected cookie to be namespaced and scoped to the exhibit, got %q", cookie)
+	}
+
+	header := r.ReverseCookies("0b5e7a4c-1a1b-4c1d-9e1f-123456789abc_sessionid=abc; theme=dark; 11111111-2222-3333-4444-555555555555_sessionid=def")
+	if header != "sessionid=abc; theme=dark" {
+		t.Errorf("Expected cookies of other exhibits to be dropped, got %q", header)
+	}
+
+	if rewriter.ReverseCookies("a=b;c=d") != "a=b;c=d" {
+		t.Errorf("Expected cookies to be passed on as is without a prefix")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	referer := rewriter.Reverse("http://localhost:8080/exhibit/123/wp-admin/")
+	if referer != "http://172.17.0.3:80/wp-admin/" {
+		t.Errorf("Expected referer to point to the application, got %q", referer)
+	}
+
+	query := rewriter.ReverseQuery("log=admin&redirect_to=http%3A%2F%2Flocalhost%3A8080%2Fexhibit%2F123%2Fwp-admin%2F&testcookie=1")
+	if query != "log=admin&redirect_to=http%3A%2F%2F172.17.0.3%3A80%2Fwp-admin%2F&testcookie=1" {
+		t.Errorf("Expected query to point to the application, got %q", query)
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := map[string]Kind{
+		"text/html; charset=utf-8": KindHTML,
+		"TEXT/CSS":                 KindCSS,
+		"application/javascript":   KindScript,
+		"application/json":         KindScript,
+		"image/png":                KindNone,
+		"":                         KindNone,
+	}
+
+	for contentType, expected := range tests {
+		if actual := KindOf(contentType); actual != expected {
+			t.Errorf("Expected %q to be of kind %d, got %d", contentType, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package rewrite
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+//go:embed shim.js
+var shim string
+
+// absolute urls inside scripts and json, relative ones can't be told apart from any other string
+var absoluteUrlReg = regexp.MustCompile(`(?i)(?:https?|wss?)://[a-z0-9.\-]+(?::\d+)?[^\s"'<>` + "`" + `\\)]*`)
+
+// Script rewrites the absolute urls pointing to the application inside a script or json document
+func (r Rewriter) Script(body []byte) []byte {
+	return absoluteUrlReg.ReplaceAllFunc(body, func(match []byte) []byte {
+		return []byte(r.URL(string(match)))
+	})
+}
+
+// shimTag returns a script tag that prefixes urls built by scripts at runtime (fetch, XMLHttpRequest, WebSocket, EventSource and history)
+func (r Rewriter) shimTag() string {
+	basePath, _ := json.Marshal(r.BasePath)
+	return "<script>" + strings.Replace(shim, "__BASE_PATH__", string(basePath), 1) + "</script>"
+}
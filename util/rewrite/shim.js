@@ -0,0 +1,47 @@
+(function () {
+  if (window.__museum) return;
+  window.__museum = true;
+  var b = __BASE_PATH__;
+  function r(u) {
+    if (typeof u !== "string" && !(u instanceof URL)) return u;
+    try {
+      var p = new URL(u, document.baseURI);
+      if (p.host !== location.host) return u;
+      if (p.pathname === b || p.pathname.indexOf(b + "/") === 0) return u;
+      p.pathname = b + p.pathname;
+      return p.href;
+    } catch (e) {
+      return u;
+    }
+  }
+  function wrap(C) {
+    if (!C) return C;
+    var W = function (u, o) {
+      return o === undefined ? new C(r(u)) : new C(r(u), o);
+    };
+    W.prototype = C.prototype;
+    Object.getOwnPropertyNames(C).forEach(function (k) {
+      if (!(k in W)) try { W[k] = C[k]; } catch (e) {}
+    });
+    return W;
+  }
+  if (window.fetch) {
+    var f = window.fetch;
+    window.fetch = function (i, o) {
+      return f.call(this, i instanceof Request ? i : r(i), o);
+    };
+  }
+  var open = XMLHttpRequest.prototype.open;
+  XMLHttpRequest.prototype.open = function (m, u) {
+    arguments[1] = r(u);
+    return open.apply(this, arguments);
+  };
+  window.WebSocket = wrap(window.WebSocket);
+  window.EventSource = wrap(window.EventSource);
+  ["pushState", "replaceState"].forEach(function (k) {
+    var h = history[k];
+    history[k] = function (s, t, u) {
+      return u == null ? h.call(this, s, t) : h.call(this, s, t, r(u));
+    };
+  });
+})();
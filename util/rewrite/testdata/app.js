@@ -0,0 +1,4 @@
+const base = "http://localhost:8080/wp-json/wp/v2";
+const socket = new WebSocket('ws://172.17.0.3/live');
+const external = `https://example.com/api`;
+fetch("/relative/urls/are/handled/by/the/shim");
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a mistake in an exhibit definition, warnings don't keep an exhibit from being created
+type Problem struct {
+	Severity Severity `json:"severity"`
+	// Path is the position of the problem in the definition, e.g. objects[1].livecheck.type
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Line and Column are only known if the definition was read from yaml (or json)
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (p Problem) String() string {
+	position := ""
+	if p.Line > 0 {
+		position = strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column) + ": "
+	}
+
+	path := ""
+	if p.Path != "" {
+		path = " (" + p.Path + ")"
+	}
+
+	return position + string(p.Severity) + ": " + p.Message + path
+}
+
+// Problems are all problems found in a definition, they are an error if there is at least one error among them
+type Problems []Problem
+
+func (p *Problems) errorf(path string, message string) {
+	*p = append(*p, Problem{Severity: SeverityError, Path: path, Message: message})
+}
+
+func (p *Problems) warnf(path string, message string) {
+	*p = append(*p, Problem{Severity: SeverityWarning, Path: path, Message: message})
+}
+
+func (p Problems) HasErrors() bool {
+	for _, problem := range p {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Problems) Errors() Problems {
+	return p.filter(SeverityError)
+}
+
+func (p Problems) Warnings() Problems {
+	return p.filter(SeverityWarning)
+}
+
+func (p Problems) filter(severity Severity) Problems {
+	filtered := make(Problems, 0)
+	for _, problem := range p {
+		if problem.Severity == severity {
+			filtered = append(filtered, problem)
+		}
+	}
+
+	return filtered
+}
+
+// Error lists the errors, warnings are left out
+func (p Problems) Error() string {
+	messages := make([]string, 0, len(p))
+	for _, problem := range p.Errors() {
+		messages = append(messages, problem.String())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Result is the answer to a dry run, Error is only set if the definition is invalid
+type Result struct {
+	Valid    bool     `json:"valid"`
+	Problems Problems `json:"problems"`
+	Error    string   `json:"error,omitempty"`
+}
+
+func NewResult(problems Problems) Result {
+	return Result{Valid: !problems.HasErrors(), Problems: problems, Error: problems.Error()}
+}
@@ -0,0 +1,362 @@
+package validation
+
+import (
+	"github.com/docker/go-units"
+	"museum/domain"
+	"museum/spec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exhibits served on their own host need a name that is a valid DNS label
+var hostNameRegex = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+// templateRegex matches every template in an environment value, known ones are {{ host }} and {{ @object }}
+var templateRegex = regexp.MustCompile(`\{\{([^}]*)}}`)
+
+// capabilities are e.g. NET_ADMIN or CAP_NET_ADMIN
+var capabilityRegex = regexp.MustCompile("^[A-Z_]+$")
+
+// users are a name or id with an optional group, e.g. www-data or 1000:1000
+var userRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+(:[a-zA-Z0-9_.-]+)?$`)
+
+var livecheckMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "DELETE": true}
+
+// Validator checks exhibit definitions, the checks that need the state of a museum instance are optional
+type Validator struct {
+	// HostRouting requires exhibit names to be valid DNS labels
+	HostRouting bool
+	// NameTaken reports whether another exhibit than the one with the given id uses a name, nil skips the check
+	NameTaken func(name string, id string) bool
+	// CheckVolume checks the driver config of a volume and whether it may be a scratch volume, nil skips the check
+	CheckVolume func(volume domain.Volume) error
+	// CheckSnapshot checks that the snapshot a volume of an exhibit is pinned to exists, nil skips the check
+	CheckSnapshot func(exhibitId string, volume domain.Volume) error
+	// Profile is the default profile objects may only relax with security.relax, nil skips the check
+	Profile *domain.Profile
+}
+
+// Validate returns all problems of an exhibit definition
+func (v Validator) Validate(exhibit domain.Exhibit) Problems {
+	problems := make(Problems, 0)
+
+	if err := spec.Check(exhibit.Spec); err != nil {
+		problems.errorf("spec", err.Error())
+	}
+
+	v.validateName(exhibit, &problems)
+	objects := validateObjects(exhibit, &problems)
+	v.validateProfiles(exhibit, &problems)
+	validateExpose(exhibit, objects, &problems)
+	validateOrder(exhibit, objects, &problems)
+	validateDependencies(exhibit, objects, &problems)
+	v.validateVolumes(exhibit, &problems)
+
+	_, err := time.ParseDuration(exhibit.Lease)
+	if err != nil {
+		problems.errorf("lease", "lease time must be a valid duration")
+	}
+
+	if _, err := exhibit.Proxy.GetTimeout(); err != nil {
+		problems.errorf("proxy.timeout", err.Error())
+	}
+
+	if _, err := exhibit.Proxy.GetIdleTimeout(); err != nil {
+		problems.errorf("proxy.idleTimeout", err.Error())
+	}
+
+	if _, err := exhibit.Proxy.GetMaxBodySize(); err != nil {
+		problems.errorf("proxy.maxBodySize", err.Error())
+	}
+
+	return problems
+}
+
+func (v Validator) validateName(exhibit domain.Exhibit, problems *Problems) {
+	if exhibit.Name == "" {
+		problems.errorf("name", "exhibit must have a name")
+		return
+	}
+
+	if v.NameTaken != nil && v.NameTaken(exhibit.Name, exhibit.Id) {
+		problems.errorf("name", "exhibit with name "+exhibit.Name+" already exists")
+	}
+
+	if v.HostRouting && !hostNameRegex.MatchString(exhibit.Name) {
+		problems.errorf("name", "exhibit name "+exhibit.Name+" must be a valid DNS label (lowercase letters, digits and dashes) when routing by host")
+	}
+}
+
+// validateObjects checks the objects and returns them by name
+func validateObjects(exhibit domain.Exhibit, problems *Problems) map[string]domain.Object {
+	objects := make(map[string]domain.Object)
+
+	if len(exhibit.Objects) == 0 {
+		problems.errorf("objects", "exhibit must have at least one object")
+	}
+
+	for i, object := range exhibit.Objects {
+		path := "objects[" + strconv.Itoa(i) + "]"
+
+		if object.Name == "" {
+			problems.errorf(path+".name", "object must have a name")
+		} else if _, ok := objects[object.Name]; ok {
+			problems.errorf(path+".name", "object name "+object.Name+" is used twice")
+		}
+
+		if object.Image == "" {
+			problems.errorf(path+".image", "object must have an image")
+		}
+
+		objects[object.Name] = object
+	}
+
+	for i, object := range exhibit.Objects {
+		path := "objects[" + strconv.Itoa(i) + "]"
+
+		validateTemplates(object, objects, path, problems)
+		validateLivecheck(object, path, problems)
+
+		for mount := range object.Mounts {
+			found := false
+			for _, volume := range exhibit.Volumes {
+				if volume.Name == mount {
+					found = true
+				}
+			}
+
+			if !found {
+				problems.errorf(path+".mounts."+mount, "mount "+mount+" does not have a corresponding volume")
+			}
+		}
+	}
+
+	return objects
+}
+
+// validateTemplates checks that environment templates reference objects of the exhibit
+func validateTemplates(object domain.Object, objects map[string]domain.Object, path string, problems *Problems) {
+	for key, value := range object.Environment {
+		for _, match := range templateRegex.FindAllStringSubmatch(value, -1) {
+			template := match[1]
+			name, isReference := domain.ObjectReference(template)
+
+			switch {
+			case isReference:
+				if _, ok := objects[name]; !ok {
+					problems.errorf(path+".environment."+key, "template "+match[0]+" references unknown object "+name)
+				}
+			case !domain.IsHostTemplate(template):
+				problems.warnf(path+".environment."+key, "unknown template "+match[0]+", it is passed on as is")
+			}
+		}
+	}
+}
+
+func validateLivecheck(object domain.Object, path string, problems *Problems) {
+	l := object.Livecheck
+	if l == nil {
+		return
+	}
+
+	path += ".livecheck"
+
+	switch l.Type {
+	case domain.LivecheckTypeHttp:
+		if method, ok := l.Config["method"]; ok && !livecheckMethods[method] {
+			problems.errorf(path+".config.method", "http livecheck method must be one of: GET, POST, PUT, DELETE (in object "+object.Name+")")
+		}
+
+		if status, ok := l.Config["status"]; ok {
+			if _, err := strconv.Atoi(status); err != nil {
+				problems.errorf(path+".config.status", "http livecheck status must be a valid integer (in object "+object.Name+")")
+			}
+		}
+
+		if port, ok := l.Config["port"]; ok {
+			if _, err := strconv.Atoi(port); err != nil {
+				problems.errorf(path+".config.port", "http livecheck port must be a valid integer (in object "+object.Name+")")
+			}
+		}
+	case domain.LivecheckTypeExec:
+		if _, ok := l.Config["command"]; !ok {
+			problems.warnf(path+".config", "exec livecheck has no command, it always succeeds (in object "+object.Name+")")
+		}
+	default:
+		problems.errorf(path+".type", "livecheck type must be one of: "+domain.LivecheckTypeHttp+", "+domain.LivecheckTypeExec+" (in object "+object.Name+")")
+	}
+}
+
+func validateExpose(exhibit domain.Exhibit, objects map[string]domain.Object, problems *Problems) {
+	if exhibit.Expose == "" {
+		problems.errorf("expose", "exhibit must expose a container")
+		return
+	}
+
+	if _, ok := objects[exhibit.Expose]; !ok {
+		problems.errorf("expose", "exhibit must expose a container that is part of the exhibit")
+	}
+}
+
+// validateOrder checks the start order, objects that are missing from it are never started
+func validateOrder(exhibit domain.Exhibit, objects map[string]domain.Object, problems *Problems) {
+	if exhibit.Order == nil {
+		return
+	}
+
+	ordered := make(map[string]bool)
+	for i, name := range exhibit.Order {
+		path := "order[" + strconv.Itoa(i) + "]"
+
+		if _, ok := objects[name]; !ok {
+			problems.errorf(path, "order references unknown object "+name)
+		}
+
+		if ordered[name] {
+			problems.errorf(path, "object "+name+" is ordered twice")
+		}
+		ordered[name] = true
+	}
+
+	for i, object := range exhibit.Objects {
+		if !ordered[object.Name] {
+			problems.warnf("objects["+strconv.Itoa(i)+"]", "object "+object.Name+" is not part of order and won't be started")
+		}
+	}
+}
+
+// validateDependencies checks that objects depend on objects that are started and don't depend on each other
+func validateDependencies(exhibit domain.Exhibit, objects map[string]domain.Object, problems *Problems) {
+	ordered := make(map[string]bool)
+	for _, name := range exhibit.Order {
+		ordered[name] = true
+	}
+
+	for i, object := range exhibit.Objects {
+		for j, name := range object.DependsOn {
+			path := "objects[" + strconv.Itoa(i) + "].dependsOn[" + strconv.Itoa(j) + "]"
+
+			if _, ok := objects[name]; !ok {
+				problems.errorf(path, "dependsOn references unknown object "+name)
+				continue
+			}
+
+			if exhibit.Order != nil && ordered[object.Name] && !ordered[name] {
+				problems.errorf(path, "object "+object.Name+" depends on "+name+", which is not part of order and won't be started")
+			}
+		}
+	}
+
+	cycle := exhibit.DependencyCycle()
+	if cycle == nil {
+		return
+	}
+
+	// the cycle is reported at the dependsOn of its first object, unless it only exists through order
+	path := "order"
+	for i, object := range exhibit.Objects {
+		if object.Name == cycle[0] && len(object.DependsOn) != 0 {
+			path = "objects[" + strconv.Itoa(i) + "].dependsOn"
+		}
+	}
+
+	problems.errorf(path, "objects depend on each other: "+strings.Join(cycle, " -> "))
+}
+
+// validateProfiles checks the resources and security settings of the objects
+func (v Validator) validateProfiles(exhibit domain.Exhibit, problems *Problems) {
+	for i, object := range exhibit.Objects {
+		path := "objects[" + strconv.Itoa(i) + "]"
+
+		if r := object.Resources; r != nil {
+			if r.Cpus != "" {
+				if cpus, err := strconv.ParseFloat(r.Cpus, 64); err != nil || cpus <= 0 {
+					problems.errorf(path+".resources.cpus", "cpus must be a positive number (in object "+object.Name+")")
+				}
+			}
+
+			if r.Memory != "" {
+				if memory, err := units.RAMInBytes(r.Memory); err != nil || memory <= 0 {
+					problems.errorf(path+".resources.memory", "memory must be a positive size, e.g. 512m (in object "+object.Name+")")
+				}
+			}
+
+			if r.Pids < 0 {
+				problems.errorf(path+".resources.pids", "pids must be positive (in object "+object.Name+")")
+			}
+		}
+
+		if s := object.Security; s != nil {
+			for j, tmpfs := range s.Tmpfs {
+				if !strings.HasPrefix(tmpfs, "/") {
+					problems.errorf(path+".security.tmpfs["+strconv.Itoa(j)+"]", "tmpfs must be an absolute path (in object "+object.Name+")")
+				}
+			}
+
+			validateCapabilities(object, s.CapDrop, path+".security.capDrop", problems)
+			validateCapabilities(object, s.CapAdd, path+".security.capAdd", problems)
+
+			if s.Seccomp != "" && s.Seccomp != domain.SeccompDefault && s.Seccomp != domain.SeccompUnconfined && !strings.HasPrefix(s.Seccomp, "/") {
+				problems.errorf(path+".security.seccomp", "seccomp must be one of: "+domain.SeccompDefault+", "+domain.SeccompUnconfined+" or the absolute path of a profile (in object "+object.Name+")")
+			}
+
+			if s.User != "" && !userRegex.MatchString(s.User) {
+				problems.errorf(path+".security.user", "user must be a name or id with an optional group, e.g. 1000:1000 (in object "+object.Name+")")
+			}
+		}
+
+		if v.Profile == nil || v.Profile.Effective(object).Security.Relax {
+			continue
+		}
+
+		for _, relaxation := range v.Profile.Relaxations(object) {
+			problems.errorf(path+"."+relaxation.Path, relaxation.Message+", set security.relax to allow it (in object "+object.Name+")")
+		}
+	}
+}
+
+func validateCapabilities(object domain.Object, capabilities []string, path string, problems *Problems) {
+	for i, c := range capabilities {
+		if !capabilityRegex.MatchString(domain.NormalizeCapability(c)) {
+			problems.errorf(path+"["+strconv.Itoa(i)+"]", "capability "+c+" is not valid (in object "+object.Name+")")
+		}
+	}
+}
+
+func (v Validator) validateVolumes(exhibit domain.Exhibit, problems *Problems) {
+	names := make(map[string]bool)
+
+	for i, volume := range exhibit.Volumes {
+		path := "volumes[" + strconv.Itoa(i) + "]"
+
+		if volume.Name == "" {
+			problems.errorf(path+".name", "volume must have a name")
+		} else if err := domain.CheckVolumeName(volume.Name); err != nil {
+			problems.errorf(path+".name", err.Error())
+		} else if names[volume.Name] {
+			problems.errorf(path+".name", "volume name "+volume.Name+" is used twice")
+		}
+		names[volume.Name] = true
+
+		if volume.Snapshot != "" {
+			if err := domain.CheckSnapshotName(volume.Snapshot); err != nil {
+				problems.errorf(path+".snapshot", err.Error())
+			} else if v.CheckSnapshot != nil && domain.CheckVolumeName(volume.Name) == nil {
+				if err := v.CheckSnapshot(exhibit.Id, volume); err != nil {
+					problems.errorf(path+".snapshot", err.Error())
+				}
+			}
+		}
+
+		if v.CheckVolume == nil {
+			continue
+		}
+
+		err := v.CheckVolume(volume)
+		if err != nil {
+			problems.errorf(path+".driver", err.Error())
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package validation
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"museum/domain"
+	"museum/spec"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// yaml errors carry their position in the message only, e.g. "yaml: line 3: mapping values are not allowed in this context"
+var syntaxErrorRegex = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+var typeErrorRegex = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// Lint reads an exhibit definition from yaml (or json, which is yaml as well) and validates it,
+// the problems carry the line and column they were found at
+func Lint(content []byte, v Validator) (domain.Exhibit, Problems) {
+	exhibit := domain.Exhibit{}
+	problems := make(Problems, 0)
+
+	root := &yaml.Node{}
+	err := yaml.Unmarshal(content, root)
+	if err != nil {
+		problems = append(problems, yamlProblem(syntaxErrorRegex, err.Error()))
+		return exhibit, problems
+	}
+
+	if len(root.Content) == 0 {
+		problems.errorf("", "exhibit definition is empty")
+		return exhibit, problems
+	}
+
+	document := root.Content[0]
+	if document.Kind != yaml.MappingNode {
+		problems = append(problems, Problem{Severity: SeverityError, Message: "exhibit definition must be a mapping", Line: document.Line, Column: document.Column})
+		return exhibit, problems
+	}
+
+	// older spec versions are upgraded first, a missing spec is reported by the validator
+	err = spec.Upgrade(document)
+	if err != nil && !errors.Is(err, spec.ErrMissingSpec) {
+		node := spec.VersionNode(document)
+		problems = append(problems, Problem{Severity: SeverityError, Path: "spec", Message: err.Error(), Line: node.Line, Column: node.Column})
+		return exhibit, problems
+	}
+
+	// decoding goes on after type errors, the rest of the definition can still be validated
+	err = document.Decode(&exhibit)
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) {
+		for _, e := range typeError.Errors {
+			problems = append(problems, yamlProblem(typeErrorRegex, e))
+		}
+	} else if err != nil {
+		problems.errorf("", err.Error())
+		return exhibit, problems
+	}
+
+	unknownKeys(document, reflect.TypeOf(exhibit), "", &problems)
+	problems = append(problems, v.Validate(exhibit)...)
+
+	positions := make(map[string]*yaml.Node)
+	collectPositions(document, "", positions)
+	for i := range problems {
+		if problems[i].Line == 0 {
+			problems[i].Line, problems[i].Column = position(positions, problems[i].Path)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return exhibit, problems
+}
+
+func yamlProblem(regex *regexp.Regexp, message string) Problem {
+	problem := Problem{Severity: SeverityError, Message: strings.TrimPrefix(message, "yaml: ")}
+
+	matches := regex.FindStringSubmatch(message)
+	if len(matches) == 3 {
+		problem.Line, _ = strconv.Atoi(matches[1])
+		problem.Message = matches[2]
+	}
+
+	return problem
+}
+
+func childPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// unknownKeys warns about keys that don't belong to a field of t, they would be dropped silently
+func unknownKeys(node *yaml.Node, t reflect.Type, path string, problems *Problems) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+
+		for i, item := range node.Content {
+			unknownKeys(item, t.Elem(), path+"["+strconv.Itoa(i)+"]", problems)
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			field, ok := fieldByKey(t, key.Value)
+			if !ok {
+				problems.warnf(childPath(path, key.Value), "unknown key "+key.Value+", it is ignored")
+				continue
+			}
+
+			unknownKeys(value, field.Type, childPath(path, key.Value), problems)
+		}
+	default:
+		// maps (e.g. environment) and interfaces (e.g. meta) take any key
+	}
+}
+
+// fieldByKey finds the field a yaml key is decoded into, fields without a tag use their lowercased name
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		if name == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// collectPositions maps the paths of a document to their nodes, mapping entries point to their key
+func collectPositions(node *yaml.Node, path string, positions map[string]*yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			p := childPath(path, key.Value)
+
+			positions[p] = key
+			collectPositions(value, p, positions)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p := path + "[" + strconv.Itoa(i) + "]"
+
+			positions[p] = item
+			collectPositions(item, p, positions)
+		}
+	}
+}
+
+// position returns the line and column of a path, a path missing from the document (e.g. a required key) falls back to its parent
+func position(positions map[string]*yaml.Node, path string) (int, int) {
+	for path != "" {
+		if node, ok := positions[path]; ok {
+			return node.Line, node.Column
+		}
+
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+
+	return 0, 0
+}
@@ -0,0 +1,247 @@
+package validation
+
+import (
+	"fmt"
+	"museum/domain"
+	"testing"
+)
+
+const invalidExhibit = `spec: v1
+name: test
+expose: web
+lease: 10m
+order:
+  - db
+  - cache
+objects:
+  - name: web
+    image: nginx
+    environment:
+      DB_HOST: "{{ @database }}"
+      URL: "{{ host }}"
+    livecheck:
+      type: tcp
+  - name: db
+    image: postgres
+    imagee: postgres
+`
+
+func find(problems Problems, path string) (Problem, bool) {
+	for _, problem := range problems {
+		if problem.Path == path {
+			return problem, true
+		}
+	}
+
+	return Problem{}, false
+}
+
+func TestLintReportsAllProblems(t *testing.T) {
+	_, problems := Lint([]byte(invalidExhibit), Validator{})
+
+	expected := []struct {
+		path     string
+		severity Severity
+		line     int
+	}{
+		{"order[1]", SeverityError, 7},
+		{"objects[0].environment.DB_HOST", SeverityError, 12},
+		{"objects[0].livecheck.type", SeverityError, 15},
+		{"objects[1].imagee", SeverityWarning, 18},
+		{"objects[0]", SeverityWarning, 9},
+	}
+
+	for _, e := range expected {
+		problem, ok := find(problems, e.path)
+		if !ok {
+			t.Errorf("Expected a problem at %s, got %v", e.path, problems)
+			continue
+		}
+
+		if problem.Severity != e.severity || problem.Line != e.line {
+			t.Errorf("Expected %s at line %d for %s, got %s", e.severity, e.line, e.path, problem)
+		}
+	}
+
+	if _, ok := find(problems, "objects[0].environment.URL"); ok {
+		t.Errorf("Expected {{ host }} to be a known template")
+	}
+
+	if _, ok := find(problems, "spec"); ok {
+		t.Errorf("Expected spec not to be an unknown key")
+	}
+}
+
+func TestLintReportsSyntaxErrorLine(t *testing.T) {
+	_, problems := Lint([]byte("name: test\nobjects: [\n"), Validator{})
+
+	if len(problems) != 1 || problems[0].Line == 0 {
+		t.Errorf("Expected a single syntax error with a line, got %v", problems)
+	}
+}
+
+func TestLintReportsTypeErrors(t *testing.T) {
+	_, problems := Lint([]byte("spec: v1\nname: test\nexpose: web\nlease: 10m\nobjects:\n  - name: web\n    image: nginx\n    port: [80]\n"), Validator{})
+
+	if len(problems) != 1 || problems[0].Line != 8 {
+		t.Errorf("Expected a type error at line 8, got %v", problems)
+	}
+}
+
+func TestLintRejectsUnsupportedSpec(t *testing.T) {
+	_, problems := Lint([]byte("name: test\nspec: v0\n"), Validator{})
+
+	if len(problems) != 1 || problems[0].Path != "spec" || problems[0].Line != 2 {
+		t.Errorf("Expected only the unsupported spec at line 2, got %v", problems)
+	}
+}
+
+func TestLintReportsDependencyProblems(t *testing.T) {
+	content := `spec: v1
+name: test
+expose: web
+lease: 10m
+objects:
+  - name: web
+    image: nginx
+    dependsOn: [api, queue]
+  - name: api
+    image: api
+    dependsOn: [db]
+  - name: db
+    image: postgres
+    dependsOn: [web]
+`
+	_, problems := Lint([]byte(content), Validator{})
+
+	if problem, ok := find(problems, "objects[0].dependsOn[1]"); !ok || problem.Line != 8 {
+		t.Errorf("Expected the unknown object queue at line 8, got %v", problems)
+	}
+
+	if problem, ok := find(problems, "objects[0].dependsOn"); !ok || problem.Message != "objects depend on each other: web -> api -> db -> web" {
+		t.Errorf("Expected the cycle web -> api -> db -> web, got %v", problems)
+	}
+}
+
+func TestLintRequiresRelaxForLooserProfile(t *testing.T) {
+	content := `spec: v1
+name: test
+expose: web
+lease: 10m
+objects:
+  - name: web
+    image: nginx
+    resources:
+      memory: 2g
+    security:
+      readOnly: false
+  - name: legacy
+    image: php
+    resources:
+      memory: 2g
+    security:
+      relax: true
+`
+	readOnly := true
+	profile := domain.Profile{
+		Resources: domain.Resources{Memory: "1g"},
+		Security:  domain.Security{ReadOnly: &readOnly},
+	}
+
+	_, problems := Lint([]byte(content), Validator{Profile: &profile})
+
+	if _, ok := find(problems, "objects[0].resources.memory"); !ok {
+		t.Errorf("Expected memory above the default limit to be rejected, got %v", problems)
+	}
+
+	if _, ok := find(problems, "objects[0].security.readOnly"); !ok {
+		t.Errorf("Expected a writable root filesystem to be rejected, got %v", problems)
+	}
+
+	if _, ok := find(problems, "objects[1].resources.memory"); ok {
+		t.Errorf("Expected relaxed objects to pass, got %v", problems)
+	}
+}
+
+func TestLintRejectsSeccompProfilesWithoutRelax(t *testing.T) {
+	content := `spec: v1
+name: test
+expose: web
+lease: 10m
+objects:
+  - name: web
+    image: nginx
+    security:
+      seccomp: /etc/shadow
+  - name: legacy
+    image: php
+    security:
+      seccomp: /etc/museum/seccomp.json
+      relax: true
+`
+	_, problems := Lint([]byte(content), Validator{Profile: &domain.Profile{}})
+
+	if problem, ok := find(problems, "objects[0].security.seccomp"); !ok || problem.Line != 9 {
+		t.Errorf("Expected a seccomp profile on the host to need relax, got %v", problems)
+	}
+
+	if _, ok := find(problems, "objects[1].security.seccomp"); ok {
+		t.Errorf("Expected relaxed objects to pass, got %v", problems)
+	}
+}
+
+func TestLintRejectsVolumeNamesLeavingTheirDirectory(t *testing.T) {
+	content := `spec: v1
+name: test
+expose: web
+lease: 10m
+objects:
+  - name: web
+    image: nginx
+    mounts:
+      ../../..: /data
+volumes:
+  - name: ../../..
+    scratch: true
+    driver:
+      type: local
+      config:
+        path: /srv/data
+`
+	_, problems := Lint([]byte(content), Validator{})
+
+	if problem, ok := find(problems, "volumes[0].name"); !ok || problem.Line != 11 {
+		t.Errorf("Expected the volume name to be rejected at line 11, got %v", problems)
+	}
+}
+
+func TestLintRejectsMissingPinnedSnapshot(t *testing.T) {
+	content := `spec: v1
+name: test
+expose: web
+lease: 10m
+objects:
+  - name: web
+    image: nginx
+    mounts:
+      data: /data
+volumes:
+  - name: data
+    snapshot: original
+    driver:
+      type: local
+      config:
+        path: /srv/data
+`
+	validator := Validator{
+		CheckSnapshot: func(exhibitId string, volume domain.Volume) error {
+			return fmt.Errorf("%w: %s", domain.ErrSnapshotNotFound, volume.Snapshot)
+		},
+	}
+
+	_, problems := Lint([]byte(content), validator)
+
+	if problem, ok := find(problems, "volumes[0].snapshot"); !ok || problem.Line != 12 {
+		t.Errorf("Expected the missing snapshot to be reported at line 12, got %v", problems)
+	}
+}